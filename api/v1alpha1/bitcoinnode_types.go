@@ -21,22 +21,39 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// Supported node implementations
+const (
+	ImplementationBtcd     = "btcd"
+	ImplementationBitcoind = "bitcoind"
+)
+
+// AppliedSpecHashAnnotation records on a StatefulSet or Service the hash of the spec the operator last applied, so that
+// fields removed from the desired spec are detected
+const AppliedSpecHashAnnotation = "bitcoin.kiln-fired.github.io/applied-spec-hash"
+
 type BTCDContainerImages struct {
 
 	// BTCD container image
 	// +kubebuilder:default:="quay.io/kiln-fired/btcd:latest"
 	BtcdImage string `json:"btcdImage,omitempty"`
 
-	// Mining timer container image
+	// Mining timer container image. Deprecated: periodic blocks are mined by the operator and the image is no longer
+	// used.
 	// +kubebuilder:default:="quay.io/kiln-fired/btcd:latest"
 	TimerImage string `json:"btcdTimerImage,omitemply"`
+
+	// Bitcoin Core container image, used by the bitcoind implementation
+	// +kubebuilder:default:="docker.io/lightninglabs/bitcoin-core:24"
+	BitcoindImage string `json:"bitcoindImage,omitempty"`
 }
 
 type RPCServer struct {
-	// Name of the secret that contains TLS certificates for the RPC server
+	// Name of the secret that contains TLS certificates for the RPC server. Defaults to <name>-rpc-tls, and a
+	// self-signed certificate is generated when the secret does not exist.
 	CertSecret string `json:"certSecret,omitempty"`
 
-	// Name of the secret that contains RPC API credentials
+	// Name of the secret that contains RPC API credentials. Defaults to <name>-rpc-creds, and a random username and
+	// password are generated when the secret does not exist.
 	ApiAuthSecretName string `json:"apiAuthSecretName,omiteempty"`
 
 	// Name of the secret key that contains RPC API username
@@ -79,6 +96,103 @@ type Mining struct {
 	// +optional
 	// +kubebuilder:default:=30
 	SecondsPerBlock int64 `json:"secondsPerBlock,omitempty"`
+
+	// Distribution of the intervals between periodic blocks, a block every secondsPerBlock when unset
+	// +optional
+	Schedule *BlockSchedule `json:"schedule,omitempty"`
+}
+
+const (
+	// BlockScheduleFixed mines a block every secondsPerBlock
+	BlockScheduleFixed = "fixed"
+	// BlockScheduleExponential mines blocks at exponentially distributed intervals, like the blocks of a real network
+	BlockScheduleExponential = "exponential"
+	// BlockScheduleScripted mines blocks on a list of steps
+	BlockScheduleScripted = "scripted"
+)
+
+type BlockSchedule struct {
+	// Type of the schedule, fixed, exponential or scripted
+	// +kubebuilder:validation:Enum=fixed;exponential;scripted
+	// +kubebuilder:default:="fixed"
+	Type string `json:"type,omitempty"`
+
+	// Mean interval in seconds of an exponential schedule, secondsPerBlock when unset
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MeanSeconds int64 `json:"meanSeconds,omitempty"`
+
+	// Steps of a scripted schedule, executed in order
+	// +optional
+	Steps []BlockScheduleStep `json:"steps,omitempty"`
+
+	// Start over with the first step after the last step of a scripted schedule, instead of stopping
+	// +optional
+	Repeat bool `json:"repeat,omitempty"`
+}
+
+type BlockScheduleStep struct {
+	// Number of blocks to mine at once, more than one for a burst
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default:=1
+	Blocks int64 `json:"blocks,omitempty"`
+
+	// Number of seconds to wait after the blocks of the step before the next step
+	// +kubebuilder:validation:Minimum=1
+	Seconds int64 `json:"seconds"`
+}
+
+type PeerSelector struct {
+	// Labels of the BitcoinNodes to connect to, an empty selector selects every BitcoinNode
+	LabelSelector metav1.LabelSelector `json:"labelSelector"`
+
+	// Labels of the namespaces to select BitcoinNodes in, only the namespace of the BitcoinNode when unset. Other
+	// namespaces require the operator to run with --allow-cross-namespace-peers.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+type PeerConnection struct {
+	// Host and port the node connects to
+	Address string `json:"address"`
+
+	// Namespace and name of the BitcoinNode selected by peerSelector, empty for the peers of the spec
+	// +optional
+	BitcoinNode string `json:"bitcoinNode,omitempty"`
+
+	// State of the connection
+	// +kubebuilder:validation:Enum=Connected;Connecting;Isolated;Failed
+	State string `json:"state"`
+
+	// Error of the last attempt to connect
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	// PeerConnected means the node has a connection to the peer
+	PeerConnected = "Connected"
+	// PeerConnecting means the peer was added to the node, which has not connected to it yet
+	PeerConnecting = "Connecting"
+	// PeerIsolated means a Reorg keeps the node apart from the peer
+	PeerIsolated = "Isolated"
+	// PeerFailed means the node refused to add the peer
+	PeerFailed = "Failed"
+)
+
+type BitcoinNodePorts struct {
+	// Peer-to-peer port, the default port of the network when unset
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	P2P int32 `json:"p2p,omitempty"`
+
+	// RPC port, the default port of the network when unset
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	RPC int32 `json:"rpc,omitempty"`
 }
 
 // BitcoinNodeSpec defines the desired state of BitcoinNode
@@ -89,13 +203,36 @@ type BitcoinNodeSpec struct {
 	// Container image overrides
 	ContainerImages BTCDContainerImages `json:"image,omitempty"`
 
+	// Node implementation to run. bitcoind does not support simnet and mines with generatetoaddress, so it requires
+	// a reward address to mine blocks.
+	// +kubebuilder:validation:Enum=btcd;bitcoind
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="implementation is immutable"
+	// +kubebuilder:default:="btcd"
+	Implementation string `json:"implementation,omitempty"`
+
+	// Bitcoin network, e.g. simnet, regtest, testnet, signet, mainnet
+	// +kubebuilder:default:="simnet"
+	Network string `json:"network,omitempty"`
+
+	// Overrides of the default ports of the network
+	// +optional
+	Ports BitcoinNodePorts `json:"ports,omitempty"`
+
 	// Configuration for the RPC Server
 	RPCServer RPCServer `json:"rpcServer,omitempty"`
 
-	// Host and port of peer to connect
+	// Host and port of peer to connect. Deprecated: use peers, the peer is connected like an entry of peers.
 	// +optional
 	Peer string `json:"peer,omitempty"`
 
+	// Hosts and ports of peers to connect. Peers removed from the list are disconnected.
+	// +optional
+	Peers []string `json:"peers,omitempty"`
+
+	// Selects other BitcoinNodes on the same network to connect to
+	// +optional
+	PeerSelector *PeerSelector `json:"peerSelector,omitempty"`
+
 	// Mining configuration
 	// +optional
 	Mining Mining `json:"mining,omitempty"`
@@ -110,11 +247,90 @@ type BitcoinNodeSpec struct {
 type BitcoinNodeStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
-	LastBlockCount int64 `json:"LastBlockCount"`
+
+	// Number of blocks in the best chain of the node
+	// +optional
+	LastBlockCount int64 `json:"lastBlockCount,omitempty"`
+
+	// Hash of the best block of the node
+	// +optional
+	BestBlockHash string `json:"bestBlockHash,omitempty"`
+
+	// Proof-of-work difficulty of the best block
+	// +optional
+	Difficulty string `json:"difficulty,omitempty"`
+
+	// Number of connected peers
+	// +optional
+	Peers int32 `json:"peers,omitempty"`
+
+	// Connection state of each peer the node is configured or selected to connect to
+	// +optional
+	PeerConnections []PeerConnection `json:"peerConnections,omitempty"`
+
+	// Number of transactions in the mempool
+	// +optional
+	MempoolSize int64 `json:"mempoolSize,omitempty"`
+
+	// Version of the node software
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Chain the node reports it is running on
+	// +optional
+	Network string `json:"network,omitempty"`
+
+	// Conditions represent the latest available observations of the BitcoinNode's state
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// Fingerprint of the Seed key material the mining reward address was provisioned from
+	// +optional
+	SeedFingerprint string `json:"seedFingerprint,omitempty"`
+
+	// Generation of the BitcoinNode that was last reconciled
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Time the operator last mined a periodic block
+	// +optional
+	LastMinedTime *metav1.Time `json:"lastMinedTime,omitempty"`
+
+	// Height of the chain after the last periodic block
+	// +optional
+	LastMinedHeight int64 `json:"lastMinedHeight,omitempty"`
+
+	// Interval drawn for the next block of an exponential or scripted schedule
+	// +optional
+	NextBlockInterval *metav1.Duration `json:"nextBlockInterval,omitempty"`
+
+	// Index of the next step of a scripted schedule
+	// +optional
+	ScheduleStep int32 `json:"scheduleStep,omitempty"`
+
+	// Hash of the block schedule the next block interval and step were recorded for. A changed schedule starts over.
+	// +optional
+	ScheduleHash string `json:"scheduleHash,omitempty"`
+
+	// Names of the RPC secrets the operator generated because they did not exist. They are owned by the BitcoinNode
+	// and deleted with it.
+	// +optional
+	GeneratedSecrets []string `json:"generatedSecrets,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Network",type=string,JSONPath=`.status.network`
+//+kubebuilder:printcolumn:name="Height",type=integer,JSONPath=`.status.lastBlockCount`
+//+kubebuilder:printcolumn:name="Peers",type=integer,JSONPath=`.status.peers`,priority=1
+//+kubebuilder:printcolumn:name="Mining",type=string,JSONPath=`.status.conditions[?(@.type=="Mining")].status`,priority=1
+//+kubebuilder:printcolumn:name="Last Mined",type=date,JSONPath=`.status.lastMinedTime`,priority=1
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // BitcoinNode is the Schema for the bitcoinnodes API
 type BitcoinNode struct {
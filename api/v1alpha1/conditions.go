@@ -0,0 +1,147 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// Condition types shared by the resources in this API group
+const (
+	// ConditionReady indicates whether the resource has been fully reconciled
+	ConditionReady = "Ready"
+
+	// ConditionSecretTampered indicates the contents of a generated secret no longer match the resource
+	ConditionSecretTampered = "SecretTampered"
+
+	// ConditionKeyMaterialStale indicates a node still uses key material that a Seed rotation replaced
+	ConditionKeyMaterialStale = "KeyMaterialStale"
+
+	// ConditionSyncing indicates a node is still downloading blocks it knows headers for
+	ConditionSyncing = "Syncing"
+
+	// ConditionMining indicates a node mines blocks, either by CPU mining or on a schedule
+	ConditionMining = "Mining"
+
+	// ConditionRPCReachable indicates the operator can call the RPC server of a node
+	ConditionRPCReachable = "RPCReachable"
+)
+
+// Condition reasons shared by the resources in this API group
+const (
+	// ReasonReconciled indicates the resource was reconciled successfully
+	ReasonReconciled = "Reconciled"
+
+	// ReasonUnsupportedNetwork indicates the resource references a network the operator does not know
+	ReasonUnsupportedNetwork = "UnsupportedNetwork"
+
+	// ReasonInvalidSpec indicates the resource spec failed validation
+	ReasonInvalidSpec = "InvalidSpec"
+
+	// ReasonSecretRefUnavailable indicates a referenced secret or secret key could not be read
+	ReasonSecretRefUnavailable = "SecretRefUnavailable"
+
+	// ReasonInvalidMnemonic indicates the mnemonic could not be decoded, e.g. because of a wrong passphrase
+	ReasonInvalidMnemonic = "InvalidMnemonic"
+
+	// ReasonSecretInSync indicates the generated secret matches the resource
+	ReasonSecretInSync = "SecretInSync"
+
+	// ReasonSecretRestored indicates the generated secret had drifted and was restored
+	ReasonSecretRestored = "SecretRestored"
+
+	// ReasonSecretDrifted indicates the generated secret has drifted and was left as is
+	ReasonSecretDrifted = "SecretDrifted"
+
+	// ReasonSecretUnrecoverable indicates generated key material was changed or removed and cannot be restored
+	ReasonSecretUnrecoverable = "SecretUnrecoverable"
+
+	// ReasonSecretConflict indicates a secret with the expected name exists but belongs to something else
+	ReasonSecretConflict = "SecretConflict"
+
+	// ReasonSharesUnavailable indicates fewer secret shares than the threshold could be read
+	ReasonSharesUnavailable = "SharesUnavailable"
+
+	// ReasonStorageUnavailable indicates the storage backend of the key material could not be reached
+	ReasonStorageUnavailable = "StorageUnavailable"
+
+	// ReasonSeedRotated indicates a Seed generated new key material and kept the previous version
+	ReasonSeedRotated = "SeedRotated"
+
+	// ReasonKeyMaterialCurrent indicates a node uses the active key material of its Seed
+	ReasonKeyMaterialCurrent = "KeyMaterialCurrent"
+
+	// ReasonSeedUnavailable indicates a referenced Seed does not exist or has no key material yet
+	ReasonSeedUnavailable = "SeedUnavailable"
+
+	// ReasonJobRunning indicates the resource waits for a Job it started to complete
+	ReasonJobRunning = "JobRunning"
+
+	// ReasonJobFailed indicates a Job started by the resource failed
+	ReasonJobFailed = "JobFailed"
+
+	// ReasonBackupUnavailable indicates a backup could not be read or decrypted
+	ReasonBackupUnavailable = "BackupUnavailable"
+
+	// ReasonFingerprintMismatch indicates key material does not match the fingerprint recorded for it
+	ReasonFingerprintMismatch = "FingerprintMismatch"
+
+	// ReasonIncompatibleSeed indicates the referenced seed cannot be used by the resource, e.g. a BIP39 seed for an lnd wallet
+	ReasonIncompatibleSeed = "IncompatibleSeed"
+
+	// ReasonBitcoinNodeUnavailable indicates a referenced BitcoinNode does not exist
+	ReasonBitcoinNodeUnavailable = "BitcoinNodeUnavailable"
+
+	// ReasonRPCResponding indicates the RPC server of a node answered the status calls
+	ReasonRPCResponding = "RPCResponding"
+
+	// ReasonRPCUnreachable indicates the RPC server of a node could not be called
+	ReasonRPCUnreachable = "RPCUnreachable"
+
+	// ReasonInitialBlockDownload indicates a node has fewer blocks than headers or reports an initial block download
+	ReasonInitialBlockDownload = "InitialBlockDownload"
+
+	// ReasonChainSynced indicates a node has downloaded the blocks of all headers it knows
+	ReasonChainSynced = "ChainSynced"
+
+	// ReasonCPUMining indicates the CPU miner of a node is running
+	ReasonCPUMining = "CPUMining"
+
+	// ReasonPeriodicBlocks indicates blocks are mined on a schedule
+	ReasonPeriodicBlocks = "PeriodicBlocks"
+
+	// ReasonMiningDisabled indicates a node does not mine blocks
+	ReasonMiningDisabled = "MiningDisabled"
+
+	// ReasonScheduleFinished indicates a scripted block schedule mined its last step
+	ReasonScheduleFinished = "ScheduleFinished"
+
+	// ReasonMining indicates the operator is mining the blocks of a request
+	ReasonMining = "Mining"
+
+	// ReasonMiningFailed indicates the node did not mine the blocks of a request
+	ReasonMiningFailed = "MiningFailed"
+
+	// ReasonMiningInterrupted indicates a request was started earlier and did not complete, so it may have mined blocks
+	ReasonMiningInterrupted = "MiningInterrupted"
+
+	// ReasonReorganizing indicates the operator is reorganizing the chain of a node
+	ReasonReorganizing = "Reorganizing"
+
+	// ReasonReorgFailed indicates the node did not invalidate or replace the blocks of a Reorg
+	ReasonReorgFailed = "ReorgFailed"
+
+	// ReasonReorgInterrupted indicates a Reorg was started earlier and did not complete, so it may have changed the
+	// chain
+	ReasonReorgInterrupted = "ReorgInterrupted"
+)
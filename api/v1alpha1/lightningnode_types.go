@@ -35,14 +35,22 @@ type BitcoinConnection struct {
 	// Hostname of the Bitcoin node RPC endpoint
 	Host string `json:"host,omitEmpty"`
 
-	// Bitcoin network, e.g. simnet, testnet, regressionnet, mainnet
+	// Name of a BitcoinNode in the same namespace to connect to. Its implementation selects the lnd backend, and its
+	// RPC endpoint is used when no host is set.
+	// +optional
+	BitcoinNode string `json:"bitcoinNode,omitempty"`
+
+	// Bitcoin network, e.g. simnet, testnet, regressionnet, mainnet. Ignored when a BitcoinNode is referenced, lnd
+	// then runs on the network of that node.
 	// +kubebuilder:default:="simnet"
 	Network string `json:"network,omitEmpty"`
 
-	// Name of the secret that contains TLS certificates for the RPC server
+	// Name of the secret that contains TLS certificates for the RPC server. Defaults to the secret of the referenced
+	// BitcoinNode.
 	CertSecret string `json:"certSecret,omitempty"`
 
-	// Name of the secret that contains bitcoin node RPC API credentials
+	// Name of the secret that contains bitcoin node RPC API credentials. Defaults to the secret of the referenced
+	// BitcoinNode, together with its keys.
 	ApiAuthSecretName string `json:"apiAuthSecretName,omiteempty"`
 
 	// Name of the secret key that contains bitcoin node RPC API username
@@ -100,6 +108,18 @@ type LightningNodeSpec struct {
 type LightningNodeStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// Conditions represent the latest available observations of the LightningNode's state
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// Fingerprint of the Seed key material the wallet was provisioned with
+	// +optional
+	SeedFingerprint string `json:"seedFingerprint,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -0,0 +1,117 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MiningRequestSpec defines the desired state of MiningRequest
+type MiningRequestSpec struct {
+	// Name of the BitcoinNode that mines the blocks, in the namespace of the MiningRequest
+	BitcoinNodeName string `json:"bitcoinNodeName"`
+
+	// Number of blocks to mine
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10000
+	Blocks int64 `json:"blocks"`
+
+	// Address that receives the block rewards. btcd always mines to the address it was started with, so an address
+	// can only be set for bitcoind nodes, which mine to the reward address of the node when none is set.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// Secret that contains the address that receives the block rewards, instead of address. Like address, it can
+	// only be set for bitcoind nodes.
+	// +optional
+	AddressSecretRef RewardAddress `json:"addressSecretRef,omitempty"`
+
+	// Raw transactions in hex to submit to the mempool of the node before mining, so the blocks include them
+	// +optional
+	Transactions []string `json:"transactions,omitempty"`
+}
+
+// MiningRequestStatus defines the observed state of MiningRequest
+type MiningRequestStatus struct {
+	// Conditions represent the latest available observations of the MiningRequest's state
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// Height of the chain before the blocks were mined
+	// +optional
+	StartHeight int64 `json:"startHeight,omitempty"`
+
+	// Height of the chain after the blocks were mined
+	// +optional
+	Height int64 `json:"height,omitempty"`
+
+	// Hashes of the mined blocks, in the order they were mined
+	// +optional
+	BlockHashes []string `json:"blockHashes,omitempty"`
+
+	// IDs of the transactions that were submitted before mining
+	// +optional
+	TransactionIDs []string `json:"transactionIDs,omitempty"`
+
+	// Time the operator started mining. A request that was started is never mined again, even when it did not
+	// complete.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Time the blocks were mined
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Generation of the MiningRequest that was last reconciled
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Node",type=string,JSONPath=`.spec.bitcoinNodeName`
+//+kubebuilder:printcolumn:name="Blocks",type=integer,JSONPath=`.spec.blocks`
+//+kubebuilder:printcolumn:name="Height",type=integer,JSONPath=`.status.height`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// MiningRequest is the Schema for the miningrequests API
+type MiningRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable, create another MiningRequest to mine more blocks"
+	Spec   MiningRequestSpec   `json:"spec,omitempty"`
+	Status MiningRequestStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// MiningRequestList contains a list of MiningRequest
+type MiningRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MiningRequest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MiningRequest{}, &MiningRequestList{})
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// MiningRequestWebhook validates MiningRequests at admission, so a reward address that the node cannot mine to is
+// rejected before any block is mined
+// +kubebuilder:object:generate=false
+type MiningRequestWebhook struct {
+	client.Client
+}
+
+//+kubebuilder:webhook:path=/validate-bitcoin-kiln-fired-github-io-v1alpha1-miningrequest,mutating=false,failurePolicy=fail,sideEffects=None,groups=bitcoin.kiln-fired.github.io,resources=miningrequests,verbs=create;update,versions=v1alpha1,name=vminingrequest.kb.io,admissionReviewVersions=v1
+
+// SetupWebhookWithManager registers the MiningRequest webhook with the Manager.
+func (w *MiningRequestWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&MiningRequest{}).
+		WithValidator(w).
+		Complete()
+}
+
+// ValidateCreate rejects MiningRequests that set both address and addressSecretRef, or that set either for an
+// existing BitcoinNode running btcd, which always mines to the address it was started with. A MiningRequest for a
+// node that does not exist yet is accepted and checked again when it is reconciled.
+func (w *MiningRequestWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	m, ok := obj.(*MiningRequest)
+	if !ok {
+		return fmt.Errorf("expected a MiningRequest but got a %T", obj)
+	}
+
+	return w.validateMiningRequest(ctx, m)
+}
+
+// ValidateUpdate validates a changed spec like a new MiningRequest
+func (w *MiningRequestWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldRequest, ok := oldObj.(*MiningRequest)
+	if !ok {
+		return fmt.Errorf("expected a MiningRequest but got a %T", oldObj)
+	}
+	m, ok := newObj.(*MiningRequest)
+	if !ok {
+		return fmt.Errorf("expected a MiningRequest but got a %T", newObj)
+	}
+
+	if m.DeletionTimestamp != nil || reflect.DeepEqual(oldRequest.Spec, m.Spec) {
+		return nil
+	}
+
+	return w.validateMiningRequest(ctx, m)
+}
+
+// ValidateDelete allows every deletion
+func (w *MiningRequestWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func (w *MiningRequestWebhook) validateMiningRequest(ctx context.Context, m *MiningRequest) error {
+	specPath := field.NewPath("spec")
+	addressPath := specPath.Child("address")
+	if m.Spec.Address == "" {
+		addressPath = specPath.Child("addressSecretRef")
+	}
+
+	var allErrs field.ErrorList
+	if m.Spec.Address != "" && m.Spec.AddressSecretRef.SecretName != "" {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("addressSecretRef"), "address and addressSecretRef are mutually exclusive"))
+	} else if m.Spec.Address != "" || m.Spec.AddressSecretRef.SecretName != "" {
+		bitcoinNode := &BitcoinNode{}
+		err := w.Get(ctx, types.NamespacedName{Name: m.Spec.BitcoinNodeName, Namespace: m.Namespace}, bitcoinNode)
+
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+
+		// Every BitcoinNode that does not run bitcoind runs btcd
+		if err == nil && bitcoinNode.Spec.Implementation != ImplementationBitcoind {
+			message := "BitcoinNode " + m.Spec.BitcoinNodeName + " runs " + ImplementationBtcd + ", which mines to the reward address of the BitcoinNode, an address can only be set for " + ImplementationBitcoind
+			allErrs = append(allErrs, field.Forbidden(addressPath, message))
+		}
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("MiningRequest").GroupKind(), m.Name, allErrs)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("MiningRequest webhook", func() {
+
+	const Namespace = "test-miningrequest-webhook-namespace"
+	const BitcoinNodeName = "miner"
+	const Address = "SZnxWPV3TUzGbJrfDuupHJw8MEkk3mEWRq"
+
+	ctx := context.Background()
+
+	miningRequestOf := func(spec MiningRequestSpec) *MiningRequest {
+		spec.BitcoinNodeName = BitcoinNodeName
+		spec.Blocks = 6
+		return &MiningRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test",
+				Namespace: Namespace,
+			},
+			Spec: spec,
+		}
+	}
+
+	BeforeEach(func() {
+		By("creating namespace to perform the tests")
+		_ = k8sClient.Create(ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      Namespace,
+				Namespace: Namespace,
+			},
+		})
+	})
+
+	DescribeTable("validating a new MiningRequest",
+		func(implementation string, spec MiningRequestSpec, expectedField string) {
+			webhook := &MiningRequestWebhook{Client: k8sClient}
+
+			By("creating the BitcoinNode of the MiningRequest")
+			bitcoinNode := &BitcoinNode{
+				ObjectMeta: metav1.ObjectMeta{Name: BitcoinNodeName, Namespace: Namespace},
+				Spec:       BitcoinNodeSpec{Implementation: implementation},
+			}
+			Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(k8sClient.Delete(ctx, bitcoinNode)).To(Succeed())
+			})
+
+			err := webhook.ValidateCreate(ctx, miningRequestOf(spec))
+
+			if expectedField == "" {
+				Expect(err).To(Not(HaveOccurred()))
+				return
+			}
+			Expect(errors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring(expectedField))
+		},
+		Entry("when a btcd node mines to its reward address", ImplementationBtcd, MiningRequestSpec{}, ""),
+		Entry("when a bitcoind node mines to an address", ImplementationBitcoind, MiningRequestSpec{Address: Address}, ""),
+		Entry("when a bitcoind node mines to an address from a secret", ImplementationBitcoind,
+			MiningRequestSpec{AddressSecretRef: RewardAddress{SecretName: "reward"}}, ""),
+		Entry("when a btcd node mines to an address", ImplementationBtcd, MiningRequestSpec{Address: Address}, "spec.address"),
+		Entry("when a node of the default implementation mines to an address from a secret", "",
+			MiningRequestSpec{AddressSecretRef: RewardAddress{SecretName: "reward"}}, "spec.addressSecretRef"),
+		Entry("when both address and addressSecretRef are set", ImplementationBitcoind,
+			MiningRequestSpec{Address: Address, AddressSecretRef: RewardAddress{SecretName: "reward"}}, "spec.addressSecretRef"),
+	)
+
+	It("accepting a MiningRequest for a BitcoinNode that does not exist yet", func() {
+		webhook := &MiningRequestWebhook{Client: k8sClient}
+		m := miningRequestOf(MiningRequestSpec{Address: Address})
+		m.Spec.BitcoinNodeName = "missing"
+		Expect(webhook.ValidateCreate(ctx, m)).To(Succeed())
+	})
+})
@@ -0,0 +1,90 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// DefaultNetwork is the network used when a resource does not specify one
+const DefaultNetwork = "simnet"
+
+// BitcoinNetwork describes a Bitcoin network supported by the operator
+// +kubebuilder:object:generate=false
+type BitcoinNetwork struct {
+	// Canonical name of the network, as used by btcd and lnd flags, e.g. --simnet or --bitcoin.simnet
+	Name string
+
+	// Chain parameters of the network
+	Params *chaincfg.Params
+
+	// Default peer-to-peer port
+	P2PPort int32
+
+	// Default btcd RPC port
+	BtcdRPCPort int32
+
+	// Default bitcoind RPC port, zero when bitcoind does not support the network
+	BitcoindRPCPort int32
+}
+
+var (
+	mainNet    = BitcoinNetwork{Name: "mainnet", Params: &chaincfg.MainNetParams, P2PPort: 8333, BtcdRPCPort: 8334, BitcoindRPCPort: 8332}
+	testNet    = BitcoinNetwork{Name: "testnet", Params: &chaincfg.TestNet3Params, P2PPort: 18333, BtcdRPCPort: 18334, BitcoindRPCPort: 18332}
+	regTestNet = BitcoinNetwork{Name: "regtest", Params: &chaincfg.RegressionNetParams, P2PPort: 18444, BtcdRPCPort: 18334, BitcoindRPCPort: 18443}
+	simNet     = BitcoinNetwork{Name: "simnet", Params: &chaincfg.SimNetParams, P2PPort: 18555, BtcdRPCPort: 18556}
+	sigNet     = BitcoinNetwork{Name: "signet", Params: &chaincfg.SigNetParams, P2PPort: 38333, BtcdRPCPort: 38332, BitcoindRPCPort: 38332}
+)
+
+// IsMainNet reports whether the network is Bitcoin mainnet, as opposed to one of the test networks
+func (n BitcoinNetwork) IsMainNet() bool {
+	return n.Name == mainNet.Name
+}
+
+// bitcoinNetworks maps every network name accepted by the CRDs to its network
+var bitcoinNetworks = map[string]BitcoinNetwork{
+	"mainnet":       mainNet,
+	"testnet":       testNet,
+	"testnet3":      testNet,
+	"regtest":       regTestNet,
+	"regressionnet": regTestNet,
+	"simnet":        simNet,
+	"signet":        sigNet,
+}
+
+// LookupNetwork returns the network registered under the given name
+func LookupNetwork(name string) (BitcoinNetwork, error) {
+	network, ok := bitcoinNetworks[name]
+	if !ok {
+		return BitcoinNetwork{}, fmt.Errorf("unsupported network %q, expected one of %s", name, strings.Join(SupportedNetworks(), ", "))
+	}
+	return network, nil
+}
+
+// SupportedNetworks returns every network name accepted by LookupNetwork
+func SupportedNetworks() []string {
+	names := make([]string, 0, len(bitcoinNetworks))
+	for name := range bitcoinNetworks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
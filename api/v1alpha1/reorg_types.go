@@ -0,0 +1,126 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReorgSpec defines the desired state of Reorg
+type ReorgSpec struct {
+	// Name of the BitcoinNode whose chain is reorganized, in the namespace of the Reorg. btcd, the default
+	// implementation of a BitcoinNode, does not implement invalidateblock, so the node must run bitcoind. A Reorg of a
+	// btcd node is rejected at admission.
+	BitcoinNodeName string `json:"bitcoinNodeName"`
+
+	// Number of blocks at the tip of the chain to invalidate
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1000
+	Depth int64 `json:"depth"`
+
+	// Number of competing blocks to mine after the invalidated ones. Peers only follow the new chain when it has more
+	// blocks than depth.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10000
+	Blocks int64 `json:"blocks"`
+
+	// Names of BitcoinNodes peered with the node to disconnect from it before the reorganization, so they keep the
+	// old chain and the network forks. The nodes are reconnected once the reorganization completed or failed, or when
+	// the Reorg is deleted.
+	// +optional
+	IsolatedNodes []string `json:"isolatedNodes,omitempty"`
+}
+
+// ReorgStatus defines the observed state of Reorg
+type ReorgStatus struct {
+	// Conditions represent the latest available observations of the Reorg's state
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// Hash of the best block before the reorganization
+	// +optional
+	OldTip string `json:"oldTip,omitempty"`
+
+	// Height of the best block before the reorganization
+	// +optional
+	OldHeight int64 `json:"oldHeight,omitempty"`
+
+	// Hash of the best block after the reorganization
+	// +optional
+	NewTip string `json:"newTip,omitempty"`
+
+	// Height of the best block after the reorganization
+	// +optional
+	NewHeight int64 `json:"newHeight,omitempty"`
+
+	// Number of blocks that were removed from the chain
+	// +optional
+	Depth int64 `json:"depth,omitempty"`
+
+	// Addresses of the peers that were disconnected to isolate the nodes
+	// +optional
+	DisconnectedPeers []string `json:"disconnectedPeers,omitempty"`
+
+	// Time the operator started the reorganization. A Reorg that was started is never run again, even when it did
+	// not complete.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Time the reorganization completed or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Generation of the Reorg that was last reconciled
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Node",type=string,JSONPath=`.spec.bitcoinNodeName`
+//+kubebuilder:printcolumn:name="Depth",type=integer,JSONPath=`.status.depth`
+//+kubebuilder:printcolumn:name="Old Height",type=integer,JSONPath=`.status.oldHeight`
+//+kubebuilder:printcolumn:name="New Height",type=integer,JSONPath=`.status.newHeight`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Reorg is the Schema for the reorgs API
+type Reorg struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable, create another Reorg to reorganize the chain again"
+	Spec   ReorgSpec   `json:"spec,omitempty"`
+	Status ReorgStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ReorgList contains a list of Reorg
+type ReorgList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Reorg `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Reorg{}, &ReorgList{})
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReorgWebhook validates Reorgs at admission, so a Reorg of a node that cannot invalidate blocks is rejected before
+// it is reconciled
+// +kubebuilder:object:generate=false
+type ReorgWebhook struct {
+	client.Client
+}
+
+//+kubebuilder:webhook:path=/validate-bitcoin-kiln-fired-github-io-v1alpha1-reorg,mutating=false,failurePolicy=fail,sideEffects=None,groups=bitcoin.kiln-fired.github.io,resources=reorgs,verbs=create;update,versions=v1alpha1,name=vreorg.kb.io,admissionReviewVersions=v1
+
+// SetupWebhookWithManager registers the Reorg webhook with the Manager.
+func (w *ReorgWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&Reorg{}).
+		WithValidator(w).
+		Complete()
+}
+
+// ValidateCreate rejects Reorgs of an existing BitcoinNode that does not run bitcoind. A Reorg of a node that does
+// not exist yet is accepted and checked again when it is reconciled.
+func (w *ReorgWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	reorg, ok := obj.(*Reorg)
+	if !ok {
+		return fmt.Errorf("expected a Reorg but got a %T", obj)
+	}
+
+	return w.validateReorg(ctx, reorg)
+}
+
+// ValidateUpdate validates a changed spec like a new Reorg
+func (w *ReorgWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldReorg, ok := oldObj.(*Reorg)
+	if !ok {
+		return fmt.Errorf("expected a Reorg but got a %T", oldObj)
+	}
+	reorg, ok := newObj.(*Reorg)
+	if !ok {
+		return fmt.Errorf("expected a Reorg but got a %T", newObj)
+	}
+
+	if reorg.DeletionTimestamp != nil || reflect.DeepEqual(oldReorg.Spec, reorg.Spec) {
+		return nil
+	}
+
+	return w.validateReorg(ctx, reorg)
+}
+
+// ValidateDelete allows every deletion
+func (w *ReorgWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func (w *ReorgWebhook) validateReorg(ctx context.Context, reorg *Reorg) error {
+	bitcoinNode := &BitcoinNode{}
+	err := w.Get(ctx, types.NamespacedName{Name: reorg.Spec.BitcoinNodeName, Namespace: reorg.Namespace}, bitcoinNode)
+
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Every BitcoinNode that does not run bitcoind runs btcd
+	if bitcoinNode.Spec.Implementation == ImplementationBitcoind {
+		return nil
+	}
+
+	nodePath := field.NewPath("spec").Child("bitcoinNodeName")
+	message := "BitcoinNode runs " + ImplementationBtcd + ", which does not implement invalidateblock, a Reorg needs a " + ImplementationBitcoind + " BitcoinNode"
+	allErrs := field.ErrorList{field.Invalid(nodePath, reorg.Spec.BitcoinNodeName, message)}
+	return apierrors.NewInvalid(GroupVersion.WithKind("Reorg").GroupKind(), reorg.Name, allErrs)
+}
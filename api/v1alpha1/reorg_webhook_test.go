@@ -0,0 +1,92 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Reorg webhook", func() {
+
+	const Namespace = "test-reorg-webhook-namespace"
+	const BitcoinNodeName = "forked"
+
+	ctx := context.Background()
+
+	reorgOf := func(name string) *Reorg {
+		return &Reorg{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test",
+				Namespace: Namespace,
+			},
+			Spec: ReorgSpec{
+				BitcoinNodeName: name,
+				Depth:           1,
+				Blocks:          2,
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		By("creating namespace to perform the tests")
+		_ = k8sClient.Create(ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      Namespace,
+				Namespace: Namespace,
+			},
+		})
+	})
+
+	DescribeTable("validating a new Reorg",
+		func(implementation string, valid bool) {
+			webhook := &ReorgWebhook{Client: k8sClient}
+
+			By("creating the BitcoinNode of the Reorg")
+			bitcoinNode := &BitcoinNode{
+				ObjectMeta: metav1.ObjectMeta{Name: BitcoinNodeName, Namespace: Namespace},
+				Spec:       BitcoinNodeSpec{Implementation: implementation},
+			}
+			Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(k8sClient.Delete(ctx, bitcoinNode)).To(Succeed())
+			})
+
+			err := webhook.ValidateCreate(ctx, reorgOf(BitcoinNodeName))
+
+			if valid {
+				Expect(err).To(Not(HaveOccurred()))
+				return
+			}
+			Expect(errors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.bitcoinNodeName"))
+		},
+		Entry("when the BitcoinNode runs bitcoind", ImplementationBitcoind, true),
+		Entry("when the BitcoinNode runs btcd", ImplementationBtcd, false),
+		Entry("when the BitcoinNode runs the default implementation", "", false),
+	)
+
+	It("accepting a Reorg of a BitcoinNode that does not exist yet", func() {
+		webhook := &ReorgWebhook{Client: k8sClient}
+		Expect(webhook.ValidateCreate(ctx, reorgOf("missing"))).To(Succeed())
+	})
+})
@@ -23,32 +23,360 @@ import (
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// Supported mnemonic formats
+const (
+	SeedFormatAezeed = "aezeed"
+	SeedFormatBIP39  = "bip39"
+)
+
+// Supported policies for secrets that no longer match their Seed
+const (
+	DriftPolicyRestore = "Restore"
+	DriftPolicyFlag    = "Flag"
+)
+
+// Supported policies for the secret of a deleted Seed
+const (
+	DeletionPolicyRetain = "Retain"
+	DeletionPolicyDelete = "Delete"
+	DeletionPolicyOrphan = "Orphan"
+)
+
+// RetainedFingerprintAnnotation records the master key fingerprint on a secret retained after its Seed was deleted,
+// so that a new Seed with the same fingerprint can adopt it
+const RetainedFingerprintAnnotation = "bitcoin.kiln-fired.github.io/retained-fingerprint"
+
+// RetainedSeedAnnotation records the name of the Seed a secret was retained for. A Seed that generates its mnemonic
+// cannot prove ownership through its fingerprint, so it only adopts a retained secret recorded under its own name.
+const RetainedSeedAnnotation = "bitcoin.kiln-fired.github.io/retained-seed"
+
+// Supported storage backends for Seed key material
+const (
+	StorageTypeKubernetes = "kubernetes"
+	StorageTypeVault      = "vault"
+)
+
+// RotationTriggerAnnotation records the rotation trigger on the key material generated by a rotation
+const RotationTriggerAnnotation = "bitcoin.kiln-fired.github.io/rotation-trigger"
+
+// SeedFingerprintAnnotation records on a StatefulSet the fingerprint of the Seed key material it was provisioned with
+const SeedFingerprintAnnotation = "bitcoin.kiln-fired.github.io/seed-fingerprint"
+
+// SeedFormatAnnotation records the mnemonic format on the secret generated for a Seed
+const SeedFormatAnnotation = "bitcoin.kiln-fired.github.io/seed-format"
+
+type AddressDerivation struct {
+	// Address type, one of np2wkh (BIP49), p2wkh (BIP84) or p2tr (BIP86)
+	// +kubebuilder:validation:Enum=np2wkh;p2wkh;p2tr
+	Type string `json:"type"`
+
+	// First account to derive addresses for. The accounts are hardened, so the last derived account must be below 2^31.
+	// +optional
+	// +kubebuilder:default:=0
+	// +kubebuilder:validation:Maximum=2147483647
+	Account uint32 `json:"account,omitempty"`
+
+	// Number of consecutive accounts to derive addresses for
+	// +optional
+	// +kubebuilder:default:=1
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	AccountCount uint32 `json:"accountCount,omitempty"`
+
+	// First receive address index to derive. The last derived index must be below 2^31, the start of the hardened
+	// indexes.
+	// +optional
+	// +kubebuilder:default:=0
+	// +kubebuilder:validation:Maximum=2147483647
+	Index uint32 `json:"index,omitempty"`
+
+	// Number of consecutive receive addresses to derive in each account
+	// +optional
+	// +kubebuilder:default:=1
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Count uint32 `json:"count,omitempty"`
+}
+
+type AccountExport struct {
+	// BIP43 purpose of the account, one of 44 (pkh), 49 (sh(wpkh)), 84 (wpkh) or 86 (tr)
+	// +kubebuilder:validation:Enum=44;49;84;86
+	Purpose uint32 `json:"purpose"`
+
+	// Account number, derived hardened
+	// +optional
+	// +kubebuilder:default:=0
+	// +kubebuilder:validation:Maximum=2147483647
+	Account uint32 `json:"account,omitempty"`
+}
+
+type ShareTarget struct {
+	// Name of the secret to write the share to
+	SecretName string `json:"secretName"`
+
+	// Namespace of the secret, defaults to the namespace of the Seed
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type SeedSharding struct {
+	// Number of shares required to recover the seed
+	// +kubebuilder:validation:Minimum=2
+	// +kubebuilder:validation:Maximum=255
+	Threshold int32 `json:"threshold"`
+
+	// Number of shares to split the seed into
+	// +kubebuilder:validation:Minimum=2
+	// +kubebuilder:validation:Maximum=255
+	Shares int32 `json:"shares"`
+
+	// Secrets to write the shares to, one per share in share order. Defaults to <secretName>-share-<index>
+	// in the namespace of the Seed.
+	// +optional
+	Targets []ShareTarget `json:"targets,omitempty"`
+}
+
+type AuthSecretRef struct {
+	// Name of the secret that contains the storage credentials
+	SecretName string `json:"secretName,omitempty"`
+
+	// Name of the secret key that contains the Vault token
+	// +kubebuilder:default:="token"
+	SecretKey string `json:"secretKey,omitempty"`
+}
+
+type SeedStorage struct {
+	// Storage backend for the key material, either kubernetes secrets or a vault KV version 2 secrets engine
+	// +kubebuilder:validation:Enum=kubernetes;vault
+	// +kubebuilder:default:="kubernetes"
+	Type string `json:"type,omitempty"`
+
+	// Address of the Vault server, e.g. https://vault.vault.svc:8200
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// Mount path of the KV version 2 secrets engine
+	// +optional
+	// +kubebuilder:default:="secret"
+	Mount string `json:"mount,omitempty"`
+
+	// Path of the key material within the mount, defaults to <namespace>/<secretName>
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Secret in the namespace of the Seed that contains the Vault token
+	// +optional
+	AuthSecretRef AuthSecretRef `json:"authSecretRef,omitempty"`
+}
+
+type DeterministicSeed struct {
+	// Salt that, together with the namespace and name of the Seed, determines the generated entropy, passphrase and
+	// birthday. Anyone who knows the salt, namespace and name can recreate the key material.
+	// +kubebuilder:validation:MinLength=1
+	Salt string `json:"salt"`
+}
+
+type MnemonicSecretRef struct {
+	// Name of the secret that contains the mnemonic phrase
+	SecretName string `json:"secretName,omitempty"`
+
+	// Name of the secret key that contains the mnemonic phrase
+	// +kubebuilder:default:="mnemonic"
+	SecretKey string `json:"secretKey,omitempty"`
+}
+
+type PassphraseSecretRef struct {
+	// Name of the secret that contains the seed passphrase
+	SecretName string `json:"secretName,omitempty"`
+
+	// Name of the secret key that contains the seed passphrase
+	// +kubebuilder:default:="passphrase"
+	SecretKey string `json:"secretKey,omitempty"`
+}
+
 // SeedSpec defines the desired state of Seed
 type SeedSpec struct {
-	// Name of secret to store master key
+	// Name of secret to store master key. With vault storage it names the Vault secret instead.
 	SecretName string `json:"secretName"`
 
-	// aezeed mnemonic phrase
+	// Mnemonic format, either aezeed as used by lnd or bip39 as used by most other wallets.
+	// BIP39 seeds cannot be used to initialize an lnd wallet.
+	// +kubebuilder:validation:Enum=aezeed;bip39
+	// +kubebuilder:default:="aezeed"
+	Format string `json:"format,omitempty"`
+
+	// Number of words of a generated BIP39 mnemonic. aezeed mnemonics always have 24 words.
+	// +optional
+	// +kubebuilder:validation:Enum=12;24
+	// +kubebuilder:default:=24
+	WordCount int `json:"wordCount,omitempty"`
+
+	// Mnemonic phrase in the configured format. Plaintext key material is only accepted on test networks,
+	// use mnemonicSecretRef instead.
 	// +optional
 	Mnemonic string `json:"mnemonic,omitempty"`
 
-	// aezeed password
+	// Secret that contains the mnemonic phrase
+	// +optional
+	MnemonicSecretRef MnemonicSecretRef `json:"mnemonicSecretRef,omitempty"`
+
+	// aezeed password or BIP39 passphrase. Plaintext key material is only accepted on test networks,
+	// use passphraseSecretRef instead.
 	// +optional
 	Passphrase string `json:"passphrase,omitempty"`
 
+	// Secret that contains the aezeed password or BIP39 passphrase
+	// +optional
+	PassphraseSecretRef PassphraseSecretRef `json:"passphraseSecretRef,omitempty"`
+
 	// Bitcoin network, e.g. simnet, testnet, regressionnet, mainnet
 	// +kubebuilder:default:="simnet"
 	Network string `json:"network,omitempty"`
+
+	// What happens to the secret when the Seed is deleted. Delete removes it together with the Seed, Orphan
+	// leaves it behind, and Retain leaves it behind annotated with its fingerprint so a new Seed with the same
+	// fingerprint, or a generated Seed with the same name, can adopt it.
+	// +kubebuilder:validation:Enum=Retain;Delete;Orphan
+	// +kubebuilder:default:="Delete"
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+
+	// Accounts to publish extended public keys and output descriptors for
+	// +optional
+	Accounts []AccountExport `json:"accounts,omitempty"`
+
+	// Name of the ConfigMap to publish account extended public keys and output descriptors in,
+	// defaults to <seed name>-accounts
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// Backend that stores the key material. Account exports and secret shares are always written to Kubernetes.
+	// +optional
+	Storage SeedStorage `json:"storage,omitempty"`
+
+	// Split the generated mnemonic and passphrase into Shamir secret shares written to separate secrets instead of
+	// storing them in the Seed secret. The Seed is recovered from any threshold shares, so existing share secrets
+	// can be used to rebuild a Seed. Share secrets are not owned by the Seed, they are deleted or retained with it
+	// according to the deletion policy.
+	// +optional
+	Sharding *SeedSharding `json:"sharding,omitempty"`
+
+	// Changing this value rotates a generated Seed. A new mnemonic replaces the active key material and the previous
+	// version stays readable in a versioned secret named <secretName>-v<version>. Nodes that reference the secret
+	// report their key material as stale until they are provisioned again.
+	// +optional
+	RotationTrigger string `json:"rotationTrigger,omitempty"`
+
+	// Derive the generated key material from a salt and the namespace and name of the Seed instead of from random
+	// entropy, so test fixtures get the same wallets every time. Such seeds are insecure and refused on mainnet.
+	// +optional
+	Deterministic *DeterministicSeed `json:"deterministic,omitempty"`
+
+	// What to do when the secret no longer matches the Seed. Restore rewrites the expected contents,
+	// Flag only reports the drift in the SecretTampered condition. Generated key material that was
+	// changed or removed cannot be restored and is always flagged.
+	// +kubebuilder:validation:Enum=Restore;Flag
+	// +kubebuilder:default:="Restore"
+	DriftPolicy string `json:"driftPolicy,omitempty"`
+
+	// Receive addresses to derive from the master key and publish in the secret
+	// +optional
+	Addresses []AddressDerivation `json:"addresses,omitempty"`
+}
+
+type SeedShareStatus struct {
+	// Index of the share, starting at 1
+	Index int32 `json:"index"`
+
+	// Name of the secret that contains the share
+	SecretName string `json:"secretName"`
+
+	// Namespace of the secret that contains the share
+	Namespace string `json:"namespace"`
+
+	// Whether the share secret exists
+	Available bool `json:"available"`
+}
+
+// SeedVersionStatus describes key material that was replaced by a rotation
+type SeedVersionStatus struct {
+	// Version of the key material, starting at 1
+	Version int32 `json:"version"`
+
+	// BIP32 fingerprint of the master key of this version
+	Fingerprint string `json:"fingerprint"`
+
+	// Name of the versioned secret that keeps the key material readable
+	SecretName string `json:"secretName"`
+
+	// Time the key material was replaced
+	RetiredAt metav1.Time `json:"retiredAt"`
 }
 
 // SeedStatus defines the observed state of Seed
 type SeedStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// Conditions represent the latest available observations of the Seed's state
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// BIP32 fingerprint of the master key, as used in output descriptors
+	// +optional
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// Internal version of the aezeed cipher seed
+	// +optional
+	AezeedVersion *int32 `json:"aezeedVersion,omitempty"`
+
+	// Wallet birthday encoded in the aezeed cipher seed
+	// +optional
+	Birthday *metav1.Time `json:"birthday,omitempty"`
+
+	// Name of the secret the key material was written to
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// Name of the ConfigMap the account extended public keys were written to
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// Shamir secret shares of the seed
+	// +optional
+	Shares []SeedShareStatus `json:"shares,omitempty"`
+
+	// Version of the active key material, incremented by every rotation
+	// +optional
+	Version int32 `json:"version,omitempty"`
+
+	// Rotation trigger the active key material was generated for
+	// +optional
+	ObservedRotationTrigger string `json:"observedRotationTrigger,omitempty"`
+
+	// Whether the key material was derived deterministically and must not hold real funds
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+
+	// Key material replaced by rotations, newest first
+	// +optional
+	PreviousVersions []SeedVersionStatus `json:"previousVersions,omitempty"`
+
+	// Generation of the Seed that was last reconciled
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Fingerprint",type=string,JSONPath=`.status.fingerprint`
+//+kubebuilder:printcolumn:name="Version",type=integer,JSONPath=`.status.version`,priority=1
+//+kubebuilder:printcolumn:name="Insecure",type=boolean,JSONPath=`.status.insecure`,priority=1
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Seed is the Schema for the seeds API
 type Seed struct {
@@ -0,0 +1,164 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/aezeed"
+	"github.com/tyler-smith/go-bip39"
+	"k8s.io/utils/strings/slices"
+)
+
+// ValidateSeedSpec checks a Seed spec for problems that can be detected without reading other objects
+func ValidateSeedSpec(spec SeedSpec, network BitcoinNetwork) error {
+	if spec.Mnemonic != "" && spec.MnemonicSecretRef.SecretName != "" {
+		return errors.New("mnemonic and mnemonicSecretRef are mutually exclusive")
+	}
+
+	if spec.Passphrase != "" && spec.PassphraseSecretRef.SecretName != "" {
+		return errors.New("passphrase and passphraseSecretRef are mutually exclusive")
+	}
+
+	if spec.Format != SeedFormatBIP39 && spec.WordCount != 0 && spec.WordCount != 24 {
+		return errors.New("aezeed mnemonics always have 24 words, wordCount only applies to the bip39 format")
+	}
+
+	if sharding := spec.Sharding; sharding != nil {
+		if spec.Mnemonic != "" || spec.MnemonicSecretRef.SecretName != "" {
+			return errors.New("sharding only applies to generated seeds, remove mnemonic and mnemonicSecretRef")
+		}
+		if sharding.Threshold < 2 || sharding.Threshold > sharding.Shares || sharding.Shares > 255 {
+			return errors.New("sharding must satisfy 2 <= threshold <= shares <= 255")
+		}
+		if len(sharding.Targets) != 0 && len(sharding.Targets) != int(sharding.Shares) {
+			return errors.New("sharding targets must list one secret per share")
+		}
+	}
+
+	if spec.RotationTrigger != "" {
+		if spec.Mnemonic != "" || spec.MnemonicSecretRef.SecretName != "" {
+			return errors.New("rotation only applies to generated seeds, remove mnemonic and mnemonicSecretRef")
+		}
+		if spec.Sharding != nil {
+			return errors.New("sharded seeds cannot be rotated")
+		}
+	}
+
+	if spec.Deterministic != nil {
+		if spec.Mnemonic != "" || spec.MnemonicSecretRef.SecretName != "" {
+			return errors.New("deterministic only applies to generated seeds, remove mnemonic and mnemonicSecretRef")
+		}
+		if spec.RotationTrigger != "" {
+			return errors.New("deterministic seeds cannot be rotated")
+		}
+		if network.IsMainNet() {
+			return errors.New("deterministic seeds are insecure and not allowed on mainnet")
+		}
+	}
+
+	if spec.Storage.Type == StorageTypeVault {
+		if spec.Storage.Address == "" {
+			return errors.New("vault storage requires an address")
+		}
+		if spec.Storage.AuthSecretRef.SecretName == "" {
+			return errors.New("vault storage requires an authSecretRef")
+		}
+	}
+
+	for _, d := range spec.Addresses {
+		if _, err := DerivationEnd(d.Account, d.AccountCount); err != nil {
+			return fmt.Errorf("address accounts: %w", err)
+		}
+		if _, err := DerivationEnd(d.Index, d.Count); err != nil {
+			return fmt.Errorf("address indexes: %w", err)
+		}
+	}
+
+	for _, a := range spec.Accounts {
+		if a.Account >= hdkeychain.HardenedKeyStart {
+			return fmt.Errorf("account %d is not below the hardened offset %d", a.Account, uint32(hdkeychain.HardenedKeyStart))
+		}
+	}
+
+	if network.IsMainNet() && (spec.Mnemonic != "" || spec.Passphrase != "") {
+		return errors.New("plaintext mnemonic and passphrase are not allowed on mainnet, use mnemonicSecretRef and passphraseSecretRef")
+	}
+
+	return nil
+}
+
+// DerivationEnd returns the end of a range of count child numbers starting at first, which must stay below the hardened
+// offset
+func DerivationEnd(first uint32, count uint32) (uint64, error) {
+	end := uint64(first) + uint64(atLeastOne(count))
+	if end > hdkeychain.HardenedKeyStart {
+		return 0, fmt.Errorf("%d child numbers starting at %d reach the hardened offset %d", atLeastOne(count), first, uint32(hdkeychain.HardenedKeyStart))
+	}
+	return end, nil
+}
+
+// atLeastOne treats an unset count as a single item
+func atLeastOne(n uint32) uint32 {
+	if n == 0 {
+		return 1
+	}
+	return n
+}
+
+// ParseAezeedMnemonic checks that a mnemonic has 24 words of the aezeed word list
+func ParseAezeedMnemonic(mnemonicStr string) (aezeed.Mnemonic, error) {
+	mnemonic := aezeed.Mnemonic{}
+	mnemonicSlice := strings.Fields(mnemonicStr)
+
+	if len(mnemonicSlice) != 24 {
+		return mnemonic, errors.New("mnemonic contains the wrong number of words")
+	}
+
+	for i, word := range mnemonicSlice {
+		if !slices.Contains(aezeed.DefaultWordList, word) {
+			return mnemonic, errors.New(fmt.Sprintf("mnemonic contains an invalid word at index %d", i))
+		}
+	}
+
+	copy(mnemonic[:], mnemonicSlice)
+	return mnemonic, nil
+}
+
+// ParseBIP39Mnemonic checks that a mnemonic has 12 or 24 words of the BIP39 word list and a valid checksum
+func ParseBIP39Mnemonic(mnemonicStr string) ([]string, error) {
+	mnemonicSlice := strings.Fields(mnemonicStr)
+
+	if len(mnemonicSlice) != 12 && len(mnemonicSlice) != 24 {
+		return nil, errors.New("mnemonic contains the wrong number of words")
+	}
+
+	for i, word := range mnemonicSlice {
+		if _, ok := bip39.GetWordIndex(word); !ok {
+			return nil, errors.New(fmt.Sprintf("mnemonic contains an invalid word at index %d", i))
+		}
+	}
+
+	if _, err := bip39.EntropyFromMnemonic(strings.Join(mnemonicSlice, " ")); err != nil {
+		return nil, errors.New("mnemonic checksum is invalid")
+	}
+
+	return mnemonicSlice, nil
+}
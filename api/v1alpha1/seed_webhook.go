@@ -0,0 +1,224 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/lightningnetwork/lnd/aezeed"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// redactedValue replaces key material in admission errors so it never ends up in API responses or audit logs
+const redactedValue = "<redacted>"
+
+// SeedWebhook defaults and validates Seeds at admission, so mistakes in the spec are rejected before the Seed is
+// reconciled
+// +kubebuilder:object:generate=false
+type SeedWebhook struct{}
+
+//+kubebuilder:webhook:path=/mutate-bitcoin-kiln-fired-github-io-v1alpha1-seed,mutating=true,failurePolicy=fail,sideEffects=None,groups=bitcoin.kiln-fired.github.io,resources=seeds,verbs=create;update,versions=v1alpha1,name=mseed.kb.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-bitcoin-kiln-fired-github-io-v1alpha1-seed,mutating=false,failurePolicy=fail,sideEffects=None,groups=bitcoin.kiln-fired.github.io,resources=seeds,verbs=create;update,versions=v1alpha1,name=vseed.kb.io,admissionReviewVersions=v1
+
+// SetupWebhookWithManager registers the Seed webhooks with the Manager.
+func (w *SeedWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&Seed{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+// Default fills in the fields of a Seed spec that were left empty
+func (w *SeedWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	s, ok := obj.(*Seed)
+	if !ok {
+		return fmt.Errorf("expected a Seed but got a %T", obj)
+	}
+	ctrllog.FromContext(ctx).V(1).Info("Defaulting Seed", "Seed.Namespace", s.Namespace, "Seed.Name", s.Name)
+
+	spec := &s.Spec
+
+	if spec.Network == "" {
+		spec.Network = DefaultNetwork
+	}
+	if spec.Format == "" {
+		spec.Format = SeedFormatAezeed
+	}
+	if spec.WordCount == 0 {
+		spec.WordCount = 24
+	}
+	if spec.DriftPolicy == "" {
+		spec.DriftPolicy = DriftPolicyRestore
+	}
+	if spec.DeletionPolicy == "" {
+		spec.DeletionPolicy = DeletionPolicyDelete
+	}
+	if len(spec.Accounts) > 0 && spec.ConfigMapName == "" {
+		spec.ConfigMapName = s.Name + "-accounts"
+	}
+
+	if spec.MnemonicSecretRef.SecretName != "" && spec.MnemonicSecretRef.SecretKey == "" {
+		spec.MnemonicSecretRef.SecretKey = "mnemonic"
+	}
+	if spec.PassphraseSecretRef.SecretName != "" && spec.PassphraseSecretRef.SecretKey == "" {
+		spec.PassphraseSecretRef.SecretKey = "passphrase"
+	}
+
+	if spec.Storage.Type == "" {
+		spec.Storage.Type = StorageTypeKubernetes
+	}
+	if spec.Storage.Type == StorageTypeVault {
+		if spec.Storage.Mount == "" {
+			spec.Storage.Mount = "secret"
+		}
+		if spec.Storage.AuthSecretRef.SecretKey == "" {
+			spec.Storage.AuthSecretRef.SecretKey = "token"
+		}
+	}
+
+	return nil
+}
+
+// ValidateCreate rejects Seeds with an unsupported network, an inconsistent spec or an invalid mnemonic
+func (w *SeedWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	s, ok := obj.(*Seed)
+	if !ok {
+		return fmt.Errorf("expected a Seed but got a %T", obj)
+	}
+
+	return seedInvalid(s, validateSeed(s))
+}
+
+// ValidateUpdate additionally rejects changes to the secret name and the supplied key material, which would
+// silently replace the keys of everything that references the Seed
+func (w *SeedWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldSeed, ok := oldObj.(*Seed)
+	if !ok {
+		return fmt.Errorf("expected a Seed but got a %T", oldObj)
+	}
+	s, ok := newObj.(*Seed)
+	if !ok {
+		return fmt.Errorf("expected a Seed but got a %T", newObj)
+	}
+
+	// Seeds that were accepted under older rules must still be able to change their metadata, status and finalizers,
+	// in particular while they are deleted
+	if s.DeletionTimestamp != nil || reflect.DeepEqual(oldSeed.Spec, s.Spec) {
+		return nil
+	}
+
+	allErrs := validateSeedImmutable(oldSeed, s)
+	allErrs = append(allErrs, validateSeed(s)...)
+	return seedInvalid(s, allErrs)
+}
+
+// ValidateDelete allows every deletion, the deletion policy decides what happens to the key material
+func (w *SeedWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func seedInvalid(s *Seed, allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("Seed").GroupKind(), s.Name, allErrs)
+}
+
+func validateSeed(s *Seed) field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	network, err := LookupNetwork(s.Spec.Network)
+	if err != nil {
+		allErrs = append(allErrs, field.NotSupported(specPath.Child("network"), s.Spec.Network, SupportedNetworks()))
+	} else if err := ValidateSeedSpec(s.Spec, network); err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath, redactedValue, err.Error()))
+	}
+
+	return append(allErrs, validateSeedMnemonic(s.Spec, specPath)...)
+}
+
+// validateSeedMnemonic runs the checks of the mnemonic import on a plaintext mnemonic. An aezeed mnemonic is also
+// decoded with its passphrase unless the passphrase is stored in a secret, which catches checksum and passphrase
+// errors that a word list check cannot.
+func validateSeedMnemonic(spec SeedSpec, specPath *field.Path) field.ErrorList {
+	if spec.Mnemonic == "" {
+		return nil
+	}
+	mnemonicPath := specPath.Child("mnemonic")
+
+	switch spec.Format {
+	case "", SeedFormatAezeed:
+		mnemonic, err := ParseAezeedMnemonic(spec.Mnemonic)
+		if err != nil {
+			return field.ErrorList{field.Invalid(mnemonicPath, redactedValue, err.Error())}
+		}
+
+		if spec.PassphraseSecretRef.SecretName != "" {
+			return nil
+		}
+
+		_, err = mnemonic.ToCipherSeed([]byte(spec.Passphrase))
+		if errors.Is(err, aezeed.ErrInvalidPass) {
+			return field.ErrorList{field.Invalid(specPath.Child("passphrase"), redactedValue, "passphrase does not decrypt the aezeed mnemonic")}
+		}
+		if err != nil {
+			return field.ErrorList{field.Invalid(mnemonicPath, redactedValue, err.Error())}
+		}
+	case SeedFormatBIP39:
+		if _, err := ParseBIP39Mnemonic(spec.Mnemonic); err != nil {
+			return field.ErrorList{field.Invalid(mnemonicPath, redactedValue, err.Error())}
+		}
+	}
+
+	return nil
+}
+
+// validateSeedImmutable rejects changes to the fields that identify the key material of a Seed
+func validateSeedImmutable(oldSeed *Seed, s *Seed) field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if s.Spec.SecretName != oldSeed.Spec.SecretName {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("secretName"), s.Spec.SecretName, "field is immutable"))
+	}
+	if s.Spec.Mnemonic != oldSeed.Spec.Mnemonic {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("mnemonic"), "field is immutable"))
+	}
+	if s.Spec.MnemonicSecretRef != oldSeed.Spec.MnemonicSecretRef {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("mnemonicSecretRef"), "field is immutable"))
+	}
+	if s.Spec.Passphrase != oldSeed.Spec.Passphrase {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("passphrase"), "field is immutable"))
+	}
+	if s.Spec.PassphraseSecretRef != oldSeed.Spec.PassphraseSecretRef {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("passphraseSecretRef"), "field is immutable"))
+	}
+	if !reflect.DeepEqual(s.Spec.Deterministic, oldSeed.Spec.Deterministic) {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("deterministic"), "field is immutable"))
+	}
+
+	return allErrs
+}
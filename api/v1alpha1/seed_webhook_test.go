@@ -0,0 +1,242 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Seed webhook", func() {
+
+	const Mnemonic = "above pioneer library glimpse exhibit analyst monitor holiday boil art ketchup mail hunt since now pattern vacant arch museum tourist brisk come pilot devote"
+	const Passphrase = "test"
+	const BIP39Mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+	ctx := context.Background()
+	webhook := &SeedWebhook{}
+
+	seedWithSpec := func(spec SeedSpec) *Seed {
+		return &Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test",
+				Namespace: "test-namespace",
+			},
+			Spec: spec,
+		}
+	}
+
+	It("defaulting an empty Seed spec", func() {
+		seed := seedWithSpec(SeedSpec{
+			SecretName: "seed",
+			Accounts:   []AccountExport{{Purpose: 84}},
+			Storage: SeedStorage{
+				Type: StorageTypeVault,
+			},
+		})
+
+		Expect(webhook.Default(ctx, seed)).To(Succeed())
+		Expect(seed.Spec.Network).To(Equal(DefaultNetwork))
+		Expect(seed.Spec.Format).To(Equal(SeedFormatAezeed))
+		Expect(seed.Spec.WordCount).To(Equal(24))
+		Expect(seed.Spec.DriftPolicy).To(Equal(DriftPolicyRestore))
+		Expect(seed.Spec.DeletionPolicy).To(Equal(DeletionPolicyDelete))
+		Expect(seed.Spec.ConfigMapName).To(Equal("test-accounts"))
+		Expect(seed.Spec.Storage.Mount).To(Equal("secret"))
+		Expect(seed.Spec.Storage.AuthSecretRef.SecretKey).To(Equal("token"))
+	})
+
+	DescribeTable("validating a new Seed",
+		func(spec SeedSpec, expectedField string) {
+			err := webhook.ValidateCreate(ctx, seedWithSpec(spec))
+
+			if expectedField == "" {
+				Expect(err).To(Not(HaveOccurred()))
+				return
+			}
+
+			Expect(errors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring(expectedField))
+			Expect(err.Error()).To(Not(ContainSubstring("pioneer")))
+		},
+		Entry(
+			"when the aezeed mnemonic and passphrase are valid",
+			SeedSpec{SecretName: "seed", Network: "simnet", Mnemonic: Mnemonic, Passphrase: Passphrase},
+			"",
+		),
+		Entry(
+			"when the mnemonic is generated",
+			SeedSpec{SecretName: "seed", Network: "regtest"},
+			"",
+		),
+		Entry(
+			"when the BIP39 mnemonic is valid",
+			SeedSpec{SecretName: "seed", Network: "simnet", Format: SeedFormatBIP39, Mnemonic: BIP39Mnemonic},
+			"",
+		),
+		Entry(
+			"when the network is not supported",
+			SeedSpec{SecretName: "seed", Network: "litecoin"},
+			"spec.network",
+		),
+		Entry(
+			"when the mnemonic has the wrong number of words",
+			SeedSpec{SecretName: "seed", Network: "simnet", Mnemonic: "above pioneer library", Passphrase: Passphrase},
+			"spec.mnemonic",
+		),
+		Entry(
+			"when the mnemonic contains an invalid word",
+			SeedSpec{SecretName: "seed", Network: "simnet", Mnemonic: "bitcoin" + Mnemonic[len("above"):], Passphrase: Passphrase},
+			"spec.mnemonic",
+		),
+		Entry(
+			"when the aezeed checksum is invalid",
+			SeedSpec{SecretName: "seed", Network: "simnet", Mnemonic: "abandon" + Mnemonic[len("above"):], Passphrase: Passphrase},
+			"spec.mnemonic",
+		),
+		Entry(
+			"when the passphrase does not decrypt the aezeed mnemonic",
+			SeedSpec{SecretName: "seed", Network: "simnet", Mnemonic: Mnemonic, Passphrase: "wrong"},
+			"spec.passphrase",
+		),
+		Entry(
+			"when the BIP39 checksum is invalid",
+			SeedSpec{SecretName: "seed", Network: "simnet", Format: SeedFormatBIP39, Mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"},
+			"spec.mnemonic",
+		),
+		Entry(
+			"when a plaintext mnemonic is used on mainnet",
+			SeedSpec{SecretName: "seed", Network: "mainnet", Mnemonic: Mnemonic, Passphrase: Passphrase},
+			"plaintext mnemonic",
+		),
+		Entry(
+			"when a deterministic seed is generated on regtest",
+			SeedSpec{SecretName: "seed", Network: "regtest", Deterministic: &DeterministicSeed{Salt: "fixture"}},
+			"",
+		),
+		Entry(
+			"when a deterministic seed is used on mainnet",
+			SeedSpec{SecretName: "seed", Network: "mainnet", Deterministic: &DeterministicSeed{Salt: "fixture"}},
+			"not allowed on mainnet",
+		),
+		Entry(
+			"when a deterministic seed imports a mnemonic",
+			SeedSpec{SecretName: "seed", Network: "simnet", Mnemonic: Mnemonic, Passphrase: Passphrase, Deterministic: &DeterministicSeed{Salt: "fixture"}},
+			"deterministic only applies to generated seeds",
+		),
+		Entry(
+			"when the last address account is the last unhardened child number",
+			SeedSpec{SecretName: "seed", Network: "regtest", Addresses: []AddressDerivation{{Type: "p2wkh", Account: 2147483646, AccountCount: 2}}},
+			"",
+		),
+		Entry(
+			"when the address accounts reach the hardened offset",
+			SeedSpec{SecretName: "seed", Network: "regtest", Addresses: []AddressDerivation{{Type: "p2wkh", Account: 2147483647, AccountCount: 2}}},
+			"hardened offset",
+		),
+		Entry(
+			"when the address indexes wrap around",
+			SeedSpec{SecretName: "seed", Network: "regtest", Addresses: []AddressDerivation{{Type: "p2wkh", Index: 4294967295, Count: 2}}},
+			"hardened offset",
+		),
+		Entry(
+			"when an exported account is hardened",
+			SeedSpec{SecretName: "seed", Network: "regtest", Accounts: []AccountExport{{Purpose: 84, Account: 2147483648}}},
+			"hardened offset",
+		),
+	)
+
+	DescribeTable("validating an updated Seed",
+		func(update func(spec *SeedSpec), expectedField string) {
+			oldSeed := seedWithSpec(SeedSpec{
+				SecretName: "seed",
+				Network:    "simnet",
+				Mnemonic:   Mnemonic,
+				Passphrase: Passphrase,
+			})
+			newSeed := oldSeed.DeepCopy()
+			update(&newSeed.Spec)
+
+			err := webhook.ValidateUpdate(ctx, oldSeed, newSeed)
+
+			if expectedField == "" {
+				Expect(err).To(Not(HaveOccurred()))
+				return
+			}
+
+			Expect(errors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring(expectedField))
+			Expect(err.Error()).To(ContainSubstring("field is immutable"))
+		},
+		Entry(
+			"when only mutable fields change",
+			func(spec *SeedSpec) {
+				spec.DriftPolicy = DriftPolicyFlag
+			},
+			"",
+		),
+		Entry(
+			"when the secret name changes",
+			func(spec *SeedSpec) {
+				spec.SecretName = "other"
+			},
+			"spec.secretName",
+		),
+		Entry(
+			"when the mnemonic is moved to a secret",
+			func(spec *SeedSpec) {
+				spec.Mnemonic = ""
+				spec.MnemonicSecretRef = MnemonicSecretRef{SecretName: "seed-material", SecretKey: "mnemonic"}
+			},
+			"spec.mnemonic",
+		),
+		Entry(
+			"when the passphrase changes",
+			func(spec *SeedSpec) {
+				spec.Passphrase = "other"
+			},
+			"spec.passphrase",
+		),
+		Entry(
+			"when the seed becomes deterministic",
+			func(spec *SeedSpec) {
+				spec.Deterministic = &DeterministicSeed{Salt: "fixture"}
+			},
+			"spec.deterministic",
+		),
+	)
+
+	It("accepting updates that do not change an invalid spec", func() {
+		oldSeed := seedWithSpec(SeedSpec{SecretName: "seed", Network: "litecoin"})
+		Expect(webhook.ValidateCreate(ctx, oldSeed)).To(Not(Succeed()))
+
+		By("changing only the metadata")
+		newSeed := oldSeed.DeepCopy()
+		newSeed.Labels = map[string]string{"app": "test"}
+		Expect(webhook.ValidateUpdate(ctx, oldSeed, newSeed)).To(Succeed())
+
+		By("changing the spec while the Seed is deleted")
+		deleted := metav1.Now()
+		newSeed.DeletionTimestamp = &deleted
+		newSeed.Spec.SecretName = "other"
+		Expect(webhook.ValidateUpdate(ctx, oldSeed, newSeed)).To(Succeed())
+	})
+})
@@ -0,0 +1,142 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RestoredFromAnnotation records on a Seed the name of the SeedRestore that recreated it
+const RestoredFromAnnotation = "bitcoin.kiln-fired.github.io/restored-from"
+
+type ConfigMapBackupLocation struct {
+	// Name of the ConfigMap that holds the backup
+	Name string `json:"name"`
+
+	// Key of the ConfigMap that holds the backup, defaults to <seed name>.age. Backups of rotated key material insert
+	// -v<version> before the extension, e.g. <seed name>-v2.age.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+type PersistentVolumeClaimBackupLocation struct {
+	// Name of the PersistentVolumeClaim that holds the backup
+	ClaimName string `json:"claimName"`
+
+	// Path of the backup file relative to the root of the volume, defaults to <seed name>.age. Backups of rotated key
+	// material insert -v<version> before the extension, e.g. <seed name>-v2.age.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Container image of the Job that writes or reads the backup file, it must provide sh and cat
+	// +optional
+	// +kubebuilder:default:="busybox:1.36"
+	Image string `json:"image,omitempty"`
+}
+
+type BackupLocation struct {
+	// Store the backup in a key of a ConfigMap. The ConfigMap is not owned by the backup and outlives it.
+	// +optional
+	ConfigMap *ConfigMapBackupLocation `json:"configMap,omitempty"`
+
+	// Store the backup in a file on a PersistentVolumeClaim, written and read by a Job
+	// +optional
+	PersistentVolumeClaim *PersistentVolumeClaimBackupLocation `json:"persistentVolumeClaim,omitempty"`
+}
+
+type BackupEncryption struct {
+	// age X25519 recipient the backup is encrypted to, e.g. age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+	// +optional
+	Recipient string `json:"recipient,omitempty"`
+
+	// Secret that contains the passphrase the backup is encrypted with, using an age scrypt recipient
+	// +optional
+	PassphraseSecretRef PassphraseSecretRef `json:"passphraseSecretRef,omitempty"`
+}
+
+// SeedBackupSpec defines the desired state of SeedBackup
+type SeedBackupSpec struct {
+	// Name of the Seed to back up, in the namespace of the SeedBackup
+	SeedName string `json:"seedName"`
+
+	// How the backup is encrypted, set either an age recipient or a passphrase
+	Encryption BackupEncryption `json:"encryption"`
+
+	// Where the encrypted backup is written, set either a ConfigMap or a PersistentVolumeClaim
+	Destination BackupLocation `json:"destination"`
+}
+
+// SeedBackupStatus defines the observed state of SeedBackup
+type SeedBackupStatus struct {
+	// Conditions represent the latest available observations of the SeedBackup's state
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// BIP32 fingerprint of the master key in the backup
+	// +optional
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// Wallet birthday encoded in the backed up aezeed cipher seed, lnd rescans the chain from it after a restore
+	// +optional
+	Birthday *metav1.Time `json:"birthday,omitempty"`
+
+	// Location the backup was written to
+	// +optional
+	Location string `json:"location,omitempty"`
+
+	// Time the backup was completed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Generation of the SeedBackup that was last reconciled
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Seed",type=string,JSONPath=`.spec.seedName`
+//+kubebuilder:printcolumn:name="Fingerprint",type=string,JSONPath=`.status.fingerprint`
+//+kubebuilder:printcolumn:name="Location",type=string,JSONPath=`.status.location`,priority=1
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// SeedBackup is the Schema for the seedbackups API
+type SeedBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SeedBackupSpec   `json:"spec,omitempty"`
+	Status SeedBackupStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SeedBackupList contains a list of SeedBackup
+type SeedBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SeedBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SeedBackup{}, &SeedBackupList{})
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type IdentitySecretRef struct {
+	// Name of the secret that contains the age identity
+	SecretName string `json:"secretName,omitempty"`
+
+	// Name of the secret key that contains the age identity, e.g. AGE-SECRET-KEY-1...
+	// +kubebuilder:default:="identity"
+	SecretKey string `json:"secretKey,omitempty"`
+}
+
+type BackupDecryption struct {
+	// Secret that contains the age X25519 identity matching the recipient of the backup
+	// +optional
+	IdentitySecretRef IdentitySecretRef `json:"identitySecretRef,omitempty"`
+
+	// Secret that contains the passphrase the backup was encrypted with
+	// +optional
+	PassphraseSecretRef PassphraseSecretRef `json:"passphraseSecretRef,omitempty"`
+}
+
+// SeedRestoreSpec defines the desired state of SeedRestore
+type SeedRestoreSpec struct {
+	// Where the encrypted backup is read from. The ConfigMap key or file path defaults to <seedName>.age.
+	Source BackupLocation `json:"source"`
+
+	// How the backup is decrypted, set either an age identity or a passphrase
+	Decryption BackupDecryption `json:"decryption"`
+
+	// Name of the Seed to recreate, defaults to the name of the SeedRestore
+	// +optional
+	SeedName string `json:"seedName,omitempty"`
+
+	// Name of the secret to recreate, defaults to the secret name of the backed up Seed
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// SeedRestoreStatus defines the observed state of SeedRestore
+type SeedRestoreStatus struct {
+	// Conditions represent the latest available observations of the SeedRestore's state
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// Name of the recreated Seed
+	// +optional
+	SeedName string `json:"seedName,omitempty"`
+
+	// BIP32 fingerprint of the master key in the backup, verified against the backed up root key
+	// +optional
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// Wallet birthday encoded in the restored aezeed cipher seed, lnd rescans the chain from it
+	// +optional
+	Birthday *metav1.Time `json:"birthday,omitempty"`
+
+	// Time the restore was completed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Generation of the SeedRestore that was last reconciled
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Seed",type=string,JSONPath=`.status.seedName`
+//+kubebuilder:printcolumn:name="Fingerprint",type=string,JSONPath=`.status.fingerprint`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// SeedRestore is the Schema for the seedrestores API
+type SeedRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SeedRestoreSpec   `json:"spec,omitempty"`
+	Status SeedRestoreStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SeedRestoreList contains a list of SeedRestore
+type SeedRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SeedRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SeedRestore{}, &SeedRestoreList{})
+}
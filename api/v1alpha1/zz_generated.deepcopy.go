@@ -22,9 +22,55 @@ limitations under the License.
 package v1alpha1
 
 import (
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccountExport) DeepCopyInto(out *AccountExport) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccountExport.
+func (in *AccountExport) DeepCopy() *AccountExport {
+	if in == nil {
+		return nil
+	}
+	out := new(AccountExport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddressDerivation) DeepCopyInto(out *AddressDerivation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddressDerivation.
+func (in *AddressDerivation) DeepCopy() *AddressDerivation {
+	if in == nil {
+		return nil
+	}
+	out := new(AddressDerivation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthSecretRef) DeepCopyInto(out *AuthSecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthSecretRef.
+func (in *AuthSecretRef) DeepCopy() *AuthSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BTCDContainerImages) DeepCopyInto(out *BTCDContainerImages) {
 	*out = *in
@@ -40,6 +86,64 @@ func (in *BTCDContainerImages) DeepCopy() *BTCDContainerImages {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupDecryption) DeepCopyInto(out *BackupDecryption) {
+	*out = *in
+	out.IdentitySecretRef = in.IdentitySecretRef
+	out.PassphraseSecretRef = in.PassphraseSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupDecryption.
+func (in *BackupDecryption) DeepCopy() *BackupDecryption {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupDecryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupEncryption) DeepCopyInto(out *BackupEncryption) {
+	*out = *in
+	out.PassphraseSecretRef = in.PassphraseSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupEncryption.
+func (in *BackupEncryption) DeepCopy() *BackupEncryption {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupEncryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupLocation) DeepCopyInto(out *BackupLocation) {
+	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(ConfigMapBackupLocation)
+		**out = **in
+	}
+	if in.PersistentVolumeClaim != nil {
+		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
+		*out = new(PersistentVolumeClaimBackupLocation)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupLocation.
+func (in *BackupLocation) DeepCopy() *BackupLocation {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupLocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BitcoinConnection) DeepCopyInto(out *BitcoinConnection) {
 	*out = *in
@@ -61,7 +165,7 @@ func (in *BitcoinNode) DeepCopyInto(out *BitcoinNode) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BitcoinNode.
@@ -114,12 +218,38 @@ func (in *BitcoinNodeList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BitcoinNodePorts) DeepCopyInto(out *BitcoinNodePorts) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BitcoinNodePorts.
+func (in *BitcoinNodePorts) DeepCopy() *BitcoinNodePorts {
+	if in == nil {
+		return nil
+	}
+	out := new(BitcoinNodePorts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BitcoinNodeSpec) DeepCopyInto(out *BitcoinNodeSpec) {
 	*out = *in
 	out.ContainerImages = in.ContainerImages
+	out.Ports = in.Ports
 	out.RPCServer = in.RPCServer
-	out.Mining = in.Mining
+	if in.Peers != nil {
+		in, out := &in.Peers, &out.Peers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PeerSelector != nil {
+		in, out := &in.PeerSelector, &out.PeerSelector
+		*out = new(PeerSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Mining.DeepCopyInto(&out.Mining)
 	in.Resources.DeepCopyInto(&out.Resources)
 }
 
@@ -136,6 +266,32 @@ func (in *BitcoinNodeSpec) DeepCopy() *BitcoinNodeSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BitcoinNodeStatus) DeepCopyInto(out *BitcoinNodeStatus) {
 	*out = *in
+	if in.PeerConnections != nil {
+		in, out := &in.PeerConnections, &out.PeerConnections
+		*out = make([]PeerConnection, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastMinedTime != nil {
+		in, out := &in.LastMinedTime, &out.LastMinedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextBlockInterval != nil {
+		in, out := &in.NextBlockInterval, &out.NextBlockInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.GeneratedSecrets != nil {
+		in, out := &in.GeneratedSecrets, &out.GeneratedSecrets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BitcoinNodeStatus.
@@ -148,6 +304,86 @@ func (in *BitcoinNodeStatus) DeepCopy() *BitcoinNodeStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlockSchedule) DeepCopyInto(out *BlockSchedule) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]BlockScheduleStep, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlockSchedule.
+func (in *BlockSchedule) DeepCopy() *BlockSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(BlockSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlockScheduleStep) DeepCopyInto(out *BlockScheduleStep) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlockScheduleStep.
+func (in *BlockScheduleStep) DeepCopy() *BlockScheduleStep {
+	if in == nil {
+		return nil
+	}
+	out := new(BlockScheduleStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapBackupLocation) DeepCopyInto(out *ConfigMapBackupLocation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapBackupLocation.
+func (in *ConfigMapBackupLocation) DeepCopy() *ConfigMapBackupLocation {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapBackupLocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeterministicSeed) DeepCopyInto(out *DeterministicSeed) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeterministicSeed.
+func (in *DeterministicSeed) DeepCopy() *DeterministicSeed {
+	if in == nil {
+		return nil
+	}
+	out := new(DeterministicSeed)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentitySecretRef) DeepCopyInto(out *IdentitySecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentitySecretRef.
+func (in *IdentitySecretRef) DeepCopy() *IdentitySecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentitySecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LNDContainerImages) DeepCopyInto(out *LNDContainerImages) {
 	*out = *in
@@ -169,7 +405,7 @@ func (in *LightningNode) DeepCopyInto(out *LightningNode) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LightningNode.
@@ -243,6 +479,13 @@ func (in *LightningNodeSpec) DeepCopy() *LightningNodeSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LightningNodeStatus) DeepCopyInto(out *LightningNodeStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LightningNodeStatus.
@@ -259,6 +502,11 @@ func (in *LightningNodeStatus) DeepCopy() *LightningNodeStatus {
 func (in *Mining) DeepCopyInto(out *Mining) {
 	*out = *in
 	out.RewardAddress = in.RewardAddress
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(BlockSchedule)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Mining.
@@ -272,56 +520,58 @@ func (in *Mining) DeepCopy() *Mining {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RPCServer) DeepCopyInto(out *RPCServer) {
+func (in *MiningRequest) DeepCopyInto(out *MiningRequest) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RPCServer.
-func (in *RPCServer) DeepCopy() *RPCServer {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MiningRequest.
+func (in *MiningRequest) DeepCopy() *MiningRequest {
 	if in == nil {
 		return nil
 	}
-	out := new(RPCServer)
+	out := new(MiningRequest)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RewardAddress) DeepCopyInto(out *RewardAddress) {
-	*out = *in
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RewardAddress.
-func (in *RewardAddress) DeepCopy() *RewardAddress {
-	if in == nil {
-		return nil
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MiningRequest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
 	}
-	out := new(RewardAddress)
-	in.DeepCopyInto(out)
-	return out
+	return nil
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Seed) DeepCopyInto(out *Seed) {
+func (in *MiningRequestList) DeepCopyInto(out *MiningRequestList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MiningRequest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Seed.
-func (in *Seed) DeepCopy() *Seed {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MiningRequestList.
+func (in *MiningRequestList) DeepCopy() *MiningRequestList {
 	if in == nil {
 		return nil
 	}
-	out := new(Seed)
+	out := new(MiningRequestList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Seed) DeepCopyObject() runtime.Object {
+func (in *MiningRequestList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -329,78 +579,736 @@ func (in *Seed) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SeedImport) DeepCopyInto(out *SeedImport) {
+func (in *MiningRequestSpec) DeepCopyInto(out *MiningRequestSpec) {
 	*out = *in
+	out.AddressSecretRef = in.AddressSecretRef
+	if in.Transactions != nil {
+		in, out := &in.Transactions, &out.Transactions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedImport.
-func (in *SeedImport) DeepCopy() *SeedImport {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MiningRequestSpec.
+func (in *MiningRequestSpec) DeepCopy() *MiningRequestSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(SeedImport)
+	out := new(MiningRequestSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SeedList) DeepCopyInto(out *SeedList) {
+func (in *MiningRequestStatus) DeepCopyInto(out *MiningRequestStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]Seed, len(*in))
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.BlockHashes != nil {
+		in, out := &in.BlockHashes, &out.BlockHashes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TransactionIDs != nil {
+		in, out := &in.TransactionIDs, &out.TransactionIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedList.
-func (in *SeedList) DeepCopy() *SeedList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MiningRequestStatus.
+func (in *MiningRequestStatus) DeepCopy() *MiningRequestStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(SeedList)
+	out := new(MiningRequestStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *SeedList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MnemonicSecretRef) DeepCopyInto(out *MnemonicSecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MnemonicSecretRef.
+func (in *MnemonicSecretRef) DeepCopy() *MnemonicSecretRef {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(MnemonicSecretRef)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SeedSpec) DeepCopyInto(out *SeedSpec) {
+func (in *PassphraseSecretRef) DeepCopyInto(out *PassphraseSecretRef) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedSpec.
-func (in *SeedSpec) DeepCopy() *SeedSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PassphraseSecretRef.
+func (in *PassphraseSecretRef) DeepCopy() *PassphraseSecretRef {
 	if in == nil {
 		return nil
 	}
-	out := new(SeedSpec)
+	out := new(PassphraseSecretRef)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SeedStatus) DeepCopyInto(out *SeedStatus) {
+func (in *PeerConnection) DeepCopyInto(out *PeerConnection) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedStatus.
-func (in *SeedStatus) DeepCopy() *SeedStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeerConnection.
+func (in *PeerConnection) DeepCopy() *PeerConnection {
 	if in == nil {
 		return nil
 	}
-	out := new(SeedStatus)
+	out := new(PeerConnection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeerSelector) DeepCopyInto(out *PeerSelector) {
+	*out = *in
+	in.LabelSelector.DeepCopyInto(&out.LabelSelector)
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeerSelector.
+func (in *PeerSelector) DeepCopy() *PeerSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(PeerSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PersistentVolumeClaimBackupLocation) DeepCopyInto(out *PersistentVolumeClaimBackupLocation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PersistentVolumeClaimBackupLocation.
+func (in *PersistentVolumeClaimBackupLocation) DeepCopy() *PersistentVolumeClaimBackupLocation {
+	if in == nil {
+		return nil
+	}
+	out := new(PersistentVolumeClaimBackupLocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RPCServer) DeepCopyInto(out *RPCServer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RPCServer.
+func (in *RPCServer) DeepCopy() *RPCServer {
+	if in == nil {
+		return nil
+	}
+	out := new(RPCServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Reorg) DeepCopyInto(out *Reorg) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Reorg.
+func (in *Reorg) DeepCopy() *Reorg {
+	if in == nil {
+		return nil
+	}
+	out := new(Reorg)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Reorg) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReorgList) DeepCopyInto(out *ReorgList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Reorg, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReorgList.
+func (in *ReorgList) DeepCopy() *ReorgList {
+	if in == nil {
+		return nil
+	}
+	out := new(ReorgList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ReorgList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReorgSpec) DeepCopyInto(out *ReorgSpec) {
+	*out = *in
+	if in.IsolatedNodes != nil {
+		in, out := &in.IsolatedNodes, &out.IsolatedNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReorgSpec.
+func (in *ReorgSpec) DeepCopy() *ReorgSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReorgSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReorgStatus) DeepCopyInto(out *ReorgStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DisconnectedPeers != nil {
+		in, out := &in.DisconnectedPeers, &out.DisconnectedPeers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReorgStatus.
+func (in *ReorgStatus) DeepCopy() *ReorgStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReorgStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RewardAddress) DeepCopyInto(out *RewardAddress) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RewardAddress.
+func (in *RewardAddress) DeepCopy() *RewardAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(RewardAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Seed) DeepCopyInto(out *Seed) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Seed.
+func (in *Seed) DeepCopy() *Seed {
+	if in == nil {
+		return nil
+	}
+	out := new(Seed)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Seed) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedBackup) DeepCopyInto(out *SeedBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedBackup.
+func (in *SeedBackup) DeepCopy() *SeedBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SeedBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedBackupList) DeepCopyInto(out *SeedBackupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SeedBackup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedBackupList.
+func (in *SeedBackupList) DeepCopy() *SeedBackupList {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedBackupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SeedBackupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedBackupSpec) DeepCopyInto(out *SeedBackupSpec) {
+	*out = *in
+	out.Encryption = in.Encryption
+	in.Destination.DeepCopyInto(&out.Destination)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedBackupSpec.
+func (in *SeedBackupSpec) DeepCopy() *SeedBackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedBackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedBackupStatus) DeepCopyInto(out *SeedBackupStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Birthday != nil {
+		in, out := &in.Birthday, &out.Birthday
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedBackupStatus.
+func (in *SeedBackupStatus) DeepCopy() *SeedBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedImport) DeepCopyInto(out *SeedImport) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedImport.
+func (in *SeedImport) DeepCopy() *SeedImport {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedImport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedList) DeepCopyInto(out *SeedList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Seed, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedList.
+func (in *SeedList) DeepCopy() *SeedList {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SeedList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedRestore) DeepCopyInto(out *SeedRestore) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedRestore.
+func (in *SeedRestore) DeepCopy() *SeedRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SeedRestore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedRestoreList) DeepCopyInto(out *SeedRestoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SeedRestore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedRestoreList.
+func (in *SeedRestoreList) DeepCopy() *SeedRestoreList {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedRestoreList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SeedRestoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedRestoreSpec) DeepCopyInto(out *SeedRestoreSpec) {
+	*out = *in
+	in.Source.DeepCopyInto(&out.Source)
+	out.Decryption = in.Decryption
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedRestoreSpec.
+func (in *SeedRestoreSpec) DeepCopy() *SeedRestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedRestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedRestoreStatus) DeepCopyInto(out *SeedRestoreStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Birthday != nil {
+		in, out := &in.Birthday, &out.Birthday
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedRestoreStatus.
+func (in *SeedRestoreStatus) DeepCopy() *SeedRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedSharding) DeepCopyInto(out *SeedSharding) {
+	*out = *in
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]ShareTarget, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedSharding.
+func (in *SeedSharding) DeepCopy() *SeedSharding {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedSharding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedShareStatus) DeepCopyInto(out *SeedShareStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedShareStatus.
+func (in *SeedShareStatus) DeepCopy() *SeedShareStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedShareStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedSpec) DeepCopyInto(out *SeedSpec) {
+	*out = *in
+	out.MnemonicSecretRef = in.MnemonicSecretRef
+	out.PassphraseSecretRef = in.PassphraseSecretRef
+	if in.Accounts != nil {
+		in, out := &in.Accounts, &out.Accounts
+		*out = make([]AccountExport, len(*in))
+		copy(*out, *in)
+	}
+	out.Storage = in.Storage
+	if in.Sharding != nil {
+		in, out := &in.Sharding, &out.Sharding
+		*out = new(SeedSharding)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Deterministic != nil {
+		in, out := &in.Deterministic, &out.Deterministic
+		*out = new(DeterministicSeed)
+		**out = **in
+	}
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]AddressDerivation, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedSpec.
+func (in *SeedSpec) DeepCopy() *SeedSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedStatus) DeepCopyInto(out *SeedStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AezeedVersion != nil {
+		in, out := &in.AezeedVersion, &out.AezeedVersion
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Birthday != nil {
+		in, out := &in.Birthday, &out.Birthday
+		*out = (*in).DeepCopy()
+	}
+	if in.Shares != nil {
+		in, out := &in.Shares, &out.Shares
+		*out = make([]SeedShareStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.PreviousVersions != nil {
+		in, out := &in.PreviousVersions, &out.PreviousVersions
+		*out = make([]SeedVersionStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedStatus.
+func (in *SeedStatus) DeepCopy() *SeedStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedStorage) DeepCopyInto(out *SeedStorage) {
+	*out = *in
+	out.AuthSecretRef = in.AuthSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedStorage.
+func (in *SeedStorage) DeepCopy() *SeedStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedVersionStatus) DeepCopyInto(out *SeedVersionStatus) {
+	*out = *in
+	in.RetiredAt.DeepCopyInto(&out.RetiredAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedVersionStatus.
+func (in *SeedVersionStatus) DeepCopy() *SeedVersionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedVersionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShareTarget) DeepCopyInto(out *ShareTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShareTarget.
+func (in *ShareTarget) DeepCopy() *ShareTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(ShareTarget)
 	in.DeepCopyInto(out)
 	return out
 }
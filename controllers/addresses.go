@@ -0,0 +1,149 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+const (
+	addressTypeNP2WKH = "np2wkh"
+	addressTypeP2WKH  = "p2wkh"
+	addressTypeP2TR   = "p2tr"
+)
+
+// addressPurposes maps each address type to the BIP43 purpose of its derivation path
+var addressPurposes = map[string]uint32{
+	addressTypeNP2WKH: 49,
+	addressTypeP2WKH:  84,
+	addressTypeP2TR:   86,
+}
+
+// masterFingerprint returns the BIP32 fingerprint of a master key, the first 4 bytes of the hash160 of its public key
+func masterFingerprint(master *hdkeychain.ExtendedKey) (string, error) {
+	pubKey, err := master.ECPubKey()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(btcutil.Hash160(pubKey.SerializeCompressed())[:4]), nil
+}
+
+// deriveAddresses derives the requested receive addresses from the master key and returns them keyed by
+// secret key. Every address is published as "<type>Address-<account>-<index>", and the first address of
+// each type is additionally published as "<type>Address" so it can be referenced as a mining reward address.
+func deriveAddresses(master *hdkeychain.ExtendedKey, derivations []bitcoinv1alpha1.AddressDerivation, params *chaincfg.Params) (map[string]string, error) {
+	addresses := map[string]string{}
+
+	for _, d := range derivations {
+		purpose, ok := addressPurposes[d.Type]
+		if !ok {
+			return nil, fmt.Errorf("unsupported address type %q", d.Type)
+		}
+
+		accountEnd, err := bitcoinv1alpha1.DerivationEnd(d.Account, d.AccountCount)
+		if err != nil {
+			return nil, err
+		}
+		indexEnd, err := bitcoinv1alpha1.DerivationEnd(d.Index, d.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		// the bounds are computed in 64 bits, so a range that ends at the hardened offset does not wrap around
+		for account := uint64(d.Account); account < accountEnd; account++ {
+			accountKey, err := deriveAccountKey(master, purpose, params.HDCoinType, uint32(account))
+			if err != nil {
+				return nil, err
+			}
+
+			externalKey, err := accountKey.Derive(0)
+			if err != nil {
+				return nil, err
+			}
+
+			for index := uint64(d.Index); index < indexEnd; index++ {
+				key, err := externalKey.Derive(uint32(index))
+				if err != nil {
+					return nil, err
+				}
+
+				address, err := addressForKey(key, d.Type, params)
+				if err != nil {
+					return nil, err
+				}
+
+				encoded := address.EncodeAddress()
+				addresses[fmt.Sprintf("%sAddress-%d-%d", d.Type, account, index)] = encoded
+
+				if _, exists := addresses[d.Type+"Address"]; !exists {
+					addresses[d.Type+"Address"] = encoded
+				}
+			}
+		}
+	}
+
+	return addresses, nil
+}
+
+// deriveAccountKey derives the hardened account key m/purpose'/coinType'/account'
+func deriveAccountKey(master *hdkeychain.ExtendedKey, purpose uint32, coinType uint32, account uint32) (*hdkeychain.ExtendedKey, error) {
+	key := master
+	for _, i := range []uint32{purpose, coinType, account} {
+		var err error
+		key, err = key.Derive(hdkeychain.HardenedKeyStart + i)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+func addressForKey(key *hdkeychain.ExtendedKey, addressType string, params *chaincfg.Params) (btcutil.Address, error) {
+	pubKey, err := key.ECPubKey()
+	if err != nil {
+		return nil, err
+	}
+
+	switch addressType {
+	case addressTypeNP2WKH:
+		witnessAddress, err := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey.SerializeCompressed()), params)
+		if err != nil {
+			return nil, err
+		}
+		script, err := txscript.PayToAddrScript(witnessAddress)
+		if err != nil {
+			return nil, err
+		}
+		return btcutil.NewAddressScriptHash(script, params)
+	case addressTypeP2WKH:
+		return btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey.SerializeCompressed()), params)
+	case addressTypeP2TR:
+		outputKey := txscript.ComputeTaprootKeyNoScript(pubKey)
+		return btcutil.NewAddressTaproot(schnorr.SerializePubKey(outputKey), params)
+	}
+
+	return nil, fmt.Errorf("unsupported address type %q", addressType)
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+const (
+	// bitcoindZMQBlockPort publishes raw blocks to lnd
+	bitcoindZMQBlockPort = 28332
+	// bitcoindZMQTxPort publishes raw transactions to lnd
+	bitcoindZMQTxPort = 28333
+)
+
+// bitcoindRPCAuth returns the -rpcauth value bitcoind checks the RPC credentials against. The salt is derived from
+// the BitcoinNode and the user so that the pod template only changes when the credentials do.
+func bitcoindRPCAuth(b *bitcoinv1alpha1.BitcoinNode, user string, pass string) string {
+	saltSum := sha256.Sum256([]byte(b.Namespace + "/" + b.Name + "/" + user))
+	salt := hex.EncodeToString(saltSum[:16])
+
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(pass))
+	return fmt.Sprintf("%s:%s$%s", user, salt, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// bitcoindChain returns the -chain value of a network, which bitcoind names differently for mainnet and testnet
+func bitcoindChain(network bitcoinv1alpha1.BitcoinNetwork) string {
+	switch {
+	case network.IsMainNet():
+		return "main"
+	case network.Name == "testnet":
+		return "test"
+	}
+	return network.Name
+}
+
+// bitcoindArgs returns the bitcoind flags that select the network, listen on its ports and publish blocks and
+// transactions over ZMQ
+func bitcoindArgs(network bitcoinv1alpha1.BitcoinNetwork, ports nodePorts, rpcAuth string) []string {
+	return []string{
+		"-chain=" + bitcoindChain(network),
+		"-server",
+		"-printtoconsole",
+		"-datadir=/data",
+		"-disablewallet",
+		fmt.Sprintf("-port=%d", ports.P2P),
+		"-rpcbind=0.0.0.0",
+		"-rpcallowip=0.0.0.0/0",
+		fmt.Sprintf("-rpcport=%d", ports.RPC),
+		"-rpcauth=" + rpcAuth,
+		fmt.Sprintf("-zmqpubrawblock=tcp://0.0.0.0:%d", ports.ZMQBlock),
+		fmt.Sprintf("-zmqpubrawtx=tcp://0.0.0.0:%d", ports.ZMQTx),
+	}
+}
+
+// statefulsetForBitcoind returns a bitcoind StatefulSet object
+func (r *BitcoinNodeReconciler) statefulsetForBitcoind(b *bitcoinv1alpha1.BitcoinNode, network bitcoinv1alpha1.BitcoinNetwork, rpcAuth string) *appsv1.StatefulSet {
+	ls := labelsForBitcoinNode(b.Name)
+	size := int32(1)
+	ports := portsForBitcoinNode(b, network)
+
+	securityContext := &corev1.SecurityContext{
+		Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		Privileged:               pointer.Bool(false),
+		RunAsNonRoot:             pointer.Bool(true),
+		AllowPrivilegeEscalation: pointer.Bool(false),
+		SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+
+	bitcoind := corev1.Container{
+		Image:   b.Spec.ContainerImages.BitcoindImage,
+		Name:    "bitcoind",
+		Command: []string{"bitcoind"},
+		Args:    bitcoindArgs(network, ports, rpcAuth),
+		Ports: []corev1.ContainerPort{
+			{
+				ContainerPort: ports.P2P,
+				Name:          "server",
+			},
+			{
+				ContainerPort: ports.RPC,
+				Name:          "rpc",
+			},
+			{
+				ContainerPort: ports.ZMQBlock,
+				Name:          "zmq-block",
+			},
+			{
+				ContainerPort: ports.ZMQTx,
+				Name:          "zmq-tx",
+			},
+		},
+		SecurityContext: securityContext,
+		LivenessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromString("rpc")},
+			},
+			InitialDelaySeconds: 5,
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromString("rpc")},
+			},
+			InitialDelaySeconds: 5,
+		},
+		Resources: b.Spec.Resources,
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      "bitcoind-data",
+				MountPath: "/data",
+			},
+		},
+	}
+
+	ss := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      b.Name,
+			Namespace: b.Namespace,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &size,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: ls,
+			},
+			ServiceName: b.Name,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: ls,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{bitcoind},
+					SecurityContext: &corev1.PodSecurityContext{
+						FSGroup: pointer.Int64(1000),
+					},
+				},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: ls,
+					Name:   "bitcoind-data",
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceStorage: resource.MustParse("2Gi"),
+						},
+					},
+				},
+			}},
+		},
+	}
+
+	err := ctrl.SetControllerReference(b, ss, r.Scheme)
+	if err != nil {
+		return nil
+	}
+	return ss
+}
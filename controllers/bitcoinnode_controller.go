@@ -19,19 +19,25 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/btcutil"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/clock"
 	"k8s.io/utils/pointer"
+	"math/rand"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 	"time"
 
 	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
@@ -41,11 +47,32 @@ import (
 type BitcoinNodeReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// Dialer connects to the RPC servers of the nodes, rpcclient when nil
+	Dialer RPCDialer
+
+	// Clock tells the time periodic blocks are scheduled by, the wall clock when nil
+	Clock clock.PassiveClock
+
+	// Random draws the intervals of exponential block schedules, a source seeded from the time when nil
+	Random *rand.Rand
+
+	// Generator creates the RPC credentials and certificates of nodes whose RPC secrets do not exist, crypto/rand
+	// when nil
+	Generator SecretGenerator
+
+	// AllowCrossNamespacePeers lets the peerSelector of a BitcoinNode select BitcoinNodes in other namespaces
+	AllowCrossNamespacePeers bool
 }
 
+// bitcoinNodeStatusInterval is how often the chain information in the status of a BitcoinNode is refreshed
+const bitcoinNodeStatusInterval = 30 * time.Second
+
 //+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=bitcoinnodes,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=bitcoinnodes/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=bitcoinnodes/finalizers,verbs=update
+//+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=reorgs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=namespaces;pods,verbs=get;list;watch
 //+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=services;secrets,verbs=get;list;watch;create;update;patch;delete
 
@@ -63,14 +90,65 @@ func (r *BitcoinNodeReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	network, err := networkForBitcoinNode(bitcoinNode)
+
+	if err != nil {
+		log.Error(err, "Failed to look up network")
+		return ctrl.Result{}, r.updateReadyCondition(ctx, bitcoinNode, metav1.ConditionFalse, bitcoinv1alpha1.ReasonUnsupportedNetwork, err.Error())
+	}
+
+	err = validateBitcoinNodeSpec(bitcoinNode.Spec, network)
+
+	if err == nil && bitcoinNode.Spec.PeerSelector != nil && bitcoinNode.Spec.PeerSelector.NamespaceSelector != nil && !r.AllowCrossNamespacePeers {
+		err = fmt.Errorf("peerSelector.namespaceSelector requires the operator to run with --allow-cross-namespace-peers")
+	}
+
+	if err != nil {
+		log.Error(err, "Invalid BitcoinNode spec")
+		return ctrl.Result{}, r.updateReadyCondition(ctx, bitcoinNode, metav1.ConditionFalse, bitcoinv1alpha1.ReasonInvalidSpec, err.Error())
+	}
+
+	implementation := implementationOf(bitcoinNode)
+
+	generatedSecrets, err := r.reconcileRPCSecrets(ctx, bitcoinNode)
+
+	if err != nil {
+		log.Error(err, "Failed to generate the RPC secrets")
+		return ctrl.Result{}, err
+	}
+
+	bitcoinNode.Status.GeneratedSecrets = generatedSecrets
+
+	// bitcoind checks the RPC credentials against an rpcauth entry in its arguments, so it needs them up front
+	var rpcUser, rpcPass string
+	if implementation == bitcoinv1alpha1.ImplementationBitcoind {
+		rpcUser, rpcPass, err = rpcCredentials(ctx, r.Client, bitcoinNode)
+		if err != nil {
+			log.Error(err, "Failed to get Secret")
+			if statusErr := r.updateReadyCondition(ctx, bitcoinNode, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSecretRefUnavailable, err.Error()); statusErr != nil {
+				log.Error(statusErr, "Failed to update BitcoinNode status")
+			}
+			return ctrl.Result{}, err
+		}
+	}
+
 	//Reconcile StatefulSet
 	foundStatefulSet := &appsv1.StatefulSet{}
 	err = r.Get(ctx, types.NamespacedName{Name: bitcoinNode.Name, Namespace: bitcoinNode.Namespace}, foundStatefulSet)
 
+	var ss *appsv1.StatefulSet
+	if implementation == bitcoinv1alpha1.ImplementationBitcoind {
+		ss = r.statefulsetForBitcoind(bitcoinNode, network, bitcoindRPCAuth(bitcoinNode, rpcUser, rpcPass))
+	} else {
+		ss = r.statefulsetForBitcoinNode(bitcoinNode, network)
+	}
+
 	if err != nil && errors.IsNotFound(err) {
-		ss := r.statefulsetForBitcoinNode(bitcoinNode)
 		log.Info("Creating a new StatefulSet", "StatefulSet.Namespace", ss.Namespace, "StatefulSet.Name", ss.Name)
-		err = r.Create(ctx, ss)
+		err = annotateSpecHash(ss, ss.Spec)
+		if err == nil {
+			err = r.Create(ctx, ss)
+		}
 		if err != nil {
 			log.Error(err, "Failed to create new StatefulSet", "StatefulSet.Namespace", ss.Namespace, "StatefulSet.Name", ss.Name)
 			return ctrl.Result{}, err
@@ -81,14 +159,28 @@ func (r *BitcoinNodeReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	changed, err := mergeStatefulSet(foundStatefulSet, ss)
+	if err == nil && changed {
+		log.Info("Updating StatefulSet", "StatefulSet.Namespace", foundStatefulSet.Namespace, "StatefulSet.Name", foundStatefulSet.Name)
+		err = r.Update(ctx, foundStatefulSet)
+	}
+	if err != nil {
+		log.Error(err, "Failed to update StatefulSet", "StatefulSet.Namespace", foundStatefulSet.Namespace, "StatefulSet.Name", foundStatefulSet.Name)
+		return ctrl.Result{}, err
+	}
+
 	// Reconcile Service
 	foundService := &corev1.Service{}
 	err = r.Get(ctx, types.NamespacedName{Name: bitcoinNode.Name, Namespace: bitcoinNode.Namespace}, foundService)
 
+	svc := r.serviceForBitcoinNode(bitcoinNode, network)
+
 	if err != nil && errors.IsNotFound(err) {
-		svc := r.serviceForBitcoinNode(bitcoinNode)
 		log.Info("Creating a new Service", "Service.Namespace", svc.Namespace, "Service.Name", svc.Name)
-		err = r.Create(ctx, svc)
+		err = annotateSpecHash(svc, svc.Spec)
+		if err == nil {
+			err = r.Create(ctx, svc)
+		}
 		if err != nil {
 			log.Error(err, "Failed to create new Service", "Service.Namespace", svc.Namespace, "Service.Name", svc.Name)
 			return ctrl.Result{}, err
@@ -99,62 +191,87 @@ func (r *BitcoinNodeReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
-	foundCertSecret := &corev1.Secret{}
-	err = r.Get(ctx, types.NamespacedName{Name: bitcoinNode.Spec.RPCServer.CertSecret, Namespace: bitcoinNode.Namespace}, foundCertSecret)
+	changed, err = mergeService(foundService, svc)
+	if err == nil && changed {
+		log.Info("Updating Service", "Service.Namespace", foundService.Namespace, "Service.Name", foundService.Name)
+		err = r.Update(ctx, foundService)
+	}
+	if err != nil {
+		log.Error(err, "Failed to update Service", "Service.Namespace", foundService.Namespace, "Service.Name", foundService.Name)
+		return ctrl.Result{}, err
+	}
+
+	seedFingerprint, keyMaterialChanged, err := reconcileKeyMaterial(ctx, r.Client, foundStatefulSet, bitcoinNode.Spec.Mining.RewardAddress.SecretName, &bitcoinNode.Status.Conditions)
 
 	if err != nil {
-		log.Error(err, "Failed to get Secret")
+		log.Error(err, "Failed to compare the reward address key material with its Seed")
 		return ctrl.Result{}, err
 	}
 
-	caCert := foundCertSecret.Data["ca.crt"]
+	if seedFingerprint != bitcoinNode.Status.SeedFingerprint || keyMaterialChanged {
+		bitcoinNode.Status.SeedFingerprint = seedFingerprint
+		err = r.Status().Update(ctx, bitcoinNode)
+		if err != nil {
+			log.Error(err, "Failed to update BitcoinNode status")
+			return ctrl.Result{}, err
+		}
+	}
 
-	foundCredSecret := &corev1.Secret{}
-	err = r.Get(ctx, types.NamespacedName{Name: bitcoinNode.Spec.RPCServer.ApiAuthSecretName, Namespace: bitcoinNode.Namespace}, foundCredSecret)
+	connCfg, err := rpcConnConfig(ctx, r.Client, bitcoinNode)
 
 	if err != nil {
 		log.Error(err, "Failed to get Secret")
+		if statusErr := r.updateReadyCondition(ctx, bitcoinNode, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSecretRefUnavailable, err.Error()); statusErr != nil {
+			log.Error(statusErr, "Failed to update BitcoinNode status")
+		}
 		return ctrl.Result{}, err
 	}
 
-	rpcUser := string(foundCredSecret.Data[bitcoinNode.Spec.RPCServer.ApiUserSecretKey])
-	rpcPass := string(foundCredSecret.Data[bitcoinNode.Spec.RPCServer.ApiPasswordSecretKey])
+	btcdClient, err := dialerOr(r.Dialer)(connCfg)
 
-	connCfg := &rpcclient.ConnConfig{
-		Host:         bitcoinNode.Name + "." + bitcoinNode.Namespace + "." + "svc.cluster.local:18556",
-		User:         rpcUser,
-		Pass:         rpcPass,
-		Certificates: caCert,
-		HTTPPostMode: true,
+	if err != nil {
+		log.Error(err, "Failed to create the RPC client")
+		return ctrl.Result{RequeueAfter: time.Second * 10}, r.updateRPCUnreachable(ctx, bitcoinNode, err)
 	}
+	defer btcdClient.Shutdown()
 
-	btcdClient, err := rpcclient.New(connCfg, nil)
 	blockCount, err := btcdClient.GetBlockCount()
 
 	if err != nil {
 		log.Error(err, "Failed to get the block count")
-		return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+		return ctrl.Result{RequeueAfter: time.Second * 10}, r.updateRPCUnreachable(ctx, bitcoinNode, err)
 	}
 
 	log.Info("Retreived block count", "count", blockCount)
 
-	peer := bitcoinNode.Spec.Peer
+	desiredPeers, err := r.desiredPeers(ctx, bitcoinNode, network)
 
-	if peer != "" {
-		perm := "perm"
-		err := btcdClient.Node("connect", peer, &perm)
-		if err != nil {
-			log.Info("Failed to add peer", "error", err.Error())
-			return ctrl.Result{}, err
-		}
-		log.Info("Connected to peer", "peer", peer)
+	if err != nil {
+		log.Error(err, "Failed to list the selected peers")
+		return ctrl.Result{}, err
+	}
+
+	bitcoinNode.Status.PeerConnections, err = r.reconcilePeers(ctx, btcdClient, bitcoinNode, desiredPeers)
+
+	if err != nil {
+		log.Error(err, "Failed to reconcile the peers")
+		return ctrl.Result{RequeueAfter: time.Second * 10}, r.updateRPCUnreachable(ctx, bitcoinNode, err)
 	}
 
 	minBlocks := bitcoinNode.Spec.Mining.MinBlocks
+	var address btcutil.Address
+
+	if (minBlocks != 0 && blockCount < minBlocks) || bitcoinNode.Spec.Mining.PeriodicBlocksEnabled {
+		address, err = rewardAddress(ctx, r.Client, bitcoinNode, network)
+		if err != nil {
+			log.Info("Failed to get the reward address", "error", err.Error())
+			return ctrl.Result{Requeue: true}, nil
+		}
+	}
 
 	if minBlocks != 0 && blockCount < minBlocks {
 		numBlocksToGenerate := minBlocks - blockCount
-		hashes, err := btcdClient.Generate(uint32(numBlocksToGenerate))
+		hashes, err := generateBlocks(btcdClient, implementation, numBlocksToGenerate, address)
 		if err != nil {
 			log.Info("Failed to generate blocks", "error", err.Error())
 			return ctrl.Result{Requeue: true}, nil
@@ -162,90 +279,147 @@ func (r *BitcoinNodeReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		log.Info("Generated blocks", "numBlocks", len(hashes))
 	}
 
-	miningEnabled, err := btcdClient.GetGenerate()
+	requeueAfter := bitcoinNodeStatusInterval
+	nextBlock, err := r.mineScheduledBlock(ctx, btcdClient, bitcoinNode, implementation, address)
 
 	if err != nil {
-		log.Info("Failed to determine if mining is enabled")
-		return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+		log.Info("Failed to generate a scheduled block", "error", err.Error())
+		return ctrl.Result{Requeue: true}, nil
 	}
 
-	log.Info("Got a mining status", "miningEnabled", miningEnabled)
-	enableMining := bitcoinNode.Spec.Mining.CpuMiningEnabled
+	if nextBlock > 0 && nextBlock < requeueAfter {
+		requeueAfter = nextBlock
+	}
+
+	// bitcoind has no CPU miner
+	miningEnabled := false
+	if implementation == bitcoinv1alpha1.ImplementationBtcd {
+		miningEnabled, err = btcdClient.GetGenerate()
 
-	if enableMining && !miningEnabled {
-		err = btcdClient.SetGenerate(true, 1)
 		if err != nil {
-			log.Info("Failed to enable mining", "error", err.Error())
+			log.Info("Failed to determine if mining is enabled")
+			return ctrl.Result{RequeueAfter: time.Second * 10}, r.updateRPCUnreachable(ctx, bitcoinNode, err)
+		}
+
+		log.Info("Got a mining status", "miningEnabled", miningEnabled)
+		enableMining := bitcoinNode.Spec.Mining.CpuMiningEnabled
+
+		if enableMining && !miningEnabled {
+			err = btcdClient.SetGenerate(true, 1)
+			if err != nil {
+				log.Info("Failed to enable mining", "error", err.Error())
+			} else {
+				log.Info("Enabled mining")
+				miningEnabled = true
+			}
 		}
-		log.Info("Enabled mining")
 	}
 
-	bitcoinNode.Status.LastBlockCount = blockCount
+	chainInfo, err := observeChain(btcdClient, implementation, &bitcoinNode.Status)
 
-	err = r.Status().Update(ctx, bitcoinNode)
+	if err != nil {
+		log.Error(err, "Failed to get the chain information")
+		return ctrl.Result{RequeueAfter: time.Second * 10}, r.updateRPCUnreachable(ctx, bitcoinNode, err)
+	}
+
+	setBitcoinNodeCondition(bitcoinNode, bitcoinv1alpha1.ConditionRPCReachable, metav1.ConditionTrue, bitcoinv1alpha1.ReasonRPCResponding, "RPC server at "+connCfg.Host+" is responding")
+	setMiningCondition(bitcoinNode, miningEnabled)
+
+	if chainSyncing(chainInfo) {
+		message := fmt.Sprintf("Downloaded %d of %d blocks", chainInfo.Blocks, chainInfo.Headers)
+		setBitcoinNodeCondition(bitcoinNode, bitcoinv1alpha1.ConditionSyncing, metav1.ConditionTrue, bitcoinv1alpha1.ReasonInitialBlockDownload, message)
+		return ctrl.Result{RequeueAfter: requeueAfter}, r.updateReadyCondition(ctx, bitcoinNode, metav1.ConditionFalse, bitcoinv1alpha1.ReasonInitialBlockDownload, message)
+	}
+
+	setBitcoinNodeCondition(bitcoinNode, bitcoinv1alpha1.ConditionSyncing, metav1.ConditionFalse, bitcoinv1alpha1.ReasonChainSynced, fmt.Sprintf("Synced to block %d", chainInfo.Blocks))
+
+	err = r.updateReadyCondition(ctx, bitcoinNode, metav1.ConditionTrue, bitcoinv1alpha1.ReasonReconciled, "BitcoinNode is synced and its RPC server is responding")
 	if err != nil {
 		log.Error(err, "Failed to update BitcoinNode status")
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
-func (r *BitcoinNodeReconciler) statefulsetForBitcoinNode(b *bitcoinv1alpha1.BitcoinNode) *appsv1.StatefulSet {
-	ls := labelsForBitcoinNode(b.Name)
-	size := int32(1)
+// now returns the time of the clock of the reconciler, the wall clock unless a test injected another one
+func (r *BitcoinNodeReconciler) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+	return r.Clock.Now()
+}
 
-	environment := []corev1.EnvVar{
-		{
-			Name: "RPCUSER",
-			ValueFrom: &corev1.EnvVarSource{
-				SecretKeyRef: &corev1.SecretKeySelector{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: b.Spec.RPCServer.ApiAuthSecretName,
-					},
-					Key: b.Spec.RPCServer.ApiUserSecretKey,
-				},
-			},
-		},
-		{
-			Name: "RPCPASS",
-			ValueFrom: &corev1.EnvVarSource{
-				SecretKeyRef: &corev1.SecretKeySelector{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: b.Spec.RPCServer.ApiAuthSecretName,
-					},
-					Key: b.Spec.RPCServer.ApiPasswordSecretKey,
-				},
-			},
-		},
+// random returns the random source of the reconciler, seeded from the time unless a test injected another one
+func (r *BitcoinNodeReconciler) random() *rand.Rand {
+	if r.Random == nil {
+		return rand.New(rand.NewSource(time.Now().UnixNano()))
 	}
+	return r.Random
+}
 
-	if b.Spec.Mining.RewardAddress.SecretName != "" {
-		rewardAddress := corev1.EnvVar{
-			Name: "MINING_ADDRESS",
-			ValueFrom: &corev1.EnvVarSource{
-				SecretKeyRef: &corev1.SecretKeySelector{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: b.Spec.Mining.RewardAddress.SecretName,
-					},
-					Key: b.Spec.Mining.RewardAddress.SecretKey,
-				},
-			},
-		}
-		environment = append(environment, rewardAddress)
+// generator returns the SecretGenerator of the reconciler, crypto/rand unless a test injected another one
+func (r *BitcoinNodeReconciler) generator() SecretGenerator {
+	if r.Generator == nil {
+		return NewSecretGenerator()
+	}
+	return r.Generator
+}
+
+// updateRPCUnreachable reports a failed RPC call in the RPCReachable and Ready conditions
+func (r *BitcoinNodeReconciler) updateRPCUnreachable(ctx context.Context, b *bitcoinv1alpha1.BitcoinNode, err error) error {
+	setBitcoinNodeCondition(b, bitcoinv1alpha1.ConditionRPCReachable, metav1.ConditionFalse, bitcoinv1alpha1.ReasonRPCUnreachable, err.Error())
+	return r.updateReadyCondition(ctx, b, metav1.ConditionFalse, bitcoinv1alpha1.ReasonRPCUnreachable, err.Error())
+}
+
+func (r *BitcoinNodeReconciler) updateReadyCondition(ctx context.Context, b *bitcoinv1alpha1.BitcoinNode, status metav1.ConditionStatus, reason string, message string) error {
+	b.Status.ObservedGeneration = b.Generation
+	setBitcoinNodeCondition(b, bitcoinv1alpha1.ConditionReady, status, reason, message)
+	return r.Status().Update(ctx, b)
+}
+
+func setBitcoinNodeCondition(b *bitcoinv1alpha1.BitcoinNode, conditionType string, status metav1.ConditionStatus, reason string, message string) {
+	meta.SetStatusCondition(&b.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: b.Generation,
+	})
+}
+
+// setMiningCondition reports whether the node mines blocks with its CPU miner or on a schedule
+func setMiningCondition(b *bitcoinv1alpha1.BitcoinNode, cpuMining bool) {
+	switch {
+	case cpuMining:
+		setBitcoinNodeCondition(b, bitcoinv1alpha1.ConditionMining, metav1.ConditionTrue, bitcoinv1alpha1.ReasonCPUMining, "CPU miner is running")
+	case b.Spec.Mining.PeriodicBlocksEnabled && scheduleFinished(b):
+		setBitcoinNodeCondition(b, bitcoinv1alpha1.ConditionMining, metav1.ConditionFalse, bitcoinv1alpha1.ReasonScheduleFinished, "The last step of the block schedule was mined")
+	case b.Spec.Mining.PeriodicBlocksEnabled:
+		setBitcoinNodeCondition(b, bitcoinv1alpha1.ConditionMining, metav1.ConditionTrue, bitcoinv1alpha1.ReasonPeriodicBlocks, scheduleDescription(b))
+	default:
+		setBitcoinNodeCondition(b, bitcoinv1alpha1.ConditionMining, metav1.ConditionFalse, bitcoinv1alpha1.ReasonMiningDisabled, "Neither CPU mining nor periodic blocks are enabled")
 	}
+}
+
+func (r *BitcoinNodeReconciler) statefulsetForBitcoinNode(b *bitcoinv1alpha1.BitcoinNode, network bitcoinv1alpha1.BitcoinNetwork) *appsv1.StatefulSet {
+	ls := labelsForBitcoinNode(b.Name)
+	size := int32(1)
+	ports := portsForBitcoinNode(b, network)
+	environment := nodeEnvironment(b)
 
 	btcd := corev1.Container{
 		Image:   b.Spec.ContainerImages.BtcdImage,
 		Name:    "btcd",
 		Command: []string{"./start-btcd.sh"},
+		Args:    btcdArgs(network, ports),
 		Ports: []corev1.ContainerPort{
 			{
-				ContainerPort: 18555,
+				ContainerPort: ports.P2P,
 				Name:          "server",
 			},
 			{
-				ContainerPort: 18556,
+				ContainerPort: ports.RPC,
 				Name:          "rpc",
 			},
 		},
@@ -263,7 +437,7 @@ func (r *BitcoinNodeReconciler) statefulsetForBitcoinNode(b *bitcoinv1alpha1.Bit
 					Command: []string{
 						"/bin/bash",
 						"-c",
-						"touch .btcd/btcd.conf && ./start-btcctl.sh getinfo",
+						"touch .btcd/btcd.conf && ./start-btcctl.sh " + btcctlFlags(network, ports) + " getinfo",
 					},
 				},
 			},
@@ -275,7 +449,7 @@ func (r *BitcoinNodeReconciler) statefulsetForBitcoinNode(b *bitcoinv1alpha1.Bit
 					Command: []string{
 						"/bin/bash",
 						"-c",
-						"touch .btcd/btcd.conf && ./start-btcctl.sh getinfo",
+						"touch .btcd/btcd.conf && ./start-btcctl.sh " + btcctlFlags(network, ports) + " getinfo",
 					},
 				},
 			},
@@ -304,47 +478,6 @@ func (r *BitcoinNodeReconciler) statefulsetForBitcoinNode(b *bitcoinv1alpha1.Bit
 		},
 	}
 
-	timer := corev1.Container{
-		Image:   b.Spec.ContainerImages.TimerImage,
-		Name:    "timer",
-		Command: []string{"/bin/sh"},
-		Args:    []string{"-c", fmt.Sprintf("while true; do ./start-btcctl.sh generate 1; sleep %d;done", b.Spec.Mining.SecondsPerBlock)},
-		Env:     environment,
-		SecurityContext: &corev1.SecurityContext{
-			Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
-			Privileged:               pointer.Bool(false),
-			RunAsNonRoot:             pointer.Bool(true),
-			AllowPrivilegeEscalation: pointer.Bool(false),
-			SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
-		},
-		VolumeMounts: []corev1.VolumeMount{
-			{
-				Name:      "btcd-home",
-				MountPath: ".btcd",
-			},
-			{
-				Name:      "btcd-data",
-				MountPath: "data",
-			},
-			{
-				Name:      "rpc-cert",
-				MountPath: "/rpc/rpc.cert",
-				SubPath:   "tls.crt",
-			},
-			{
-				Name:      "rpc-cert",
-				MountPath: "/rpc/rpc.key",
-				SubPath:   "tls.key",
-			},
-		},
-	}
-
-	containers := []corev1.Container{btcd}
-
-	if b.Spec.Mining.PeriodicBlocksEnabled == true {
-		containers = append(containers, timer)
-	}
-
 	ss := &appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      b.Name,
@@ -361,7 +494,7 @@ func (r *BitcoinNodeReconciler) statefulsetForBitcoinNode(b *bitcoinv1alpha1.Bit
 					Labels: ls,
 				},
 				Spec: corev1.PodSpec{
-					Containers: containers,
+					Containers: []corev1.Container{btcd},
 					Volumes: []corev1.Volume{
 						{
 							Name: "btcd-home",
@@ -373,7 +506,7 @@ func (r *BitcoinNodeReconciler) statefulsetForBitcoinNode(b *bitcoinv1alpha1.Bit
 							Name: "rpc-cert",
 							VolumeSource: corev1.VolumeSource{
 								Secret: &corev1.SecretVolumeSource{
-									SecretName: b.Spec.RPCServer.CertSecret,
+									SecretName: rpcServerFor(b).CertSecret,
 								},
 							},
 						},
@@ -404,8 +537,9 @@ func (r *BitcoinNodeReconciler) statefulsetForBitcoinNode(b *bitcoinv1alpha1.Bit
 	return ss
 }
 
-func (r *BitcoinNodeReconciler) serviceForBitcoinNode(b *bitcoinv1alpha1.BitcoinNode) *corev1.Service {
+func (r *BitcoinNodeReconciler) serviceForBitcoinNode(b *bitcoinv1alpha1.BitcoinNode, network bitcoinv1alpha1.BitcoinNetwork) *corev1.Service {
 	ls := labelsForBitcoinNode(b.Name)
+	ports := portsForBitcoinNode(b, network)
 
 	svc := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -419,14 +553,14 @@ func (r *BitcoinNodeReconciler) serviceForBitcoinNode(b *bitcoinv1alpha1.Bitcoin
 				{
 					Name:       "server",
 					Protocol:   "TCP",
-					Port:       18555,
-					TargetPort: intstr.FromInt(18555),
+					Port:       ports.P2P,
+					TargetPort: intstr.FromInt(int(ports.P2P)),
 				},
 				{
 					Name:       "rpc",
 					Protocol:   "TCP",
-					Port:       18556,
-					TargetPort: intstr.FromInt(18556),
+					Port:       ports.RPC,
+					TargetPort: intstr.FromInt(int(ports.RPC)),
 				},
 			},
 			Selector:                 ls,
@@ -435,6 +569,23 @@ func (r *BitcoinNodeReconciler) serviceForBitcoinNode(b *bitcoinv1alpha1.Bitcoin
 		},
 	}
 
+	if implementationOf(b) == bitcoinv1alpha1.ImplementationBitcoind {
+		svc.Spec.Ports = append(svc.Spec.Ports,
+			corev1.ServicePort{
+				Name:       "zmq-block",
+				Protocol:   "TCP",
+				Port:       ports.ZMQBlock,
+				TargetPort: intstr.FromInt(int(ports.ZMQBlock)),
+			},
+			corev1.ServicePort{
+				Name:       "zmq-tx",
+				Protocol:   "TCP",
+				Port:       ports.ZMQTx,
+				TargetPort: intstr.FromInt(int(ports.ZMQTx)),
+			},
+		)
+	}
+
 	err := ctrl.SetControllerReference(b, svc, r.Scheme)
 	if err != nil {
 		return nil
@@ -442,6 +593,120 @@ func (r *BitcoinNodeReconciler) serviceForBitcoinNode(b *bitcoinv1alpha1.Bitcoin
 	return svc
 }
 
+// nodePorts are the ports a BitcoinNode listens on
+type nodePorts struct {
+	P2P      int32
+	RPC      int32
+	ZMQBlock int32
+	ZMQTx    int32
+}
+
+// networkForBitcoinNode returns the network of a BitcoinNode
+func networkForBitcoinNode(b *bitcoinv1alpha1.BitcoinNode) (bitcoinv1alpha1.BitcoinNetwork, error) {
+	name := b.Spec.Network
+	if name == "" {
+		name = bitcoinv1alpha1.DefaultNetwork
+	}
+	return bitcoinv1alpha1.LookupNetwork(name)
+}
+
+// implementationOf returns the node implementation of a BitcoinNode, btcd unless bitcoind was selected
+func implementationOf(b *bitcoinv1alpha1.BitcoinNode) string {
+	if b.Spec.Implementation == bitcoinv1alpha1.ImplementationBitcoind {
+		return bitcoinv1alpha1.ImplementationBitcoind
+	}
+	return bitcoinv1alpha1.ImplementationBtcd
+}
+
+// portsForBitcoinNode returns the default ports of the network and implementation of a BitcoinNode with the ports
+// overridden in its spec
+func portsForBitcoinNode(b *bitcoinv1alpha1.BitcoinNode, network bitcoinv1alpha1.BitcoinNetwork) nodePorts {
+	ports := nodePorts{
+		P2P:      network.P2PPort,
+		RPC:      network.BtcdRPCPort,
+		ZMQBlock: bitcoindZMQBlockPort,
+		ZMQTx:    bitcoindZMQTxPort,
+	}
+	if implementationOf(b) == bitcoinv1alpha1.ImplementationBitcoind {
+		ports.RPC = network.BitcoindRPCPort
+	}
+
+	if b.Spec.Ports.P2P != 0 {
+		ports.P2P = b.Spec.Ports.P2P
+	}
+	if b.Spec.Ports.RPC != 0 {
+		ports.RPC = b.Spec.Ports.RPC
+	}
+	return ports
+}
+
+// btcdArgs returns the btcd flags that select the network and listen on its ports
+func btcdArgs(network bitcoinv1alpha1.BitcoinNetwork, ports nodePorts) []string {
+	args := []string{
+		fmt.Sprintf("--listen=0.0.0.0:%d", ports.P2P),
+		fmt.Sprintf("--rpclisten=0.0.0.0:%d", ports.RPC),
+	}
+	if !network.IsMainNet() {
+		args = append([]string{"--" + network.Name}, args...)
+	}
+	return args
+}
+
+// btcctlFlags returns the btcctl flags that select the network and the RPC port of the local btcd
+func btcctlFlags(network bitcoinv1alpha1.BitcoinNetwork, ports nodePorts) string {
+	flags := fmt.Sprintf("--rpcserver=localhost:%d", ports.RPC)
+	if !network.IsMainNet() {
+		flags = "--" + network.Name + " " + flags
+	}
+	return flags
+}
+
+// nodeEnvironment returns the RPC credential and reward address variables of the btcd container
+func nodeEnvironment(b *bitcoinv1alpha1.BitcoinNode) []corev1.EnvVar {
+	rpcServer := rpcServerFor(b)
+	environment := []corev1.EnvVar{
+		{
+			Name: "RPCUSER",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: rpcServer.ApiAuthSecretName,
+					},
+					Key: rpcServer.ApiUserSecretKey,
+				},
+			},
+		},
+		{
+			Name: "RPCPASS",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: rpcServer.ApiAuthSecretName,
+					},
+					Key: rpcServer.ApiPasswordSecretKey,
+				},
+			},
+		},
+	}
+
+	if b.Spec.Mining.RewardAddress.SecretName != "" {
+		rewardAddress := corev1.EnvVar{
+			Name: "MINING_ADDRESS",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: b.Spec.Mining.RewardAddress.SecretName,
+					},
+					Key: b.Spec.Mining.RewardAddress.SecretKey,
+				},
+			},
+		}
+		environment = append(environment, rewardAddress)
+	}
+
+	return environment
+}
+
 func labelsForBitcoinNode(name string) map[string]string {
 	return map[string]string{"app": "bitcoinnode", "bitcoinnode_cr": name}
 }
@@ -452,5 +717,25 @@ func (r *BitcoinNodeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&bitcoinv1alpha1.BitcoinNode{}).
 		Owns(&appsv1.StatefulSet{}).
 		Owns(&corev1.Service{}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.bitcoinNodesForSecret)).
 		Complete(r)
 }
+
+// bitcoinNodesForSecret maps a Seed secret to the BitcoinNodes that mine to one of its addresses, so a rotation is
+// reported on the nodes, and an RPC secret to the BitcoinNodes that use it, so a deleted secret is generated again
+func (r *BitcoinNodeReconciler) bitcoinNodesForSecret(secret client.Object) []reconcile.Request {
+	bitcoinNodes := &bitcoinv1alpha1.BitcoinNodeList{}
+	err := r.List(context.Background(), bitcoinNodes, client.InNamespace(secret.GetNamespace()))
+	if err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, b := range bitcoinNodes.Items {
+		rpcServer := rpcServerFor(&b)
+		if b.Spec.Mining.RewardAddress.SecretName == secret.GetName() || rpcServer.CertSecret == secret.GetName() || rpcServer.ApiAuthSecretName == secret.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: b.Name, Namespace: b.Namespace}})
+		}
+	}
+	return requests
+}
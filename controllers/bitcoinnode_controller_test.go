@@ -2,12 +2,27 @@ package controllers
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	testingclock "k8s.io/utils/clock/testing"
+	"math/rand"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"time"
 
@@ -18,12 +33,63 @@ var _ = Describe("BitcoinNode controller", func() {
 
 	const Namespace = "test-namespace"
 	const BitcoinNodeName = "test"
+	const RewardAddressSecretName = "mining-address"
 
 	ctx := context.Background()
 	bitcoinNodeNamespaceName := types.NamespacedName{Namespace: Namespace, Name: BitcoinNodeName}
 	statefulSetNamespaceName := types.NamespacedName{Namespace: Namespace, Name: BitcoinNodeName}
 
+	rpcServer := bitcoinv1alpha1.RPCServer{
+		CertSecret:           "btcd-rpc-tls",
+		ApiAuthSecretName:    "btcd-rpc-creds",
+		ApiUserSecretKey:     "username",
+		ApiPasswordSecretKey: "password",
+	}
+
+	createRPCSecrets := func() {
+		By("creating the RPC certificate and credential secrets")
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: rpcServer.CertSecret, Namespace: Namespace},
+			StringData: map[string]string{"ca.crt": "", "tls.crt": "", "tls.key": ""},
+		})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: rpcServer.ApiAuthSecretName, Namespace: Namespace},
+			StringData: map[string]string{"username": "kiln", "password": "secret"},
+		})).To(Succeed())
+	}
+
+	// fakeClock schedules the periodic blocks of the reconciled BitcoinNodes
+	var fakeClock *testingclock.FakeClock
+
+	// randomSeed seeds the intervals of exponential block schedules
+	const randomSeed = 42
+	var random *rand.Rand
+
+	// reconcileBitcoinNode reconciles a BitcoinNode against the fake node until the StatefulSet and Service exist and
+	// the status was reported
+	reconcileBitcoinNode := func(node *fakeBitcoinRPC) ctrl.Result {
+		bitcoinNodeReconciler := BitcoinNodeReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+			Dialer: node.dial,
+			Clock:  fakeClock,
+			Random: random,
+		}
+		var result ctrl.Result
+		for i := 0; i < 3; i++ {
+			var err error
+			result, err = bitcoinNodeReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: bitcoinNodeNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+		return result
+	}
+
 	BeforeEach(func() {
+		fakeClock = testingclock.NewFakeClock(time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC))
+		random = rand.New(rand.NewSource(randomSeed))
+
 		By("creating namespace to perform the tests")
 		_ = k8sClient.Create(ctx, &corev1.Namespace{
 			ObjectMeta: metav1.ObjectMeta{
@@ -44,9 +110,25 @@ var _ = Describe("BitcoinNode controller", func() {
 		By("cleaning up StatefulSet")
 		statefulSet := &appsv1.StatefulSet{}
 		err = k8sClient.Get(ctx, statefulSetNamespaceName, statefulSet)
-		Expect(err).To(Not(HaveOccurred()))
-		err = k8sClient.Delete(ctx, statefulSet)
-		Expect(err).To(Not(HaveOccurred()))
+		if err == nil {
+			err = k8sClient.Delete(ctx, statefulSet)
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		By("cleaning up Service")
+		service := &corev1.Service{}
+		err = k8sClient.Get(ctx, statefulSetNamespaceName, service)
+		if err == nil {
+			err = k8sClient.Delete(ctx, service)
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		By("cleaning up the RPC secrets")
+		_ = k8sClient.Delete(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: rpcServer.CertSecret, Namespace: Namespace}})
+		_ = k8sClient.Delete(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: rpcServer.ApiAuthSecretName, Namespace: Namespace}})
+		_ = k8sClient.Delete(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: RewardAddressSecretName, Namespace: Namespace}})
+		_ = k8sClient.Delete(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: BitcoinNodeName + "-rpc-tls", Namespace: Namespace}})
+		_ = k8sClient.Delete(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: BitcoinNodeName + "-rpc-creds", Namespace: Namespace}})
 	})
 
 	It("should reconcile the BitcoinNode instance", func() {
@@ -153,11 +235,810 @@ var _ = Describe("BitcoinNode controller", func() {
 			return nil
 		}, time.Minute, time.Second).Should(Succeed())
 
-		By("checking for the existence of a timer container")
+		By("checking that periodic blocks are not mined by a timer container")
 		Eventually(func() error {
-			Expect(len(foundStatefulSet.Spec.Template.Spec.Containers)).To(Equal(2))
+			Expect(len(foundStatefulSet.Spec.Template.Spec.Containers)).To(Equal(1))
 			return nil
 		}, time.Minute, time.Second).Should(Succeed())
 	})
 
+	It("reporting the chain information in the BitcoinNode status", func() {
+		createRPCSecrets()
+		bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BitcoinNodeName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+				Mining: bitcoinv1alpha1.Mining{
+					CpuMiningEnabled: true,
+					MinBlocks:        100,
+				},
+				RPCServer: rpcServer,
+			},
+		}
+
+		By("creating the custom resource for the kind BitcoinNode")
+		Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+
+		By("reconciling the custom resource against a node with peers and mempool transactions")
+		node := newFakeBitcoinRPC(10)
+		node.peers = []btcjson.GetPeerInfoResult{{Addr: "10.0.0.1:18555"}, {Addr: "10.0.0.2:18555"}}
+		node.mempool = 3
+		result := reconcileBitcoinNode(node)
+		Expect(result.RequeueAfter).To(Equal(bitcoinNodeStatusInterval))
+		Expect(node.height()).To(Equal(int64(100)))
+
+		By("checking if the status reports the chain of the node")
+		found := &bitcoinv1alpha1.BitcoinNode{}
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+		Expect(found.Status.LastBlockCount).To(Equal(int64(100)))
+		Expect(found.Status.BestBlockHash).To(Equal(node.bestBlockHash()))
+		Expect(found.Status.Difficulty).To(Equal("1"))
+		Expect(found.Status.Peers).To(Equal(int32(2)))
+		Expect(found.Status.MempoolSize).To(Equal(int64(3)))
+		Expect(found.Status.Version).To(Equal("0.23.4"))
+		Expect(found.Status.Network).To(Equal("simnet"))
+		Expect(found.Status.ObservedGeneration).To(Equal(found.Generation))
+		Expect(found.Status.GeneratedSecrets).To(BeEmpty())
+
+		By("checking the conditions of the node")
+		Expect(meta.IsStatusConditionTrue(found.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(found.Status.Conditions, bitcoinv1alpha1.ConditionRPCReachable)).To(BeTrue())
+		Expect(meta.IsStatusConditionFalse(found.Status.Conditions, bitcoinv1alpha1.ConditionSyncing)).To(BeTrue())
+		mining := meta.FindStatusCondition(found.Status.Conditions, bitcoinv1alpha1.ConditionMining)
+		Expect(mining).To(Not(BeNil()))
+		Expect(mining.Status).To(Equal(metav1.ConditionTrue))
+		Expect(mining.Reason).To(Equal(bitcoinv1alpha1.ReasonCPUMining))
+
+		By("reporting a node that is behind its headers as syncing")
+		node.headers = 150
+		reconcileBitcoinNode(node)
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(found.Status.Conditions, bitcoinv1alpha1.ConditionSyncing)).To(BeTrue())
+		ready := meta.FindStatusCondition(found.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(ready).To(Not(BeNil()))
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal(bitcoinv1alpha1.ReasonInitialBlockDownload))
+	})
+
+	It("mining periodic blocks on the schedule of the BitcoinNode", func() {
+		createRPCSecrets()
+		bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BitcoinNodeName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+				Mining: bitcoinv1alpha1.Mining{
+					PeriodicBlocksEnabled: true,
+					SecondsPerBlock:       60,
+				},
+				RPCServer: rpcServer,
+			},
+		}
+
+		By("creating the custom resource for the kind BitcoinNode")
+		Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+
+		By("mining the first block when the node is reconciled")
+		node := newFakeBitcoinRPC(1)
+		result := reconcileBitcoinNode(node)
+		Expect(node.height()).To(Equal(int64(2)))
+		Expect(result.RequeueAfter).To(Equal(bitcoinNodeStatusInterval))
+
+		found := &bitcoinv1alpha1.BitcoinNode{}
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+		Expect(found.Status.LastMinedTime).To(Not(BeNil()))
+		Expect(found.Status.LastMinedTime.Time.Equal(fakeClock.Now())).To(BeTrue())
+		Expect(found.Status.LastMinedHeight).To(Equal(int64(2)))
+
+		By("waiting for the block interval to elapse before mining the next block")
+		fakeClock.Step(30 * time.Second)
+		result = reconcileBitcoinNode(node)
+		Expect(node.height()).To(Equal(int64(2)))
+		Expect(result.RequeueAfter).To(Equal(30 * time.Second))
+
+		fakeClock.Step(30 * time.Second)
+		reconcileBitcoinNode(node)
+		Expect(node.height()).To(Equal(int64(3)))
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+		Expect(found.Status.LastMinedHeight).To(Equal(int64(3)))
+
+		By("applying a shorter block interval to the next block")
+		found.Spec.Mining.SecondsPerBlock = 10
+		Expect(k8sClient.Update(ctx, found)).To(Succeed())
+		result = reconcileBitcoinNode(node)
+		Expect(node.height()).To(Equal(int64(3)))
+		Expect(result.RequeueAfter).To(Equal(10 * time.Second))
+
+		fakeClock.Step(10 * time.Second)
+		reconcileBitcoinNode(node)
+		Expect(node.height()).To(Equal(int64(4)))
+
+		By("checking that no timer container runs in the StatefulSet")
+		foundStatefulSet := &appsv1.StatefulSet{}
+		Expect(k8sClient.Get(ctx, statefulSetNamespaceName, foundStatefulSet)).To(Succeed())
+		Expect(foundStatefulSet.Spec.Template.Spec.Containers).To(HaveLen(1))
+	})
+
+	It("not mining a scheduled block again when its status could not be written", func() {
+		createRPCSecrets()
+		bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BitcoinNodeName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+				Mining: bitcoinv1alpha1.Mining{
+					PeriodicBlocksEnabled: true,
+					SecondsPerBlock:       60,
+				},
+				RPCServer: rpcServer,
+			},
+		}
+
+		By("creating the custom resource for the kind BitcoinNode")
+		Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+
+		By("mining the first block when the node is reconciled")
+		node := newFakeBitcoinRPC(1)
+		reconcileBitcoinNode(node)
+		Expect(node.height()).To(Equal(int64(2)))
+
+		By("reconciling the next block while status updates fail")
+		fakeClock.Step(60 * time.Second)
+		failingReconciler := BitcoinNodeReconciler{
+			Client: &failingStatusClient{Client: k8sClient},
+			Scheme: k8sClient.Scheme(),
+			Dialer: node.dial,
+			Clock:  fakeClock,
+			Random: random,
+		}
+		_, _ = failingReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: bitcoinNodeNamespaceName,
+		})
+		Expect(node.height()).To(Equal(int64(2)))
+
+		By("mining the block once the status can be written")
+		reconcileBitcoinNode(node)
+		Expect(node.height()).To(Equal(int64(3)))
+	})
+
+	It("mining blocks at exponentially distributed intervals", func() {
+		createRPCSecrets()
+		bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BitcoinNodeName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+				Mining: bitcoinv1alpha1.Mining{
+					PeriodicBlocksEnabled: true,
+					Schedule: &bitcoinv1alpha1.BlockSchedule{
+						Type:        bitcoinv1alpha1.BlockScheduleExponential,
+						MeanSeconds: 600,
+					},
+				},
+				RPCServer: rpcServer,
+			},
+		}
+
+		By("creating the custom resource for the kind BitcoinNode")
+		Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+
+		By("drawing the intervals from the same seeded source as the reconciler")
+		expected := rand.New(rand.NewSource(randomSeed))
+
+		By("mining the first block and drawing the interval until the next one")
+		node := newFakeBitcoinRPC(1)
+		reconcileBitcoinNode(node)
+		Expect(node.height()).To(Equal(int64(2)))
+
+		found := &bitcoinv1alpha1.BitcoinNode{}
+		for height := int64(3); height <= 5; height++ {
+			interval := exponentialInterval(expected, 600*time.Second)
+			Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+			Expect(found.Status.NextBlockInterval).To(Not(BeNil()))
+			Expect(found.Status.NextBlockInterval.Duration).To(Equal(interval))
+
+			By("mining the next block once the drawn interval elapsed")
+			fakeClock.Step(interval - time.Second)
+			reconcileBitcoinNode(node)
+			Expect(node.height()).To(Equal(height - 1))
+			fakeClock.Step(time.Second)
+			reconcileBitcoinNode(node)
+			Expect(node.height()).To(Equal(height))
+		}
+	})
+
+	It("mining blocks on a scripted schedule", func() {
+		createRPCSecrets()
+		bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BitcoinNodeName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+				Mining: bitcoinv1alpha1.Mining{
+					PeriodicBlocksEnabled: true,
+					Schedule: &bitcoinv1alpha1.BlockSchedule{
+						Type: bitcoinv1alpha1.BlockScheduleScripted,
+						Steps: []bitcoinv1alpha1.BlockScheduleStep{
+							{Blocks: 1, Seconds: 5},
+							{Blocks: 6, Seconds: 3600},
+							{Blocks: 1, Seconds: 5},
+						},
+					},
+				},
+				RPCServer: rpcServer,
+			},
+		}
+
+		By("creating the custom resource for the kind BitcoinNode")
+		Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+
+		By("mining the first step right away")
+		node := newFakeBitcoinRPC(1)
+		result := reconcileBitcoinNode(node)
+		Expect(node.height()).To(Equal(int64(2)))
+		Expect(result.RequeueAfter).To(Equal(5 * time.Second))
+
+		By("mining a burst of blocks after the interval of the first step")
+		fakeClock.Step(5 * time.Second)
+		result = reconcileBitcoinNode(node)
+		Expect(node.height()).To(Equal(int64(8)))
+		Expect(result.RequeueAfter).To(Equal(bitcoinNodeStatusInterval))
+
+		By("waiting out the long gap after the burst")
+		fakeClock.Step(3599 * time.Second)
+		reconcileBitcoinNode(node)
+		Expect(node.height()).To(Equal(int64(8)))
+		fakeClock.Step(time.Second)
+		reconcileBitcoinNode(node)
+		Expect(node.height()).To(Equal(int64(9)))
+
+		By("stopping after the last step")
+		fakeClock.Step(time.Hour)
+		reconcileBitcoinNode(node)
+		Expect(node.height()).To(Equal(int64(9)))
+
+		found := &bitcoinv1alpha1.BitcoinNode{}
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+		Expect(found.Status.ScheduleStep).To(Equal(int32(3)))
+		mining := meta.FindStatusCondition(found.Status.Conditions, bitcoinv1alpha1.ConditionMining)
+		Expect(mining).To(Not(BeNil()))
+		Expect(mining.Status).To(Equal(metav1.ConditionFalse))
+		Expect(mining.Reason).To(Equal(bitcoinv1alpha1.ReasonScheduleFinished))
+	})
+
+	It("starting a block schedule over when it is changed mid-run", func() {
+		createRPCSecrets()
+		bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BitcoinNodeName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+				Mining: bitcoinv1alpha1.Mining{
+					PeriodicBlocksEnabled: true,
+					Schedule: &bitcoinv1alpha1.BlockSchedule{
+						Type: bitcoinv1alpha1.BlockScheduleScripted,
+						Steps: []bitcoinv1alpha1.BlockScheduleStep{
+							{Blocks: 1, Seconds: 3600},
+							{Blocks: 1, Seconds: 3600},
+						},
+					},
+				},
+				RPCServer: rpcServer,
+			},
+		}
+
+		By("creating the custom resource for the kind BitcoinNode")
+		Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+
+		By("mining the first step right away")
+		node := newFakeBitcoinRPC(1)
+		reconcileBitcoinNode(node)
+		Expect(node.height()).To(Equal(int64(2)))
+
+		By("replacing the steps while the first interval runs")
+		found := &bitcoinv1alpha1.BitcoinNode{}
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+		found.Spec.Mining.Schedule.Steps = []bitcoinv1alpha1.BlockScheduleStep{{Blocks: 3, Seconds: 5}}
+		Expect(k8sClient.Update(ctx, found)).To(Succeed())
+
+		By("mining the first step of the new schedule right away")
+		reconcileBitcoinNode(node)
+		Expect(node.height()).To(Equal(int64(5)))
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+		Expect(found.Status.ScheduleStep).To(Equal(int32(1)))
+		Expect(found.Status.NextBlockInterval.Duration).To(Equal(5 * time.Second))
+
+		By("stopping after the last step of the new schedule")
+		fakeClock.Step(time.Hour)
+		reconcileBitcoinNode(node)
+		Expect(node.height()).To(Equal(int64(5)))
+
+		By("restarting the finished schedule when it is made to repeat")
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+		found.Spec.Mining.Schedule.Repeat = true
+		Expect(k8sClient.Update(ctx, found)).To(Succeed())
+		result := reconcileBitcoinNode(node)
+		Expect(node.height()).To(Equal(int64(8)))
+		Expect(result.RequeueAfter).To(Equal(5 * time.Second))
+	})
+
+	It("generating the RPC secrets when they do not exist", func() {
+		bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BitcoinNodeName,
+				Namespace: Namespace,
+			},
+		}
+
+		By("creating the custom resource for the kind BitcoinNode")
+		Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+
+		By("reconciling the custom resource created")
+		node := newFakeBitcoinRPC(1)
+		reconcileBitcoinNode(node)
+
+		found := &bitcoinv1alpha1.BitcoinNode{}
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+		Expect(found.Status.GeneratedSecrets).To(Equal([]string{BitcoinNodeName + "-rpc-creds", BitcoinNodeName + "-rpc-tls"}))
+
+		By("checking the generated credentials are owned by the BitcoinNode and used to connect")
+		credSecret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: BitcoinNodeName + "-rpc-creds", Namespace: Namespace}, credSecret)).To(Succeed())
+		Expect(metav1.IsControlledBy(credSecret, found)).To(BeTrue())
+		Expect(credSecret.Data["username"]).To(HaveLen(rpcUserLength))
+		Expect(credSecret.Data["password"]).To(HaveLen(DefaultSecretLength))
+		Expect(node.config.User).To(Equal(string(credSecret.Data["username"])))
+		Expect(node.config.Pass).To(Equal(string(credSecret.Data["password"])))
+
+		By("checking the generated certificate is issued by the CA for the names of the headless Service")
+		certSecret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: BitcoinNodeName + "-rpc-tls", Namespace: Namespace}, certSecret)).To(Succeed())
+		Expect(metav1.IsControlledBy(certSecret, found)).To(BeTrue())
+		Expect(certSecret.Type).To(Equal(corev1.SecretTypeTLS))
+		Expect(node.config.Certificates).To(Equal(certSecret.Data["ca.crt"]))
+
+		_, err := tls.X509KeyPair(certSecret.Data["tls.crt"], certSecret.Data["tls.key"])
+		Expect(err).To(Not(HaveOccurred()))
+		roots := x509.NewCertPool()
+		Expect(roots.AppendCertsFromPEM(certSecret.Data["ca.crt"])).To(BeTrue())
+		block, _ := pem.Decode(certSecret.Data["tls.crt"])
+		Expect(block).To(Not(BeNil()))
+		serverCert, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).To(Not(HaveOccurred()))
+		for _, name := range []string{"test.test-namespace.svc.cluster.local", "test-0.test.test-namespace.svc.cluster.local", "localhost"} {
+			_, err = serverCert.Verify(x509.VerifyOptions{DNSName: name, Roots: roots})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		By("checking the StatefulSet mounts the generated secrets")
+		foundStatefulSet := &appsv1.StatefulSet{}
+		Expect(k8sClient.Get(ctx, statefulSetNamespaceName, foundStatefulSet)).To(Succeed())
+		Expect(foundStatefulSet.Spec.Template.Spec.Volumes).To(ContainElement(HaveField("VolumeSource.Secret.SecretName", BitcoinNodeName+"-rpc-tls")))
+		Expect(foundStatefulSet.Spec.Template.Spec.Containers[0].Env[0].ValueFrom.SecretKeyRef.Name).To(Equal(BitcoinNodeName + "-rpc-creds"))
+
+		By("keeping the generated credentials on later reconciles")
+		reconcileBitcoinNode(node)
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: BitcoinNodeName + "-rpc-creds", Namespace: Namespace}, credSecret)).To(Succeed())
+		Expect(node.config.Pass).To(Equal(string(credSecret.Data["password"])))
+	})
+
+	It("reporting an RPC server that cannot be reached", func() {
+		createRPCSecrets()
+		bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BitcoinNodeName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+				RPCServer: rpcServer,
+			},
+		}
+
+		By("creating the custom resource for the kind BitcoinNode")
+		Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+
+		By("reconciling the custom resource against a node that does not answer")
+		node := newFakeBitcoinRPC(0)
+		node.err = errors.New("connection refused")
+		reconcileBitcoinNode(node)
+
+		By("checking if the RPCReachable and Ready conditions report the failure")
+		found := &bitcoinv1alpha1.BitcoinNode{}
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+		for _, conditionType := range []string{bitcoinv1alpha1.ConditionRPCReachable, bitcoinv1alpha1.ConditionReady} {
+			condition := meta.FindStatusCondition(found.Status.Conditions, conditionType)
+			Expect(condition).To(Not(BeNil()))
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(bitcoinv1alpha1.ReasonRPCUnreachable))
+			Expect(condition.Message).To(ContainSubstring("connection refused"))
+		}
+	})
+
+	It("applying spec changes to the existing StatefulSet", func() {
+		createRPCSecrets()
+		bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BitcoinNodeName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+				ContainerImages: bitcoinv1alpha1.BTCDContainerImages{
+					BtcdImage: "quay.io/kiln-fired/btcd:v0.23.3",
+				},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				},
+				RPCServer: rpcServer,
+			},
+		}
+
+		By("creating the custom resource for the kind BitcoinNode")
+		Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+
+		By("reconciling the custom resource created")
+		node := newFakeBitcoinRPC(1)
+		reconcileBitcoinNode(node)
+		foundStatefulSet := &appsv1.StatefulSet{}
+		Expect(k8sClient.Get(ctx, statefulSetNamespaceName, foundStatefulSet)).To(Succeed())
+		Expect(foundStatefulSet.Spec.Template.Spec.Containers[0].Resources.Requests).To(HaveKey(corev1.ResourceCPU))
+		selector := foundStatefulSet.Spec.Selector.DeepCopy()
+		volumeClaimTemplates := foundStatefulSet.Spec.VolumeClaimTemplates
+
+		By("checking that reconciling an unchanged BitcoinNode does not update the StatefulSet")
+		resourceVersion := foundStatefulSet.ResourceVersion
+		reconcileBitcoinNode(node)
+		Expect(k8sClient.Get(ctx, statefulSetNamespaceName, foundStatefulSet)).To(Succeed())
+		Expect(foundStatefulSet.ResourceVersion).To(Equal(resourceVersion))
+
+		By("changing the image and resources of the BitcoinNode")
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, bitcoinNode)).To(Succeed())
+		bitcoinNode.Spec.ContainerImages.BtcdImage = "quay.io/kiln-fired/btcd:v0.23.4"
+		bitcoinNode.Spec.Resources = corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+		}
+		Expect(k8sClient.Update(ctx, bitcoinNode)).To(Succeed())
+		reconcileBitcoinNode(node)
+
+		By("checking if the StatefulSet was updated and kept its immutable fields")
+		Expect(k8sClient.Get(ctx, statefulSetNamespaceName, foundStatefulSet)).To(Succeed())
+		containers := foundStatefulSet.Spec.Template.Spec.Containers
+		Expect(containers).To(HaveLen(1))
+		Expect(containers[0].Image).To(Equal("quay.io/kiln-fired/btcd:v0.23.4"))
+		Expect(containers[0].Resources.Limits.Memory().String()).To(Equal("2Gi"))
+		Expect(containers[0].Resources.Requests).To(BeEmpty())
+		Expect(foundStatefulSet.Spec.Selector).To(Equal(selector))
+		Expect(foundStatefulSet.Spec.VolumeClaimTemplates).To(HaveLen(len(volumeClaimTemplates)))
+		Expect(foundStatefulSet.Spec.VolumeClaimTemplates[0].Name).To(Equal(volumeClaimTemplates[0].Name))
+		Expect(foundStatefulSet.Annotations).To(HaveKey(bitcoinv1alpha1.AppliedSpecHashAnnotation))
+	})
+
+	DescribeTable("using the ports of the network",
+		func(network string, ports bitcoinv1alpha1.BitcoinNodePorts, expectedArgs []string, expectedP2PPort int32, expectedRPCPort int32) {
+			createRPCSecrets()
+			bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      BitcoinNodeName,
+					Namespace: Namespace,
+				},
+				Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+					Network:   network,
+					Ports:     ports,
+					RPCServer: rpcServer,
+				},
+			}
+
+			By("creating the custom resource for the kind BitcoinNode")
+			Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+
+			By("reconciling the custom resource created")
+			node := newFakeBitcoinRPC(1)
+			reconcileBitcoinNode(node)
+
+			By("checking the flags and ports of the btcd container")
+			foundStatefulSet := &appsv1.StatefulSet{}
+			Expect(k8sClient.Get(ctx, statefulSetNamespaceName, foundStatefulSet)).To(Succeed())
+			btcd := foundStatefulSet.Spec.Template.Spec.Containers[0]
+			Expect(btcd.Args).To(Equal(expectedArgs))
+			Expect(btcd.Ports[0].ContainerPort).To(Equal(expectedP2PPort))
+			Expect(btcd.Ports[1].ContainerPort).To(Equal(expectedRPCPort))
+
+			By("checking the ports of the Service")
+			foundService := &corev1.Service{}
+			Expect(k8sClient.Get(ctx, statefulSetNamespaceName, foundService)).To(Succeed())
+			Expect(foundService.Spec.Ports[0].Port).To(Equal(expectedP2PPort))
+			Expect(foundService.Spec.Ports[1].Port).To(Equal(expectedRPCPort))
+
+			By("checking the RPC host the operator connects to")
+			Expect(node.config).To(Not(BeNil()))
+			Expect(node.config.Host).To(HaveSuffix(fmt.Sprintf(":%d", expectedRPCPort)))
+		},
+		Entry(
+			"when the network is regtest",
+			"regtest",
+			bitcoinv1alpha1.BitcoinNodePorts{},
+			[]string{"--regtest", "--listen=0.0.0.0:18444", "--rpclisten=0.0.0.0:18334"},
+			int32(18444),
+			int32(18334),
+		),
+		Entry(
+			"when the network is mainnet",
+			"mainnet",
+			bitcoinv1alpha1.BitcoinNodePorts{},
+			[]string{"--listen=0.0.0.0:8333", "--rpclisten=0.0.0.0:8334"},
+			int32(8333),
+			int32(8334),
+		),
+		Entry(
+			"when the ports of signet are overridden",
+			"signet",
+			bitcoinv1alpha1.BitcoinNodePorts{P2P: 39333, RPC: 39332},
+			[]string{"--signet", "--listen=0.0.0.0:39333", "--rpclisten=0.0.0.0:39332"},
+			int32(39333),
+			int32(39332),
+		),
+	)
+
+	It("running bitcoind when it is selected as the implementation", func() {
+		createRPCSecrets()
+		address, err := btcutil.NewAddressWitnessPubKeyHash(make([]byte, 20), &chaincfg.RegressionNetParams)
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: RewardAddressSecretName, Namespace: Namespace},
+			StringData: map[string]string{"address": address.EncodeAddress()},
+		})).To(Succeed())
+
+		bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BitcoinNodeName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+				Implementation: bitcoinv1alpha1.ImplementationBitcoind,
+				Network:        "regtest",
+				Mining: bitcoinv1alpha1.Mining{
+					MinBlocks: 101,
+					RewardAddress: bitcoinv1alpha1.RewardAddress{
+						SecretName: RewardAddressSecretName,
+						SecretKey:  "address",
+					},
+				},
+				RPCServer: rpcServer,
+			},
+		}
+
+		By("creating the custom resource for the kind BitcoinNode")
+		Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+
+		By("reconciling the custom resource against a bitcoind node")
+		node := newFakeBitcoinRPC(0)
+		node.chain = "regtest"
+		node.version = 240100
+		reconcileBitcoinNode(node)
+
+		By("checking the flags and ports of the bitcoind container")
+		foundStatefulSet := &appsv1.StatefulSet{}
+		Expect(k8sClient.Get(ctx, statefulSetNamespaceName, foundStatefulSet)).To(Succeed())
+		bitcoind := foundStatefulSet.Spec.Template.Spec.Containers[0]
+		Expect(bitcoind.Name).To(Equal("bitcoind"))
+		Expect(bitcoind.Args).To(ContainElements("-chain=regtest", "-port=18444", "-rpcport=18443", "-zmqpubrawblock=tcp://0.0.0.0:28332"))
+		Expect(bitcoind.Args).To(ContainElement(HavePrefix("-rpcauth=kiln:")))
+
+		By("checking the Service publishes the ZMQ ports")
+		foundService := &corev1.Service{}
+		Expect(k8sClient.Get(ctx, statefulSetNamespaceName, foundService)).To(Succeed())
+		var servicePorts []int32
+		for _, port := range foundService.Spec.Ports {
+			servicePorts = append(servicePorts, port.Port)
+		}
+		Expect(servicePorts).To(Equal([]int32{18444, 18443, 28332, 28333}))
+
+		By("checking the operator connects without TLS and mines to the reward address")
+		Expect(node.config.DisableTLS).To(BeTrue())
+		Expect(node.config.Host).To(HaveSuffix(":18443"))
+		Expect(node.height()).To(Equal(int64(101)))
+		Expect(node.minedTo).To(HaveLen(101))
+		Expect(node.minedTo[0]).To(Equal(address.EncodeAddress()))
+
+		By("checking the status reports the bitcoind version")
+		found := &bitcoinv1alpha1.BitcoinNode{}
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+		Expect(found.Status.Version).To(Equal("24.1.0"))
+		Expect(meta.IsStatusConditionTrue(found.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeTrue())
+	})
+
+	It("connecting to the listed peers and disconnecting the removed ones", func() {
+		createRPCSecrets()
+		bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BitcoinNodeName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+				RPCServer: rpcServer,
+				Peers:     []string{"10.0.1.1:18555", "10.0.1.2:18555"},
+			},
+		}
+
+		By("creating the custom resource for the kind BitcoinNode")
+		Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+
+		By("reconciling the custom resource against a node connected to one of the peers")
+		node := newFakeBitcoinRPC(0)
+		node.peers = []btcjson.GetPeerInfoResult{{ID: 1, Addr: "10.0.1.1:18555"}}
+		node.rejectUnknownPeers = true
+		reconcileBitcoinNode(node)
+
+		By("checking that only the missing peer was added")
+		Expect(node.connected).To(Equal([]string{"10.0.1.2:18555"}))
+		found := &bitcoinv1alpha1.BitcoinNode{}
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+		Expect(found.Status.PeerConnections).To(Equal([]bitcoinv1alpha1.PeerConnection{
+			{Address: "10.0.1.1:18555", State: bitcoinv1alpha1.PeerConnected},
+			{Address: "10.0.1.2:18555", State: bitcoinv1alpha1.PeerConnecting},
+		}))
+
+		By("checking that the added peer is reported as connected and not added again")
+		reconcileBitcoinNode(node)
+		Expect(node.connected).To(Equal([]string{"10.0.1.2:18555"}))
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+		Expect(found.Status.PeerConnections).To(Equal([]bitcoinv1alpha1.PeerConnection{
+			{Address: "10.0.1.1:18555", State: bitcoinv1alpha1.PeerConnected},
+			{Address: "10.0.1.2:18555", State: bitcoinv1alpha1.PeerConnected},
+		}))
+
+		By("removing a peer from the list")
+		found.Spec.Peers = []string{"10.0.1.2:18555"}
+		Expect(k8sClient.Update(ctx, found)).To(Succeed())
+		reconcileBitcoinNode(node)
+
+		By("checking that the removed peer was removed from the permanent peers of btcd")
+		Expect(node.disconnected).To(Equal([]string{"10.0.1.1:18555"}))
+		Expect(node.peers).To(ConsistOf(HaveField("Addr", "10.0.1.2:18555")))
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+		Expect(found.Status.PeerConnections).To(Equal([]bitcoinv1alpha1.PeerConnection{
+			{Address: "10.0.1.2:18555", State: bitcoinv1alpha1.PeerConnected},
+		}))
+	})
+
+	It("connecting to the BitcoinNodes the peer selector selects", func() {
+		createRPCSecrets()
+		labels := map[string]string{"role": "miner"}
+		selected := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{Name: BitcoinNodeName + "-selected", Namespace: Namespace, Labels: labels},
+			Spec:       bitcoinv1alpha1.BitcoinNodeSpec{Network: "simnet"},
+		}
+		otherNetwork := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{Name: BitcoinNodeName + "-regtest", Namespace: Namespace, Labels: labels},
+			Spec:       bitcoinv1alpha1.BitcoinNodeSpec{Network: "regtest"},
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: selected.Name + "-0", Namespace: Namespace, Labels: labelsForBitcoinNode(selected.Name)},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "btcd", Image: "btcd"}}},
+		}
+		bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BitcoinNodeName,
+				Namespace: Namespace,
+				Labels:    labels,
+			},
+			Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+				Network:      "simnet",
+				RPCServer:    rpcServer,
+				PeerSelector: &bitcoinv1alpha1.PeerSelector{LabelSelector: metav1.LabelSelector{MatchLabels: labels}},
+			},
+		}
+
+		By("creating the BitcoinNodes to select and the pod of the one on the same network")
+		for _, object := range []client.Object{selected, otherNetwork, pod} {
+			Expect(k8sClient.Create(ctx, object)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, object, client.GracePeriodSeconds(0))
+		}
+		pod.Status.PodIP = "10.0.2.7"
+		Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+
+		By("creating the custom resource for the kind BitcoinNode")
+		Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+
+		By("reconciling the custom resource against a node the selected node connected to")
+		node := newFakeBitcoinRPC(0)
+		node.peers = []btcjson.GetPeerInfoResult{{ID: 1, Addr: "10.0.2.7:40312", Inbound: true}}
+		reconcileBitcoinNode(node)
+
+		By("checking that the connection from the selected node is reported and not added again")
+		Expect(node.connected).To(BeEmpty())
+		found := &bitcoinv1alpha1.BitcoinNode{}
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+		Expect(found.Status.PeerConnections).To(Equal([]bitcoinv1alpha1.PeerConnection{{
+			Address:     selected.Name + "." + Namespace + ".svc.cluster.local:18555",
+			BitcoinNode: Namespace + "/" + selected.Name,
+			State:       bitcoinv1alpha1.PeerConnected,
+		}}))
+	})
+
+	It("refusing peers in other namespaces unless the operator allows them", func() {
+		createRPCSecrets()
+		bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BitcoinNodeName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+				RPCServer: rpcServer,
+				PeerSelector: &bitcoinv1alpha1.PeerSelector{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"bitcoin": "simnet"}},
+				},
+			},
+		}
+
+		By("creating the custom resource for the kind BitcoinNode")
+		Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+
+		By("reconciling the custom resource created")
+		reconcileBitcoinNode(newFakeBitcoinRPC(0))
+
+		By("checking if the Ready condition reports the invalid spec")
+		found := &bitcoinv1alpha1.BitcoinNode{}
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+		condition := meta.FindStatusCondition(found.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(condition).To(Not(BeNil()))
+		Expect(condition.Reason).To(Equal(bitcoinv1alpha1.ReasonInvalidSpec))
+		Expect(condition.Message).To(ContainSubstring("--allow-cross-namespace-peers"))
+	})
+
+	It("refusing to run bitcoind on simnet", func() {
+		createRPCSecrets()
+		bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BitcoinNodeName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+				Implementation: bitcoinv1alpha1.ImplementationBitcoind,
+				Network:        "simnet",
+				RPCServer:      rpcServer,
+			},
+		}
+
+		By("creating the custom resource for the kind BitcoinNode")
+		Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+
+		By("reconciling the custom resource created")
+		reconcileBitcoinNode(newFakeBitcoinRPC(0))
+
+		By("checking if the Ready condition reports the invalid spec")
+		found := &bitcoinv1alpha1.BitcoinNode{}
+		Expect(k8sClient.Get(ctx, bitcoinNodeNamespaceName, found)).To(Succeed())
+		condition := meta.FindStatusCondition(found.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(condition).To(Not(BeNil()))
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(bitcoinv1alpha1.ReasonInvalidSpec))
+		Expect(condition.Message).To(ContainSubstring("simnet"))
+
+		By("checking that no statefulset was created")
+		Expect(apierrors.IsNotFound(k8sClient.Get(ctx, statefulSetNamespaceName, &appsv1.StatefulSet{}))).To(BeTrue())
+	})
 })
+
+// failingStatusClient fails every status update
+type failingStatusClient struct {
+	client.Client
+}
+
+func (c *failingStatusClient) Status() client.StatusWriter {
+	return failingStatusWriter{c.Client.Status()}
+}
+
+type failingStatusWriter struct {
+	client.StatusWriter
+}
+
+func (w failingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return apierrors.NewServiceUnavailable("injected status update failure")
+}
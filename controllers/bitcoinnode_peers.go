@@ -0,0 +1,268 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcjson"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// desiredPeer is a peer a BitcoinNode should be connected to
+type desiredPeer struct {
+	address string
+	// node is the BitcoinNode selected by the peerSelector, nil for the peers of the spec
+	node *bitcoinv1alpha1.BitcoinNode
+}
+
+// desiredPeers returns the peers of the spec of a BitcoinNode followed by the BitcoinNodes on its network that its
+// peerSelector selects
+func (r *BitcoinNodeReconciler) desiredPeers(ctx context.Context, b *bitcoinv1alpha1.BitcoinNode, network bitcoinv1alpha1.BitcoinNetwork) ([]desiredPeer, error) {
+	var peers []desiredPeer
+	seen := map[string]bool{}
+	add := func(peer desiredPeer) {
+		if peer.address != "" && !seen[peer.address] {
+			seen[peer.address] = true
+			peers = append(peers, peer)
+		}
+	}
+
+	add(desiredPeer{address: b.Spec.Peer})
+	for _, address := range b.Spec.Peers {
+		add(desiredPeer{address: address})
+	}
+
+	selector := b.Spec.PeerSelector
+	if selector == nil {
+		return peers, nil
+	}
+
+	labelSelector, err := metav1.LabelSelectorAsSelector(&selector.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, err := r.peerNamespaces(ctx, b, selector.NamespaceSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, namespace := range namespaces {
+		nodes := &bitcoinv1alpha1.BitcoinNodeList{}
+		err = r.List(ctx, nodes, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: labelSelector})
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range nodes.Items {
+			node := &nodes.Items[i]
+			if (node.Name == b.Name && node.Namespace == b.Namespace) || !node.DeletionTimestamp.IsZero() {
+				continue
+			}
+			nodeNetwork, err := networkForBitcoinNode(node)
+			if err != nil || nodeNetwork.Name != network.Name {
+				continue
+			}
+			add(desiredPeer{address: peerAddress(node, nodeNetwork), node: node})
+		}
+	}
+	return peers, nil
+}
+
+// peerNamespaces returns the namespaces a peerSelector selects BitcoinNodes in
+func (r *BitcoinNodeReconciler) peerNamespaces(ctx context.Context, b *bitcoinv1alpha1.BitcoinNode, namespaceSelector *metav1.LabelSelector) ([]string, error) {
+	if namespaceSelector == nil {
+		return []string{b.Namespace}, nil
+	}
+	if !r.AllowCrossNamespacePeers {
+		return nil, errors.New("peerSelector.namespaceSelector requires the operator to run with --allow-cross-namespace-peers")
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(namespaceSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := &corev1.NamespaceList{}
+	err = r.List(ctx, namespaces, client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, namespace := range namespaces.Items {
+		names = append(names, namespace.Name)
+	}
+	return names, nil
+}
+
+// peerAddress returns the address other nodes connect to a BitcoinNode at, its peer-to-peer port on its Service
+func peerAddress(b *bitcoinv1alpha1.BitcoinNode, network bitcoinv1alpha1.BitcoinNetwork) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local:%d", b.Name, b.Namespace, portsForBitcoinNode(b, network).P2P)
+}
+
+// reconcilePeers connects a node to the desired peers it is not connected to and disconnects the peers the operator
+// connected earlier that are no longer desired. Other connections of the node are left alone. It returns the state of
+// the connection to each desired peer.
+func (r *BitcoinNodeReconciler) reconcilePeers(ctx context.Context, c BitcoinRPC, b *bitcoinv1alpha1.BitcoinNode, desired []desiredPeer) ([]bitcoinv1alpha1.PeerConnection, error) {
+	log := ctrllog.FromContext(ctx)
+	implementation := implementationOf(b)
+
+	peers, err := c.GetPeerInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	isolated, err := isolatedPeerNodes(ctx, r.Client, b)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := map[string]bool{}
+	var connections []bitcoinv1alpha1.PeerConnection
+
+	for _, peer := range desired {
+		wanted[peer.address] = true
+		connection := bitcoinv1alpha1.PeerConnection{Address: peer.address}
+		name := peerNodeName(peer.address)
+		if peer.node != nil {
+			connection.BitcoinNode = peer.node.Namespace + "/" + peer.node.Name
+			if peer.node.Namespace != b.Namespace {
+				name = ""
+			}
+		}
+
+		switch {
+		case isolated[name]:
+			connection.State = bitcoinv1alpha1.PeerIsolated
+		case connectedTo(peers, peer.address, r.peerIPs(ctx, connection)):
+			connection.State = bitcoinv1alpha1.PeerConnected
+		default:
+			err = connectPeer(c, implementation, peer.address)
+			if err != nil && !peerAlreadyAdded(err) {
+				log.Info("Failed to add peer", "peer", peer.address, "error", err.Error())
+				connection.State = bitcoinv1alpha1.PeerFailed
+				connection.Message = err.Error()
+			} else {
+				log.Info("Connecting to peer", "peer", peer.address)
+				connection.State = bitcoinv1alpha1.PeerConnecting
+			}
+		}
+		connections = append(connections, connection)
+	}
+
+	for _, previous := range b.Status.PeerConnections {
+		if wanted[previous.Address] {
+			continue
+		}
+
+		log.Info("Disconnecting from peer", "peer", previous.Address)
+		err = removePeer(c, implementation, previous.Address)
+		if err != nil && !peerNotFound(err) {
+			log.Info("Failed to remove peer", "peer", previous.Address, "error", err.Error())
+		}
+
+		ips := r.peerIPs(ctx, previous)
+		for _, peer := range peers {
+			if peer.Inbound || !peerMatches(peer, previous.Address, ips) {
+				continue
+			}
+			err = disconnectPeer(c, implementation, peer)
+			if err != nil && !peerNotFound(err) {
+				return nil, err
+			}
+		}
+	}
+	return connections, nil
+}
+
+// peerIPs returns the IP addresses a peer connects from, the pod IPs of a selected BitcoinNode or the addresses its
+// host resolves to
+func (r *BitcoinNodeReconciler) peerIPs(ctx context.Context, connection bitcoinv1alpha1.PeerConnection) map[string]bool {
+	if namespace, name, found := strings.Cut(connection.BitcoinNode, "/"); found {
+		ips, err := podIPs(ctx, r.Client, &bitcoinv1alpha1.BitcoinNode{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}})
+		if err == nil {
+			return ips
+		}
+	}
+
+	ips := map[string]bool{}
+	host, _, err := net.SplitHostPort(connection.Address)
+	if err != nil {
+		return ips
+	}
+	addresses, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return ips
+	}
+	for _, address := range addresses {
+		ips[address] = true
+	}
+	return ips
+}
+
+// connectedTo reports whether any connection of a node is to or from a peer
+func connectedTo(peers []btcjson.GetPeerInfoResult, address string, ips map[string]bool) bool {
+	for _, peer := range peers {
+		if peerMatches(peer, address, ips) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerMatches reports whether a connection is to a peer, by the address bitcoind reports for added peers or by the
+// IP address btcd reports and inbound connections come from
+func peerMatches(peer btcjson.GetPeerInfoResult, address string, ips map[string]bool) bool {
+	if peer.Addr == address {
+		return true
+	}
+	host, _, err := net.SplitHostPort(peer.Addr)
+	return err == nil && ips[host]
+}
+
+// peerNotFound reports whether a node refused to remove or disconnect a peer it does not know, for example one that was
+// removed before, which btcd reports as an invalid parameter and bitcoind as a peer that was not added or connected
+func peerNotFound(err error) bool {
+	var rpcErr *btcjson.RPCError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	switch rpcErr.Code {
+	case btcjson.ErrRPCClientNodeNotAdded, btcjson.ErrRPCClientNodeNotConnected:
+		return true
+	case btcjson.ErrRPCInvalidParameter:
+		return rpcErr.Message == "peer not found"
+	}
+	return false
+}
+
+// peerAlreadyAdded reports whether bitcoind refused to add a peer because it was added before and is still connecting
+func peerAlreadyAdded(err error) bool {
+	var rpcErr *btcjson.RPCError
+	return errors.As(err, &rpcErr) && rpcErr.Code == btcjson.ErrRPCClientNodeAlreadyAdded
+}
@@ -0,0 +1,279 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/wire"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// BitcoinRPC is the part of the btcd and bitcoind RPC APIs the reconcilers call, implemented by
+// *rpcclient.Client
+type BitcoinRPC interface {
+	GetBlockCount() (int64, error)
+	GetBlockChainInfo() (*btcjson.GetBlockChainInfoResult, error)
+	GetBlockHash(blockHeight int64) (*chainhash.Hash, error)
+	InvalidateBlock(blockHash *chainhash.Hash) error
+	GetInfo() (*btcjson.InfoWalletResult, error)
+	GetNetworkInfo() (*btcjson.GetNetworkInfoResult, error)
+	GetPeerInfo() ([]btcjson.GetPeerInfoResult, error)
+	Node(command btcjson.NodeSubCmd, host string, connectSubCmd *string) error
+	AddNode(host string, command rpcclient.AddNodeCommand) error
+	Generate(numBlocks uint32) ([]*chainhash.Hash, error)
+	GenerateToAddress(numBlocks int64, address btcutil.Address, maxTries *int64) ([]*chainhash.Hash, error)
+	GetGenerate() (bool, error)
+	SetGenerate(enable bool, numCPUs int) error
+	SendRawTransaction(tx *wire.MsgTx, allowHighFees bool) (*chainhash.Hash, error)
+	RawRequest(method string, params []json.RawMessage) (json.RawMessage, error)
+	Shutdown()
+}
+
+// RPCDialer returns a client for the RPC server described by the connection config
+type RPCDialer func(config *rpcclient.ConnConfig) (BitcoinRPC, error)
+
+// DialRPC is the RPCDialer that connects to the node with rpcclient
+func DialRPC(config *rpcclient.ConnConfig) (BitcoinRPC, error) {
+	return rpcclient.New(config, nil)
+}
+
+// dialerOr returns the RPCDialer a test injected into a reconciler, or DialRPC when there is none
+func dialerOr(d RPCDialer) RPCDialer {
+	if d == nil {
+		return DialRPC
+	}
+	return d
+}
+
+// rpcConnConfig returns the configuration of a connection to the RPC server of a BitcoinNode through its Service, with
+// the credentials and, for btcd, the CA certificate from its RPC secrets
+func rpcConnConfig(ctx context.Context, c client.Client, b *bitcoinv1alpha1.BitcoinNode) (*rpcclient.ConnConfig, error) {
+	network, err := networkForBitcoinNode(b)
+	if err != nil {
+		return nil, err
+	}
+
+	connCfg := &rpcclient.ConnConfig{
+		Host:         fmt.Sprintf("%s.%s.svc.cluster.local:%d", b.Name, b.Namespace, portsForBitcoinNode(b, network).RPC),
+		HTTPPostMode: true,
+	}
+
+	if implementationOf(b) == bitcoinv1alpha1.ImplementationBitcoind {
+		connCfg.DisableTLS = true
+	} else {
+		certSecret := &corev1.Secret{}
+		err = c.Get(ctx, types.NamespacedName{Name: rpcServerFor(b).CertSecret, Namespace: b.Namespace}, certSecret)
+		if err != nil {
+			return nil, err
+		}
+		connCfg.Certificates = certSecret.Data["ca.crt"]
+	}
+
+	connCfg.User, connCfg.Pass, err = rpcCredentials(ctx, c, b)
+	if err != nil {
+		return nil, err
+	}
+	return connCfg, nil
+}
+
+// rpcCredentials returns the RPC username and password from the credential secret of a BitcoinNode
+func rpcCredentials(ctx context.Context, c client.Client, b *bitcoinv1alpha1.BitcoinNode) (string, string, error) {
+	rpcServer := rpcServerFor(b)
+	credSecret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Name: rpcServer.ApiAuthSecretName, Namespace: b.Namespace}, credSecret)
+	if err != nil {
+		return "", "", err
+	}
+	return string(credSecret.Data[rpcServer.ApiUserSecretKey]), string(credSecret.Data[rpcServer.ApiPasswordSecretKey]), nil
+}
+
+// rewardAddress returns the address bitcoind mines to. btcd reads the address from its environment and gets nil.
+func rewardAddress(ctx context.Context, c client.Client, b *bitcoinv1alpha1.BitcoinNode, network bitcoinv1alpha1.BitcoinNetwork) (btcutil.Address, error) {
+	if implementationOf(b) != bitcoinv1alpha1.ImplementationBitcoind {
+		return nil, nil
+	}
+	return addressFromSecret(ctx, c, b.Namespace, b.Spec.Mining.RewardAddress, network)
+}
+
+// addressFromSecret reads an address of the network from a secret, from its np2wkhAddress key unless another key is
+// set
+func addressFromSecret(ctx context.Context, c client.Client, namespace string, ref bitcoinv1alpha1.RewardAddress, network bitcoinv1alpha1.BitcoinNetwork) (btcutil.Address, error) {
+	address, err := secretValue(ctx, c, namespace, ref.SecretName, secretKeyOrDefault(ref.SecretKey, "np2wkhAddress"))
+	if err != nil {
+		return nil, err
+	}
+	return decodeAddress(strings.TrimSpace(address), network)
+}
+
+// decodeAddress decodes an address and checks that it belongs to the network
+func decodeAddress(address string, network bitcoinv1alpha1.BitcoinNetwork) (btcutil.Address, error) {
+	decoded, err := btcutil.DecodeAddress(address, network.Params)
+	if err != nil {
+		return nil, err
+	}
+	if !decoded.IsForNet(network.Params) {
+		return nil, fmt.Errorf("address %s is not a %s address", address, network.Name)
+	}
+	return decoded, nil
+}
+
+// getMempoolInfo calls getmempoolinfo, which rpcclient has no method for
+func getMempoolInfo(c BitcoinRPC) (*btcjson.GetMempoolInfoResult, error) {
+	result, err := c.RawRequest("getmempoolinfo", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &btcjson.GetMempoolInfoResult{}
+	err = json.Unmarshal(result, info)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// observeChain fills the chain, peer and mempool information of the status from the node and returns the chain
+// information it was taken from
+func observeChain(c BitcoinRPC, implementation string, status *bitcoinv1alpha1.BitcoinNodeStatus) (*btcjson.GetBlockChainInfoResult, error) {
+	chainInfo, err := c.GetBlockChainInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := nodeVersion(c, implementation)
+	if err != nil {
+		return nil, err
+	}
+
+	peers, err := c.GetPeerInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	mempool, err := getMempoolInfo(c)
+	if err != nil {
+		return nil, err
+	}
+
+	status.LastBlockCount = int64(chainInfo.Blocks)
+	status.BestBlockHash = chainInfo.BestBlockHash
+	status.Difficulty = strconv.FormatFloat(chainInfo.Difficulty, 'g', -1, 64)
+	status.Network = chainInfo.Chain
+	status.Version = version
+	status.Peers = int32(len(peers))
+	status.MempoolSize = mempool.Size
+	return chainInfo, nil
+}
+
+// chainSyncing reports whether a node still downloads blocks, which btcd only signals through its header count
+func chainSyncing(chainInfo *btcjson.GetBlockChainInfoResult) bool {
+	return chainInfo.InitialBlockDownload || chainInfo.Headers > chainInfo.Blocks
+}
+
+// nodeVersion returns the version of the node software. bitcoind removed getinfo and reports its version through
+// getnetworkinfo.
+func nodeVersion(c BitcoinRPC, implementation string) (string, error) {
+	if implementation == bitcoinv1alpha1.ImplementationBitcoind {
+		info, err := c.GetNetworkInfo()
+		if err != nil {
+			return "", err
+		}
+		return bitcoindVersion(info.Version), nil
+	}
+
+	info, err := c.GetInfo()
+	if err != nil {
+		return "", err
+	}
+	return btcdVersion(info.Version), nil
+}
+
+// generateBlocks mines blocks on a node. btcd mines to the address it was started with, bitcoind has no generate
+// call and mines to the given address.
+func generateBlocks(c BitcoinRPC, implementation string, numBlocks int64, address btcutil.Address) ([]*chainhash.Hash, error) {
+	if implementation == bitcoinv1alpha1.ImplementationBitcoind {
+		return c.GenerateToAddress(numBlocks, address, nil)
+	}
+	return c.Generate(uint32(numBlocks))
+}
+
+// connectPeer adds a permanent peer to a node. bitcoind has no node call and adds peers with addnode.
+func connectPeer(c BitcoinRPC, implementation string, peer string) error {
+	if implementation == bitcoinv1alpha1.ImplementationBitcoind {
+		return c.AddNode(peer, rpcclient.ANAdd)
+	}
+	perm := "perm"
+	return c.Node(btcjson.NConnect, peer, &perm)
+}
+
+// removePeer removes a permanent peer from a node, so the node does not connect to it again
+func removePeer(c BitcoinRPC, implementation string, peer string) error {
+	if implementation == bitcoinv1alpha1.ImplementationBitcoind {
+		return c.AddNode(peer, rpcclient.ANRemove)
+	}
+	return c.Node(btcjson.NRemove, peer, nil)
+}
+
+// disconnectPeer closes the connection of a node to a peer. btcd refuses to disconnect permanent peers and removes
+// them instead, bitcoind disconnects by node id with disconnectnode, which rpcclient has no method for.
+func disconnectPeer(c BitcoinRPC, implementation string, peer btcjson.GetPeerInfoResult) error {
+	id := strconv.FormatInt(int64(peer.ID), 10)
+	if implementation == bitcoinv1alpha1.ImplementationBitcoind {
+		_, err := c.RawRequest("disconnectnode", []json.RawMessage{json.RawMessage(`""`), json.RawMessage(id)})
+		return err
+	}
+	if !peer.Inbound && c.Node(btcjson.NRemove, id, nil) == nil {
+		return nil
+	}
+	return c.Node(btcjson.NDisconnect, id, nil)
+}
+
+// peerNodeName returns the name of the BitcoinNode a peer address points to through its Service, the first label of
+// the host
+func peerNodeName(peer string) string {
+	host, _, err := net.SplitHostPort(peer)
+	if err != nil {
+		host = peer
+	}
+	return strings.SplitN(host, ".", 2)[0]
+}
+
+// btcdVersion formats the version number btcd reports, which encodes major, minor and patch as 1000000*major +
+// 10000*minor + 100*patch
+func btcdVersion(version int32) string {
+	return fmt.Sprintf("%d.%d.%d", version/1000000, version/10000%100, version/100%100)
+}
+
+// bitcoindVersion formats the version number bitcoind reports, which encodes major, minor and patch as
+// 10000*major + 100*minor + patch
+func bitcoindVersion(version int32) string {
+	return fmt.Sprintf("%d.%d.%d", version/10000, version/100%100, version%100)
+}
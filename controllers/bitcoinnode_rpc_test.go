@@ -0,0 +1,328 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"strconv"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// fakeBitcoinRPC is an in-memory node implementing the RPC calls made by the BitcoinNode reconciler. Its chain is a
+// list of block hashes derived from the height.
+type fakeBitcoinRPC struct {
+	mu sync.Mutex
+
+	// err is returned by every call when set, like a node whose RPC server cannot be reached
+	err error
+
+	config     *rpcclient.ConnConfig
+	chain      string
+	version    int32
+	blocks     []chainhash.Hash
+	headers    int32
+	generating bool
+	peers      []btcjson.GetPeerInfoResult
+	mempool    int64
+	connected  []string
+	// forks counts the invalidated blocks, so blocks mined after a reorganization get new hashes
+	forks int
+	// invalidated lists the hashes passed to invalidateblock
+	invalidated []string
+	// disconnected lists the ids and addresses of the peers that were disconnected or removed
+	disconnected []string
+	// minedTo lists the address of each block mined with generatetoaddress
+	minedTo []string
+	// sent lists the transactions submitted with sendrawtransaction
+	sent []*wire.MsgTx
+	// invalidateErr is returned by invalidateblock when set
+	invalidateErr error
+	// rejectUnknownPeers makes removing or disconnecting a peer the node is not connected to fail like btcd does
+	rejectUnknownPeers bool
+}
+
+func newFakeBitcoinRPC(height int) *fakeBitcoinRPC {
+	f := &fakeBitcoinRPC{
+		chain:   "simnet",
+		version: 230400,
+	}
+	f.mine(height)
+	return f
+}
+
+// dial is the RPCDialer of the fake node
+func (f *fakeBitcoinRPC) dial(config *rpcclient.ConnConfig) (BitcoinRPC, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.config = config
+	return f, nil
+}
+
+// mine appends blocks to the chain of the fake node
+func (f *fakeBitcoinRPC) mine(n int) []*chainhash.Hash {
+	var hashes []*chainhash.Hash
+	for i := 0; i < n; i++ {
+		height := make([]byte, 8)
+		binary.BigEndian.PutUint64(height, uint64(len(f.blocks)+1))
+		if f.forks > 0 {
+			height = append(height, byte(f.forks))
+		}
+		hash := chainhash.DoubleHashH(height)
+		f.blocks = append(f.blocks, hash)
+		hashes = append(hashes, &hash)
+	}
+	return hashes
+}
+
+// height returns the number of blocks in the chain of the fake node
+func (f *fakeBitcoinRPC) height() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return int64(len(f.blocks))
+}
+
+func (f *fakeBitcoinRPC) bestBlockHash() string {
+	if len(f.blocks) == 0 {
+		return chainhash.Hash{}.String()
+	}
+	return f.blocks[len(f.blocks)-1].String()
+}
+
+func (f *fakeBitcoinRPC) GetBlockCount() (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return int64(len(f.blocks)), f.err
+}
+
+func (f *fakeBitcoinRPC) GetBlockChainInfo() (*btcjson.GetBlockChainInfoResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	headers := f.headers
+	if headers < int32(len(f.blocks)) {
+		headers = int32(len(f.blocks))
+	}
+	return &btcjson.GetBlockChainInfoResult{
+		Chain:         f.chain,
+		Blocks:        int32(len(f.blocks)),
+		Headers:       headers,
+		BestBlockHash: f.bestBlockHash(),
+		Difficulty:    1,
+	}, nil
+}
+
+func (f *fakeBitcoinRPC) GetBlockHash(blockHeight int64) (*chainhash.Hash, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	if blockHeight < 1 || blockHeight > int64(len(f.blocks)) {
+		return nil, &btcjson.RPCError{Code: btcjson.ErrRPCOutOfRange, Message: "Block number out of range"}
+	}
+	hash := f.blocks[blockHeight-1]
+	return &hash, nil
+}
+
+func (f *fakeBitcoinRPC) InvalidateBlock(blockHash *chainhash.Hash) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	if f.invalidateErr != nil {
+		return f.invalidateErr
+	}
+	for i, hash := range f.blocks {
+		if hash == *blockHash {
+			f.blocks = f.blocks[:i]
+			f.forks++
+			f.invalidated = append(f.invalidated, blockHash.String())
+			return nil
+		}
+	}
+	return &btcjson.RPCError{Code: btcjson.ErrRPCBlockNotFound, Message: "Block not found"}
+}
+
+// connect records an added peer and connects to it at once
+func (f *fakeBitcoinRPC) connect(host string) {
+	f.connected = append(f.connected, host)
+	f.peers = append(f.peers, btcjson.GetPeerInfoResult{ID: int32(100 + len(f.connected)), Addr: host})
+}
+
+// removePeers drops the peers whose id or address was disconnected
+func (f *fakeBitcoinRPC) removePeers(target string) error {
+	var peers []btcjson.GetPeerInfoResult
+	for _, peer := range f.peers {
+		if strconv.FormatInt(int64(peer.ID), 10) != target && peer.Addr != target {
+			peers = append(peers, peer)
+		}
+	}
+	if f.rejectUnknownPeers && len(peers) == len(f.peers) {
+		return &btcjson.RPCError{Code: btcjson.ErrRPCInvalidParameter, Message: "peer not found"}
+	}
+	f.peers = peers
+	f.disconnected = append(f.disconnected, target)
+	return nil
+}
+
+func (f *fakeBitcoinRPC) GetInfo() (*btcjson.InfoWalletResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &btcjson.InfoWalletResult{
+		Version:     f.version,
+		Blocks:      int32(len(f.blocks)),
+		Connections: int32(len(f.peers)),
+		Difficulty:  1,
+	}, nil
+}
+
+func (f *fakeBitcoinRPC) GetNetworkInfo() (*btcjson.GetNetworkInfoResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &btcjson.GetNetworkInfoResult{
+		Version:     f.version,
+		Connections: int32(len(f.peers)),
+	}, nil
+}
+
+func (f *fakeBitcoinRPC) GetPeerInfo() ([]btcjson.GetPeerInfoResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.peers, f.err
+}
+
+func (f *fakeBitcoinRPC) Node(command btcjson.NodeSubCmd, host string, connectSubCmd *string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	switch command {
+	case btcjson.NConnect:
+		f.connect(host)
+	case btcjson.NRemove, btcjson.NDisconnect:
+		return f.removePeers(host)
+	}
+	return nil
+}
+
+func (f *fakeBitcoinRPC) AddNode(host string, command rpcclient.AddNodeCommand) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	switch command {
+	case rpcclient.ANAdd:
+		f.connect(host)
+	case rpcclient.ANRemove:
+		return f.removePeers(host)
+	}
+	return nil
+}
+
+func (f *fakeBitcoinRPC) Generate(numBlocks uint32) ([]*chainhash.Hash, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.mine(int(numBlocks)), nil
+}
+
+func (f *fakeBitcoinRPC) GenerateToAddress(numBlocks int64, address btcutil.Address, maxTries *int64) ([]*chainhash.Hash, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	for i := int64(0); i < numBlocks; i++ {
+		f.minedTo = append(f.minedTo, address.EncodeAddress())
+	}
+	return f.mine(int(numBlocks)), nil
+}
+
+func (f *fakeBitcoinRPC) GetGenerate() (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.generating, f.err
+}
+
+func (f *fakeBitcoinRPC) SetGenerate(enable bool, numCPUs int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.generating = enable
+	return nil
+}
+
+func (f *fakeBitcoinRPC) SendRawTransaction(tx *wire.MsgTx, allowHighFees bool) (*chainhash.Hash, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.sent = append(f.sent, tx)
+	hash := tx.TxHash()
+	return &hash, nil
+}
+
+func (f *fakeBitcoinRPC) RawRequest(method string, params []json.RawMessage) (json.RawMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	switch method {
+	case "getmempoolinfo":
+		return json.Marshal(&btcjson.GetMempoolInfoResult{Size: f.mempool})
+	case "disconnectnode":
+		var id int32
+		err := json.Unmarshal(params[1], &id)
+		if err != nil {
+			return nil, err
+		}
+		err = f.removePeers(strconv.FormatInt(int64(id), 10))
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(nil)
+	}
+	return nil, &btcjson.RPCError{Code: btcjson.ErrRPCMethodNotFound.Code, Message: "Method not found"}
+}
+
+func (f *fakeBitcoinRPC) Shutdown() {}
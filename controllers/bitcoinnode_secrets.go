@@ -0,0 +1,242 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+const (
+	// rpcUserLength is the length of generated RPC usernames
+	rpcUserLength = 12
+
+	// rpcCertificateValidity is how long generated RPC certificates are valid
+	rpcCertificateValidity = 10 * 365 * 24 * time.Hour
+)
+
+// rpcServerFor returns the RPC secrets of a BitcoinNode, named after the node when the spec leaves them empty
+func rpcServerFor(b *bitcoinv1alpha1.BitcoinNode) bitcoinv1alpha1.RPCServer {
+	rpcServer := b.Spec.RPCServer
+	if rpcServer.CertSecret == "" {
+		rpcServer.CertSecret = b.Name + "-rpc-tls"
+	}
+	if rpcServer.ApiAuthSecretName == "" {
+		rpcServer.ApiAuthSecretName = b.Name + "-rpc-creds"
+	}
+	if rpcServer.ApiUserSecretKey == "" {
+		rpcServer.ApiUserSecretKey = "username"
+	}
+	if rpcServer.ApiPasswordSecretKey == "" {
+		rpcServer.ApiPasswordSecretKey = "password"
+	}
+	return rpcServer
+}
+
+// reconcileRPCSecrets creates the RPC credential secret and, for btcd, the RPC certificate secret of a BitcoinNode when
+// they do not exist. It returns the names of the secrets the operator generated, which are the ones the node controls.
+func (r *BitcoinNodeReconciler) reconcileRPCSecrets(ctx context.Context, b *bitcoinv1alpha1.BitcoinNode) ([]string, error) {
+	rpcServer := rpcServerFor(b)
+	var generated []string
+
+	controlled, err := r.ensureRPCSecret(ctx, b, rpcServer.ApiAuthSecretName, func() (*corev1.Secret, error) {
+		return r.rpcCredentialSecret(b, rpcServer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if controlled {
+		generated = append(generated, rpcServer.ApiAuthSecretName)
+	}
+
+	// bitcoind serves RPC without TLS
+	if implementationOf(b) == bitcoinv1alpha1.ImplementationBitcoind {
+		return generated, nil
+	}
+
+	controlled, err = r.ensureRPCSecret(ctx, b, rpcServer.CertSecret, func() (*corev1.Secret, error) {
+		return r.rpcCertificateSecret(b, rpcServer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if controlled {
+		generated = append(generated, rpcServer.CertSecret)
+	}
+	return generated, nil
+}
+
+// ensureRPCSecret creates the named secret with generate when it does not exist and reports whether the secret is
+// controlled by the BitcoinNode. Secrets created by users are left alone.
+func (r *BitcoinNodeReconciler) ensureRPCSecret(ctx context.Context, b *bitcoinv1alpha1.BitcoinNode, name string, generate func() (*corev1.Secret, error)) (bool, error) {
+	found := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: b.Namespace}, found)
+
+	if err != nil && errors.IsNotFound(err) {
+		found, err = generate()
+		if err == nil {
+			err = ctrl.SetControllerReference(b, found, r.Scheme)
+		}
+		if err == nil {
+			err = r.Create(ctx, found)
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	return metav1.IsControlledBy(found, b), nil
+}
+
+// rpcCredentialSecret returns a secret with a random RPC username and password
+func (r *BitcoinNodeReconciler) rpcCredentialSecret(b *bitcoinv1alpha1.BitcoinNode, rpcServer bitcoinv1alpha1.RPCServer) (*corev1.Secret, error) {
+	user, err := r.generator().String(rpcUserLength, AlphanumericAlphabet)
+	if err != nil {
+		return nil, err
+	}
+	pass, err := r.generator().String(DefaultSecretLength, AlphanumericAlphabet)
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rpcServer.ApiAuthSecretName,
+			Namespace: b.Namespace,
+			Labels:    labelsForBitcoinNode(b.Name),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			rpcServer.ApiUserSecretKey:     []byte(user),
+			rpcServer.ApiPasswordSecretKey: []byte(pass),
+		},
+	}, nil
+}
+
+// rpcCertificateSecret returns a TLS secret with a self-signed CA and a server certificate it issued for the DNS
+// names of the headless Service of the node and for localhost, which the probes connect to
+func (r *BitcoinNodeReconciler) rpcCertificateSecret(b *bitcoinv1alpha1.BitcoinNode, rpcServer bitcoinv1alpha1.RPCServer) (*corev1.Secret, error) {
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := notBefore.Add(rpcCertificateValidity)
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), r.generator())
+	if err != nil {
+		return nil, err
+	}
+	caSerial, err := r.certificateSerial()
+	if err != nil {
+		return nil, err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          caSerial,
+		Subject:               pkix.Name{Organization: []string{"kiln-operator"}, CommonName: b.Name + " RPC CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(r.generator(), caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, err
+	}
+
+	serverKey, err := ecdsa.GenerateKey(elliptic.P256(), r.generator())
+	if err != nil {
+		return nil, err
+	}
+	serverSerial, err := r.certificateSerial()
+	if err != nil {
+		return nil, err
+	}
+	serverTemplate := &x509.Certificate{
+		SerialNumber: serverSerial,
+		Subject:      pkix.Name{Organization: []string{"kiln-operator"}, CommonName: b.Name},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     rpcDNSNames(b),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	serverDER, err := x509.CreateCertificate(r.generator(), serverTemplate, ca, &serverKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	serverKeyDER, err := x509.MarshalECPrivateKey(serverKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rpcServer.CertSecret,
+			Namespace: b.Namespace,
+			Labels:    labelsForBitcoinNode(b.Name),
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"ca.crt":                pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+			corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: serverDER}),
+			corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: serverKeyDER}),
+		},
+	}, nil
+}
+
+// rpcDNSNames returns the names the RPC server of a BitcoinNode is reached at through its headless Service, including
+// the names of its pods
+func rpcDNSNames(b *bitcoinv1alpha1.BitcoinNode) []string {
+	return []string{
+		"localhost",
+		b.Name,
+		fmt.Sprintf("%s.%s", b.Name, b.Namespace),
+		fmt.Sprintf("%s.%s.svc", b.Name, b.Namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", b.Name, b.Namespace),
+		fmt.Sprintf("*.%s.%s.svc", b.Name, b.Namespace),
+		fmt.Sprintf("*.%s.%s.svc.cluster.local", b.Name, b.Namespace),
+	}
+}
+
+// certificateSerial returns a random 128 bit certificate serial number
+func (r *BitcoinNodeReconciler) certificateSerial() (*big.Int, error) {
+	serial := make([]byte, 16)
+	_, err := r.generator().Read(serial)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(serial), nil
+}
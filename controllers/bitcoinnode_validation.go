@@ -0,0 +1,61 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// validateBitcoinNodeSpec checks a BitcoinNode spec for problems that can be detected without reading other objects
+func validateBitcoinNodeSpec(spec bitcoinv1alpha1.BitcoinNodeSpec, network bitcoinv1alpha1.BitcoinNetwork) error {
+	if schedule := spec.Mining.Schedule; schedule != nil {
+		if schedule.Type == bitcoinv1alpha1.BlockScheduleScripted && len(schedule.Steps) == 0 {
+			return errors.New("scripted block schedules require at least one step")
+		}
+		if schedule.Type != bitcoinv1alpha1.BlockScheduleScripted && len(schedule.Steps) != 0 {
+			return errors.New("steps only apply to scripted block schedules")
+		}
+	}
+
+	for _, peer := range append([]string{spec.Peer}, spec.Peers...) {
+		if _, _, err := net.SplitHostPort(peer); peer != "" && err != nil {
+			return fmt.Errorf("peer %s is not a host and port", peer)
+		}
+	}
+
+	if spec.Implementation != bitcoinv1alpha1.ImplementationBitcoind {
+		return nil
+	}
+
+	if network.BitcoindRPCPort == 0 {
+		return fmt.Errorf("bitcoind does not support %s", network.Name)
+	}
+
+	if spec.Mining.CpuMiningEnabled {
+		return errors.New("bitcoind has no CPU miner, use periodicBlocksEnabled instead of cpuMiningEnabled")
+	}
+
+	if (spec.Mining.MinBlocks > 0 || spec.Mining.PeriodicBlocksEnabled) && spec.Mining.RewardAddress.SecretName == "" {
+		return errors.New("bitcoind mines to an address, minBlocks and periodicBlocksEnabled require a rewardAddress")
+	}
+
+	return nil
+}
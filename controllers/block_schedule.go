@@ -0,0 +1,195 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// blockInterval returns the time between the periodic blocks of a BitcoinNode
+func blockInterval(b *bitcoinv1alpha1.BitcoinNode) time.Duration {
+	if b.Spec.Mining.SecondsPerBlock < 1 {
+		return time.Second
+	}
+	return time.Duration(b.Spec.Mining.SecondsPerBlock) * time.Second
+}
+
+// scheduleType returns the type of the block schedule of a BitcoinNode, fixed unless another one was selected
+func scheduleType(b *bitcoinv1alpha1.BitcoinNode) string {
+	if b.Spec.Mining.Schedule == nil || b.Spec.Mining.Schedule.Type == "" {
+		return bitcoinv1alpha1.BlockScheduleFixed
+	}
+	return b.Spec.Mining.Schedule.Type
+}
+
+// scheduleFinished reports whether a scripted schedule that does not repeat executed its last step
+func scheduleFinished(b *bitcoinv1alpha1.BitcoinNode) bool {
+	if scheduleType(b) != bitcoinv1alpha1.BlockScheduleScripted || b.Spec.Mining.Schedule.Repeat {
+		return false
+	}
+	return int(b.Status.ScheduleStep) >= len(b.Spec.Mining.Schedule.Steps)
+}
+
+// scheduleDescription describes the block schedule of a BitcoinNode for its Mining condition
+func scheduleDescription(b *bitcoinv1alpha1.BitcoinNode) string {
+	switch scheduleType(b) {
+	case bitcoinv1alpha1.BlockScheduleExponential:
+		mean := b.Spec.Mining.Schedule.MeanSeconds
+		if mean == 0 {
+			mean = int64(blockInterval(b).Seconds())
+		}
+		return fmt.Sprintf("Blocks are mined at exponentially distributed intervals with a mean of %d seconds", mean)
+	case bitcoinv1alpha1.BlockScheduleScripted:
+		return fmt.Sprintf("Blocks are mined on a script of %d steps", len(b.Spec.Mining.Schedule.Steps))
+	}
+	return fmt.Sprintf("A block is mined every %d seconds", b.Spec.Mining.SecondsPerBlock)
+}
+
+// mineScheduledBlock mines the blocks that are due on the schedule of a BitcoinNode with periodic blocks and returns
+// how long to wait for the next ones. The first blocks are mined right away. A fixed schedule takes its interval from
+// the spec on every call, so a changed interval applies to the next block, while exponential and scripted schedules
+// record the interval they drew for the next block in the status. The time of the blocks and the advanced schedule are
+// written to the status before the blocks are mined, so a failed status update cannot mine them twice; blocks that
+// then fail to be mined are skipped.
+func (r *BitcoinNodeReconciler) mineScheduledBlock(ctx context.Context, c BitcoinRPC, b *bitcoinv1alpha1.BitcoinNode, implementation string, address btcutil.Address) (time.Duration, error) {
+	if !b.Spec.Mining.PeriodicBlocksEnabled {
+		return 0, nil
+	}
+
+	err := resetChangedSchedule(b)
+	if err != nil {
+		return 0, err
+	}
+
+	if scheduleFinished(b) {
+		return 0, nil
+	}
+
+	now := r.now()
+
+	if last := b.Status.LastMinedTime; last != nil {
+		interval := blockInterval(b)
+		if scheduleType(b) != bitcoinv1alpha1.BlockScheduleFixed && b.Status.NextBlockInterval != nil {
+			interval = b.Status.NextBlockInterval.Duration
+		}
+
+		next := last.Add(interval)
+		if now.Before(next) {
+			return next.Sub(now), nil
+		}
+	}
+
+	blocks, interval := r.nextScheduleStep(b)
+
+	b.Status.LastMinedTime = &metav1.Time{Time: now}
+	b.Status.NextBlockInterval = nil
+	if scheduleType(b) != bitcoinv1alpha1.BlockScheduleFixed {
+		b.Status.NextBlockInterval = &metav1.Duration{Duration: interval}
+	}
+
+	err = r.Status().Update(ctx, b)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = generateBlocks(c, implementation, blocks, address)
+	if err != nil {
+		return 0, err
+	}
+
+	height, err := c.GetBlockCount()
+	if err != nil {
+		return 0, err
+	}
+	b.Status.LastMinedHeight = height
+
+	if scheduleFinished(b) {
+		return 0, nil
+	}
+	return interval, nil
+}
+
+// resetChangedSchedule starts the block schedule of a BitcoinNode over with its first blocks right away when it changed
+// since its step and next block interval were recorded
+func resetChangedSchedule(b *bitcoinv1alpha1.BitcoinNode) error {
+	hash, err := specHash(b.Spec.Mining.Schedule)
+	if err != nil {
+		return err
+	}
+	if b.Status.ScheduleHash != "" && b.Status.ScheduleHash != hash {
+		b.Status.LastMinedTime = nil
+		b.Status.NextBlockInterval = nil
+		b.Status.ScheduleStep = 0
+	}
+	b.Status.ScheduleHash = hash
+	return nil
+}
+
+// nextScheduleStep returns the number of blocks to mine now and the interval until the next blocks, and advances a
+// scripted schedule to its next step
+func (r *BitcoinNodeReconciler) nextScheduleStep(b *bitcoinv1alpha1.BitcoinNode) (int64, time.Duration) {
+	schedule := b.Spec.Mining.Schedule
+
+	switch scheduleType(b) {
+	case bitcoinv1alpha1.BlockScheduleExponential:
+		mean := blockInterval(b)
+		if schedule.MeanSeconds > 0 {
+			mean = time.Duration(schedule.MeanSeconds) * time.Second
+		}
+		return 1, exponentialInterval(r.random(), mean)
+
+	case bitcoinv1alpha1.BlockScheduleScripted:
+		// the steps may have been shortened since the index was recorded
+		index := int(b.Status.ScheduleStep) % len(schedule.Steps)
+		step := schedule.Steps[index]
+		b.Status.ScheduleStep = int32(index + 1)
+		if schedule.Repeat && int(b.Status.ScheduleStep) == len(schedule.Steps) {
+			b.Status.ScheduleStep = 0
+		}
+
+		blocks := step.Blocks
+		if blocks < 1 {
+			blocks = 1
+		}
+		seconds := step.Seconds
+		if seconds < 1 {
+			seconds = 1
+		}
+		return blocks, time.Duration(seconds) * time.Second
+	}
+
+	return 1, blockInterval(b)
+}
+
+// exponentialInterval draws an interval from the exponential distribution with the given mean. The interval is
+// rounded to whole seconds and is at least a second, because the time of the last block is recorded in seconds.
+func exponentialInterval(random *rand.Rand, mean time.Duration) time.Duration {
+	seconds := math.Round(random.ExpFloat64() * mean.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// accountScripts maps each supported BIP43 purpose to the output descriptor script expression of its accounts
+var accountScripts = map[uint32]string{
+	44: "pkh(%s)",
+	49: "sh(wpkh(%s))",
+	84: "wpkh(%s)",
+	86: "tr(%s)",
+}
+
+// slip132Versions maps BIP49 and BIP84 to the SLIP-132 extended public key versions used by wallets that do not
+// support descriptors, for mainnet (ypub, zpub) and for every test network (upub, vpub)
+var slip132Versions = map[uint32]struct{ mainNet, testNet [4]byte }{
+	49: {mainNet: [4]byte{0x04, 0x9d, 0x7c, 0xb2}, testNet: [4]byte{0x04, 0x4a, 0x52, 0x62}},
+	84: {mainNet: [4]byte{0x04, 0xb2, 0x47, 0x46}, testNet: [4]byte{0x04, 0x5f, 0x1c, 0xf6}},
+}
+
+// slip132Names is the mainnet prefix of the SLIP-132 keys, used to name them in the ConfigMap
+var slip132Names = map[uint32]string{
+	49: "ypub",
+	84: "zpub",
+}
+
+// exportAccounts derives the requested account extended public keys and returns them, along with receive and
+// change output descriptors, keyed by ConfigMap key. Every account is published under the "bip<purpose>-<account>-"
+// prefix as "xpub", "receive-descriptor" and "change-descriptor", plus "ypub" or "zpub" for BIP49 and BIP84.
+func exportAccounts(master *hdkeychain.ExtendedKey, accounts []bitcoinv1alpha1.AccountExport, network bitcoinv1alpha1.BitcoinNetwork) (map[string]string, error) {
+	params := network.Params
+	exports := map[string]string{}
+
+	fingerprint, err := masterFingerprint(master)
+	if err != nil {
+		return nil, err
+	}
+	exports["fingerprint"] = fingerprint
+
+	for _, a := range accounts {
+		script, ok := accountScripts[a.Purpose]
+		if !ok {
+			return nil, fmt.Errorf("unsupported account purpose %d", a.Purpose)
+		}
+
+		accountKey, err := deriveAccountKey(master, a.Purpose, params.HDCoinType, a.Account)
+		if err != nil {
+			return nil, err
+		}
+
+		publicKey, err := accountKey.Neuter()
+		if err != nil {
+			return nil, err
+		}
+
+		prefix := fmt.Sprintf("bip%d-%d-", a.Purpose, a.Account)
+		xpub := publicKey.String()
+		exports[prefix+"xpub"] = xpub
+
+		if versions, ok := slip132Versions[a.Purpose]; ok {
+			version := versions.testNet
+			if network.IsMainNet() {
+				version = versions.mainNet
+			}
+			slip132Key, err := publicKey.CloneWithVersion(version[:])
+			if err != nil {
+				return nil, err
+			}
+			exports[prefix+slip132Names[a.Purpose]] = slip132Key.String()
+		}
+
+		origin := fmt.Sprintf("[%s/%dh/%dh/%dh]%s", fingerprint, a.Purpose, params.HDCoinType, a.Account, xpub)
+		for branch, name := range []string{"receive", "change"} {
+			descriptor, err := withDescriptorChecksum(fmt.Sprintf(script, fmt.Sprintf("%s/%d/*", origin, branch)))
+			if err != nil {
+				return nil, err
+			}
+			exports[prefix+name+"-descriptor"] = descriptor
+		}
+	}
+
+	return exports, nil
+}
+
+const (
+	descriptorInputCharset    = "0123456789()[],'/*abcdefgh@:$%{}IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+// descriptorPolyMod is the BCH code generator used by output descriptor checksums
+func descriptorPolyMod(c uint64, val uint64) uint64 {
+	c0 := c >> 35
+	c = ((c & 0x7ffffffff) << 5) ^ val
+	for i, generator := range []uint64{0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd} {
+		if c0&(1<<i) != 0 {
+			c ^= generator
+		}
+	}
+	return c
+}
+
+// withDescriptorChecksum appends the BIP380 checksum to an output descriptor
+func withDescriptorChecksum(descriptor string) (string, error) {
+	c := uint64(1)
+	class := uint64(0)
+	classCount := 0
+
+	for _, ch := range descriptor {
+		pos := strings.IndexRune(descriptorInputCharset, ch)
+		if pos < 0 {
+			return "", fmt.Errorf("descriptor contains invalid character %q", ch)
+		}
+		c = descriptorPolyMod(c, uint64(pos)&31)
+		class = class*3 + uint64(pos)>>5
+		classCount++
+		if classCount == 3 {
+			c = descriptorPolyMod(c, class)
+			class = 0
+			classCount = 0
+		}
+	}
+	if classCount > 0 {
+		c = descriptorPolyMod(c, class)
+	}
+	for i := 0; i < 8; i++ {
+		c = descriptorPolyMod(c, 0)
+	}
+	c ^= 1
+
+	checksum := make([]byte, 8)
+	for i := range checksum {
+		checksum[i] = descriptorChecksumCharset[(c>>(5*(7-i)))&31]
+	}
+	return descriptor + "#" + string(checksum), nil
+}
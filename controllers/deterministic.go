@@ -0,0 +1,75 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+
+	"github.com/lightningnetwork/lnd/aezeed"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// deterministicBirthdayDays bounds the derived birthday to the first years after the Bitcoin genesis block, so it
+// never lies in the future and a restored wallet rescans the whole chain of a test network
+const deterministicBirthdayDays = 4096
+
+// deterministicReader expands a key into an endless stream of bytes using SHA-256 in counter mode
+type deterministicReader struct {
+	key     [sha256.Size]byte
+	counter uint64
+	block   []byte
+}
+
+func (d *deterministicReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(d.block) == 0 {
+			input := make([]byte, sha256.Size+8)
+			copy(input, d.key[:])
+			binary.BigEndian.PutUint64(input[sha256.Size:], d.counter)
+			block := sha256.Sum256(input)
+			d.block = block[:]
+			d.counter++
+		}
+		copied := copy(p[n:], d.block)
+		d.block = d.block[copied:]
+		n += copied
+	}
+	return n, nil
+}
+
+// deterministicKey derives the key of a deterministic Seed from its salt, namespace and name. The purpose separates
+// the streams used for the entropy and for the birthday.
+func deterministicKey(s *bitcoinv1alpha1.Seed, purpose string) [sha256.Size]byte {
+	return sha256.Sum256([]byte("kiln-operator deterministic seed\x00" + purpose + "\x00" + s.Spec.Deterministic.Salt + "\x00" + s.Namespace + "/" + s.Name))
+}
+
+// newDeterministicGenerator returns a SecretGenerator that always produces the same entropy, salt and passphrase
+// for a deterministic Seed
+func newDeterministicGenerator(s *bitcoinv1alpha1.Seed) *RandomSecretGenerator {
+	return &RandomSecretGenerator{Source: &deterministicReader{key: deterministicKey(s, "entropy")}}
+}
+
+// deterministicBirthday returns the wallet birthday of a deterministic Seed
+func deterministicBirthday(s *bitcoinv1alpha1.Seed) time.Time {
+	key := deterministicKey(s, "birthday")
+	days := binary.BigEndian.Uint16(key[:2]) % deterministicBirthdayDays
+	return aezeed.BitcoinGenesisDate.Add(time.Duration(days) * 24 * time.Hour)
+}
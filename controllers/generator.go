@@ -0,0 +1,86 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+)
+
+const (
+	// AlphanumericAlphabet is the default alphabet of generated passphrases and passwords
+	AlphanumericAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890"
+
+	// DefaultSecretLength is the default length of generated passphrases and passwords
+	DefaultSecretLength = 32
+)
+
+// SecretGenerator generates the key material and credentials created by the operator, such as mnemonic entropy,
+// Seed passphrases, RPC passwords and wallet passwords
+type SecretGenerator interface {
+	// Read fills p with random bytes
+	Read(p []byte) (int, error)
+
+	// String returns a random string of the given length drawn uniformly from the alphabet
+	String(length int, alphabet string) (string, error)
+}
+
+// RandomSecretGenerator is a SecretGenerator that draws from a source of random bytes
+type RandomSecretGenerator struct {
+	// Source of random bytes, crypto/rand when nil. Tests can use a seeded source to get deterministic secrets.
+	Source io.Reader
+}
+
+// NewSecretGenerator returns a SecretGenerator backed by crypto/rand
+func NewSecretGenerator() *RandomSecretGenerator {
+	return &RandomSecretGenerator{Source: rand.Reader}
+}
+
+func (g *RandomSecretGenerator) Read(p []byte) (int, error) {
+	return io.ReadFull(g.source(), p)
+}
+
+func (g *RandomSecretGenerator) String(length int, alphabet string) (string, error) {
+	symbols := []rune(alphabet)
+	if len(symbols) < 2 {
+		return "", errors.New("alphabet must contain at least 2 symbols")
+	}
+	if length <= 0 {
+		return "", errors.New("length must be positive")
+	}
+
+	// rand.Int rejects out of range samples, so every symbol is equally likely
+	size := big.NewInt(int64(len(symbols)))
+	b := make([]rune, length)
+	for i := range b {
+		n, err := rand.Int(g.source(), size)
+		if err != nil {
+			return "", err
+		}
+		b[i] = symbols[n.Int64()]
+	}
+	return string(b), nil
+}
+
+func (g *RandomSecretGenerator) source() io.Reader {
+	if g.Source == nil {
+		return rand.Reader
+	}
+	return g.Source
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"math/rand"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Secret generator", func() {
+
+	DescribeTable("generating a random string",
+		func(length int, alphabet string) {
+			value, err := NewSecretGenerator().String(length, alphabet)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect([]rune(value)).To(HaveLen(length))
+			for _, symbol := range value {
+				Expect(strings.ContainsRune(alphabet, symbol)).To(BeTrue())
+			}
+		},
+		Entry(
+			"when the default length and alphabet are used",
+			DefaultSecretLength,
+			AlphanumericAlphabet,
+		),
+		Entry(
+			"when the alphabet is restricted",
+			64,
+			"0123456789abcdef",
+		),
+		Entry(
+			"when the alphabet contains multibyte symbols",
+			16,
+			"αβγδ",
+		),
+	)
+
+	It("rejecting an alphabet with fewer than 2 symbols", func() {
+		_, err := NewSecretGenerator().String(DefaultSecretLength, "a")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("generating the same secrets from the same seeded source", func() {
+		first := &RandomSecretGenerator{Source: rand.New(rand.NewSource(1))}
+		second := &RandomSecretGenerator{Source: rand.New(rand.NewSource(1))}
+
+		firstValue, err := first.String(DefaultSecretLength, AlphanumericAlphabet)
+		Expect(err).To(Not(HaveOccurred()))
+		secondValue, err := second.String(DefaultSecretLength, AlphanumericAlphabet)
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(firstValue).To(Equal(secondValue))
+		Expect(firstValue).To(Equal("s09hHcLpwFpAIDYvMhnqd9n88ehrIgjb"))
+	})
+})
@@ -0,0 +1,120 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// seedSecretFingerprint returns the fingerprint of the root key in a Seed secret, or an empty string when the
+// secret was not written by a Seed
+func seedSecretFingerprint(secret *corev1.Secret) string {
+	rootKey, ok := secret.Data["rootkey"]
+	if !ok {
+		return ""
+	}
+
+	fingerprint, err := rootKeyFingerprint(string(rootKey))
+	if err != nil {
+		return ""
+	}
+	return fingerprint
+}
+
+// reconcileKeyMaterial compares the Seed key material a StatefulSet was provisioned with to the active key material
+// in the Seed secret and sets the KeyMaterialStale condition. StatefulSets provisioned before the fingerprint was
+// recorded are assumed to use the active key material. It returns the fingerprint the StatefulSet was provisioned
+// with, or an empty string when the secret does not hold Seed key material, and whether the condition changed.
+func reconcileKeyMaterial(ctx context.Context, c client.Client, ss *appsv1.StatefulSet, secretName string, conditions *[]metav1.Condition) (string, bool, error) {
+	var previous *metav1.Condition
+	if condition := meta.FindStatusCondition(*conditions, bitcoinv1alpha1.ConditionKeyMaterialStale); condition != nil {
+		previous = condition.DeepCopy()
+	}
+
+	provisioned, err := compareKeyMaterial(ctx, c, ss, secretName, conditions)
+	if err != nil {
+		return "", false, err
+	}
+
+	changed := !reflect.DeepEqual(previous, meta.FindStatusCondition(*conditions, bitcoinv1alpha1.ConditionKeyMaterialStale))
+	return provisioned, changed, nil
+}
+
+// compareKeyMaterial sets the KeyMaterialStale condition and returns the fingerprint the StatefulSet was provisioned with
+func compareKeyMaterial(ctx context.Context, c client.Client, ss *appsv1.StatefulSet, secretName string, conditions *[]metav1.Condition) (string, error) {
+	if secretName == "" {
+		meta.RemoveStatusCondition(conditions, bitcoinv1alpha1.ConditionKeyMaterialStale)
+		return "", nil
+	}
+
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Name: secretName, Namespace: ss.Namespace}, secret)
+	if errors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	active := seedSecretFingerprint(secret)
+	if active == "" {
+		meta.RemoveStatusCondition(conditions, bitcoinv1alpha1.ConditionKeyMaterialStale)
+		return "", nil
+	}
+
+	provisioned := ss.Annotations[bitcoinv1alpha1.SeedFingerprintAnnotation]
+	if provisioned == "" {
+		if ss.Annotations == nil {
+			ss.Annotations = map[string]string{}
+		}
+		ss.Annotations[bitcoinv1alpha1.SeedFingerprintAnnotation] = active
+		err = c.Update(ctx, ss)
+		if err != nil {
+			return "", err
+		}
+		provisioned = active
+	}
+
+	if provisioned != active {
+		meta.SetStatusCondition(conditions, metav1.Condition{
+			Type:    bitcoinv1alpha1.ConditionKeyMaterialStale,
+			Status:  metav1.ConditionTrue,
+			Reason:  bitcoinv1alpha1.ReasonSeedRotated,
+			Message: "provisioned with seed " + provisioned + " but secret " + secretName + " now holds seed " + active + ", recreate the node to use the rotated key material",
+		})
+	} else {
+		meta.SetStatusCondition(conditions, metav1.Condition{
+			Type:    bitcoinv1alpha1.ConditionKeyMaterialStale,
+			Status:  metav1.ConditionFalse,
+			Reason:  bitcoinv1alpha1.ReasonKeyMaterialCurrent,
+			Message: "provisioned with the active seed " + active,
+		})
+	}
+
+	return provisioned, nil
+}
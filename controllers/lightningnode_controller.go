@@ -18,9 +18,11 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -30,7 +32,10 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
 )
@@ -44,6 +49,8 @@ type LightningNodeReconciler struct {
 //+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=lightningnodes,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=lightningnodes/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=lightningnodes/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=bitcoinnodes,verbs=get;list;watch
 
 func (r *LightningNodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := ctrllog.FromContext(ctx)
@@ -59,14 +66,75 @@ func (r *LightningNodeReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, err
 	}
 
+	// lnd can only initialize a wallet from an aezeed mnemonic
+	if seedSecretName := lightningNode.Spec.Wallet.Seed.SecretName; seedSecretName != "" {
+		seedSecret := &corev1.Secret{}
+		err = r.Get(ctx, types.NamespacedName{Name: seedSecretName, Namespace: lightningNode.Namespace}, seedSecret)
+
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to get seed Secret")
+			return ctrl.Result{}, err
+		}
+
+		if err == nil && seedSecret.Annotations[bitcoinv1alpha1.SeedFormatAnnotation] == bitcoinv1alpha1.SeedFormatBIP39 {
+			log.Info("Refusing to initialize an lnd wallet from a BIP39 seed", "Secret.Name", seedSecretName)
+			meta.SetStatusCondition(&lightningNode.Status.Conditions, metav1.Condition{
+				Type:    bitcoinv1alpha1.ConditionReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  bitcoinv1alpha1.ReasonIncompatibleSeed,
+				Message: "seed secret " + seedSecretName + " contains a BIP39 mnemonic, lnd wallets require an aezeed mnemonic",
+			})
+			return ctrl.Result{}, r.Status().Update(ctx, lightningNode)
+		}
+	}
+
+	backend, err := r.backendForLightningNode(ctx, lightningNode)
+
+	if err != nil && errors.IsNotFound(err) {
+		log.Info("BitcoinNode resource not found", "BitcoinNode.Name", lightningNode.Spec.BitcoinConnection.BitcoinNode)
+		meta.SetStatusCondition(&lightningNode.Status.Conditions, metav1.Condition{
+			Type:    bitcoinv1alpha1.ConditionReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  bitcoinv1alpha1.ReasonBitcoinNodeUnavailable,
+			Message: "BitcoinNode " + lightningNode.Spec.BitcoinConnection.BitcoinNode + " does not exist",
+		})
+		return ctrl.Result{}, r.Status().Update(ctx, lightningNode)
+	} else if err != nil {
+		log.Error(err, "Failed to get BitcoinNode")
+		return ctrl.Result{}, err
+	}
+
+	// lnd must run on the network of the BitcoinNode it connects to
+	networkName := lightningNode.Spec.BitcoinConnection.Network
+	if backend.Network != "" {
+		networkName = backend.Network
+	}
+
+	network, err := bitcoinv1alpha1.LookupNetwork(networkName)
+
+	if err != nil {
+		log.Error(err, "Failed to look up network")
+		meta.SetStatusCondition(&lightningNode.Status.Conditions, metav1.Condition{
+			Type:    bitcoinv1alpha1.ConditionReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  bitcoinv1alpha1.ReasonUnsupportedNetwork,
+			Message: err.Error(),
+		})
+		return ctrl.Result{}, r.Status().Update(ctx, lightningNode)
+	}
+
 	// Reconcile StatefulSet
 	foundStatefulSet := &appsv1.StatefulSet{}
 	err = r.Get(ctx, types.NamespacedName{Name: lightningNode.Name, Namespace: lightningNode.Namespace}, foundStatefulSet)
 
+	ss := r.statefulsetForLightningNode(lightningNode, network, backend)
+
 	if err != nil && errors.IsNotFound(err) {
-		ss := r.statefulsetForLightningNode(lightningNode)
 		log.Info("Creating a new StatefulSet", "StatefulSet.Namespace", ss.Namespace, "StatefulSet.Name", ss.Name)
-		err = r.Create(ctx, ss)
+		err = annotateSpecHash(ss, ss.Spec)
+		if err == nil {
+			err = r.Create(ctx, ss)
+		}
 		if err != nil {
 			log.Error(err, "Failed to create new StatefulSet", "StatefulSet.Namespace", ss.Namespace, "StatefulSet.Name", ss.Name)
 			return ctrl.Result{}, err
@@ -77,6 +145,16 @@ func (r *LightningNodeReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, err
 	}
 
+	changed, err := mergeStatefulSet(foundStatefulSet, ss)
+	if err == nil && changed {
+		log.Info("Updating StatefulSet", "StatefulSet.Namespace", foundStatefulSet.Namespace, "StatefulSet.Name", foundStatefulSet.Name)
+		err = r.Update(ctx, foundStatefulSet)
+	}
+	if err != nil {
+		log.Error(err, "Failed to update StatefulSet", "StatefulSet.Namespace", foundStatefulSet.Namespace, "StatefulSet.Name", foundStatefulSet.Name)
+		return ctrl.Result{}, err
+	}
+
 	// Reconcile Service
 	foundService := &corev1.Service{}
 	err = r.Get(ctx, types.NamespacedName{Name: lightningNode.Name, Namespace: lightningNode.Namespace}, foundService)
@@ -95,13 +173,170 @@ func (r *LightningNodeReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, err
 	}
 
+	seedFingerprint, _, err := reconcileKeyMaterial(ctx, r.Client, foundStatefulSet, lightningNode.Spec.Wallet.Seed.SecretName, &lightningNode.Status.Conditions)
+
+	if err != nil {
+		log.Error(err, "Failed to compare the wallet key material with its Seed")
+		return ctrl.Result{}, err
+	}
+
+	lightningNode.Status.SeedFingerprint = seedFingerprint
+
+	meta.SetStatusCondition(&lightningNode.Status.Conditions, metav1.Condition{
+		Type:    bitcoinv1alpha1.ConditionReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  bitcoinv1alpha1.ReasonReconciled,
+		Message: "StatefulSet and Service are available",
+	})
+
+	err = r.Status().Update(ctx, lightningNode)
+	if err != nil {
+		log.Error(err, "Failed to update LightningNode status")
+		return ctrl.Result{}, err
+	}
+
 	return ctrl.Result{}, nil
 }
 
-func (r *LightningNodeReconciler) statefulsetForLightningNode(l *bitcoinv1alpha1.LightningNode) *appsv1.StatefulSet {
+// lightningBackend is the chain backend lnd connects to
+type lightningBackend struct {
+	// Implementation is the lnd backend, btcd or bitcoind
+	Implementation string
+	// Host is the RPC endpoint of the node
+	Host string
+	// ZMQBlock and ZMQTx are the endpoints bitcoind publishes raw blocks and transactions on
+	ZMQBlock string
+	ZMQTx    string
+	// RPCServer names the RPC certificate and credential secrets of the node
+	RPCServer bitcoinv1alpha1.RPCServer
+	// Network is the network of the referenced BitcoinNode, empty when lnd connects to a host
+	Network string
+}
+
+// backendForLightningNode returns the chain backend of a LightningNode, taken from the BitcoinNode it references or
+// a btcd node at the configured host
+func (r *LightningNodeReconciler) backendForLightningNode(ctx context.Context, l *bitcoinv1alpha1.LightningNode) (lightningBackend, error) {
+	connection := l.Spec.BitcoinConnection
+	backend := lightningBackend{
+		Implementation: bitcoinv1alpha1.ImplementationBtcd,
+		Host:           connection.Host,
+		RPCServer: bitcoinv1alpha1.RPCServer{
+			CertSecret:           connection.CertSecret,
+			ApiAuthSecretName:    connection.ApiAuthSecretName,
+			ApiUserSecretKey:     connection.ApiUserSecretKey,
+			ApiPasswordSecretKey: connection.ApiPasswordSecretKey,
+		},
+	}
+
+	nodeName := l.Spec.BitcoinConnection.BitcoinNode
+	if nodeName == "" {
+		return backend, nil
+	}
+
+	bitcoinNode := &bitcoinv1alpha1.BitcoinNode{}
+	err := r.Get(ctx, types.NamespacedName{Name: nodeName, Namespace: l.Namespace}, bitcoinNode)
+	if err != nil {
+		return backend, err
+	}
+
+	network, err := networkForBitcoinNode(bitcoinNode)
+	if err != nil {
+		return backend, err
+	}
+
+	ports := portsForBitcoinNode(bitcoinNode, network)
+	serviceHost := fmt.Sprintf("%s.%s.svc.cluster.local", bitcoinNode.Name, bitcoinNode.Namespace)
+
+	backend.Implementation = implementationOf(bitcoinNode)
+	backend.Network = network.Name
+	if backend.Host == "" {
+		backend.Host = fmt.Sprintf("%s:%d", serviceHost, ports.RPC)
+	}
+
+	// the secrets of the BitcoinNode, which may have been generated, are used unless others are set
+	rpcServer := rpcServerFor(bitcoinNode)
+	if connection.CertSecret == "" {
+		backend.RPCServer.CertSecret = rpcServer.CertSecret
+	}
+	if connection.ApiAuthSecretName == "" {
+		backend.RPCServer.ApiAuthSecretName = rpcServer.ApiAuthSecretName
+		backend.RPCServer.ApiUserSecretKey = rpcServer.ApiUserSecretKey
+		backend.RPCServer.ApiPasswordSecretKey = rpcServer.ApiPasswordSecretKey
+	}
+	if backend.Implementation == bitcoinv1alpha1.ImplementationBitcoind {
+		backend.ZMQBlock = fmt.Sprintf("tcp://%s:%d", serviceHost, ports.ZMQBlock)
+		backend.ZMQTx = fmt.Sprintf("tcp://%s:%d", serviceHost, ports.ZMQTx)
+	}
+	return backend, nil
+}
+
+func (r *LightningNodeReconciler) statefulsetForLightningNode(l *bitcoinv1alpha1.LightningNode, network bitcoinv1alpha1.BitcoinNetwork, backend lightningBackend) *appsv1.StatefulSet {
 	ls := labelsForLightningNode(l.Name)
 	size := int32(1)
 
+	args := []string{
+		"--wallet-unlock-password-file=/secret/wallet-password",
+		"--$(CHAIN).active",
+		"--$(CHAIN).$(NETWORK)",
+		"--$(CHAIN).node=$(BACKEND)",
+		"--$(BACKEND).rpchost=$(RPCHOST)",
+		"--$(BACKEND).rpcuser=$(RPCUSER)",
+		"--$(BACKEND).rpcpass=$(RPCPASS)",
+		"--rpclisten=0.0.0.0:10009",
+	}
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      "lnd-home",
+			MountPath: ".lnd",
+		},
+		{
+			Name:      "wallet-password",
+			MountPath: "/secret/wallet-password",
+			SubPath:   l.Spec.Wallet.Password.SecretKey,
+		},
+	}
+	volumes := []corev1.Volume{
+		{
+			Name: "seed",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: l.Spec.Wallet.Seed.SecretName,
+				},
+			},
+		},
+		{
+			Name: "wallet-password",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: l.Spec.Wallet.Password.SecretName,
+				},
+			},
+		},
+	}
+
+	// bitcoind serves RPC without TLS and notifies lnd of blocks and transactions over ZMQ
+	if backend.Implementation == bitcoinv1alpha1.ImplementationBitcoind {
+		args = append(args,
+			"--bitcoind.zmqpubrawblock="+backend.ZMQBlock,
+			"--bitcoind.zmqpubrawtx="+backend.ZMQTx,
+		)
+	} else {
+		args = append(args, "--$(BACKEND).rpccert=/rpc/rpc.cert")
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "rpc-cert",
+			MountPath: "/rpc/rpc.cert",
+			SubPath:   "tls.crt",
+		})
+		volumes = append(volumes, corev1.Volume{
+			Name: "rpc-cert",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: backend.RPCServer.CertSecret,
+				},
+			},
+		})
+	}
+
 	ss := &appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      l.Name,
@@ -129,7 +364,7 @@ func (r *LightningNodeReconciler) statefulsetForLightningNode(l *bitcoinv1alpha1
 							"--file.seed=/secret/seed/$(SEEDMNEMONICKEY)",
 							"--file.seed-passphrase=/secret/seed/$(SEEDPASSPHRASEKEY)",
 							"--file.wallet-password=/secret/wallet-password",
-							"--init-file.output-wallet-dir=$HOME/.lnd/data/chain/bitcoin/simnet",
+							"--init-file.output-wallet-dir=$HOME/.lnd/data/chain/bitcoin/" + network.Name,
 							"--init-file.validate-password",
 						},
 						Env: []corev1.EnvVar{
@@ -162,17 +397,7 @@ func (r *LightningNodeReconciler) statefulsetForLightningNode(l *bitcoinv1alpha1
 						Image:   l.Spec.ContainerImages.LndImage,
 						Name:    "lnd",
 						Command: []string{"lnd"},
-						Args: []string{
-							"--wallet-unlock-password-file=/secret/wallet-password",
-							"--$(CHAIN).active",
-							"--$(CHAIN).$(NETWORK)",
-							"--$(CHAIN).node=$(BACKEND)",
-							"--$(BACKEND).rpccert=/rpc/rpc.cert",
-							"--$(BACKEND).rpchost=$(RPCHOST)",
-							"--$(BACKEND).rpcuser=$(RPCUSER)",
-							"--$(BACKEND).rpcpass=$(RPCPASS)",
-							"--rpclisten=0.0.0.0:10009",
-						},
+						Args:    args,
 						Ports: []corev1.ContainerPort{
 							{
 								ContainerPort: 9735,
@@ -186,20 +411,20 @@ func (r *LightningNodeReconciler) statefulsetForLightningNode(l *bitcoinv1alpha1
 						Env: []corev1.EnvVar{
 							{
 								Name:  "NETWORK",
-								Value: l.Spec.BitcoinConnection.Network,
+								Value: network.Name,
 							},
 							{
 								Name:  "RPCHOST",
-								Value: l.Spec.BitcoinConnection.Host,
+								Value: backend.Host,
 							},
 							{
 								Name: "RPCUSER",
 								ValueFrom: &corev1.EnvVarSource{
 									SecretKeyRef: &corev1.SecretKeySelector{
 										LocalObjectReference: corev1.LocalObjectReference{
-											Name: l.Spec.BitcoinConnection.ApiAuthSecretName,
+											Name: backend.RPCServer.ApiAuthSecretName,
 										},
-										Key: l.Spec.BitcoinConnection.ApiUserSecretKey,
+										Key: backend.RPCServer.ApiUserSecretKey,
 									},
 								},
 							},
@@ -208,9 +433,9 @@ func (r *LightningNodeReconciler) statefulsetForLightningNode(l *bitcoinv1alpha1
 								ValueFrom: &corev1.EnvVarSource{
 									SecretKeyRef: &corev1.SecretKeySelector{
 										LocalObjectReference: corev1.LocalObjectReference{
-											Name: l.Spec.BitcoinConnection.ApiAuthSecretName,
+											Name: backend.RPCServer.ApiAuthSecretName,
 										},
-										Key: l.Spec.BitcoinConnection.ApiPasswordSecretKey,
+										Key: backend.RPCServer.ApiPasswordSecretKey,
 									},
 								},
 							},
@@ -220,7 +445,7 @@ func (r *LightningNodeReconciler) statefulsetForLightningNode(l *bitcoinv1alpha1
 							},
 							{
 								Name:  "BACKEND",
-								Value: "btcd",
+								Value: backend.Implementation,
 							},
 						},
 						SecurityContext: &corev1.SecurityContext{
@@ -230,49 +455,9 @@ func (r *LightningNodeReconciler) statefulsetForLightningNode(l *bitcoinv1alpha1
 							AllowPrivilegeEscalation: pointer.Bool(false),
 							SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
 						},
-						VolumeMounts: []corev1.VolumeMount{
-							{
-								Name:      "lnd-home",
-								MountPath: ".lnd",
-							},
-							{
-								Name:      "rpc-cert",
-								MountPath: "/rpc/rpc.cert",
-								SubPath:   "tls.crt",
-							},
-							{
-								Name:      "wallet-password",
-								MountPath: "/secret/wallet-password",
-								SubPath:   l.Spec.Wallet.Password.SecretKey,
-							},
-						},
+						VolumeMounts: volumeMounts,
 					}},
-					Volumes: []corev1.Volume{
-						{
-							Name: "rpc-cert",
-							VolumeSource: corev1.VolumeSource{
-								Secret: &corev1.SecretVolumeSource{
-									SecretName: l.Spec.BitcoinConnection.CertSecret,
-								},
-							},
-						},
-						{
-							Name: "seed",
-							VolumeSource: corev1.VolumeSource{
-								Secret: &corev1.SecretVolumeSource{
-									SecretName: l.Spec.Wallet.Seed.SecretName,
-								},
-							},
-						},
-						{
-							Name: "wallet-password",
-							VolumeSource: corev1.VolumeSource{
-								Secret: &corev1.SecretVolumeSource{
-									SecretName: l.Spec.Wallet.Password.SecretName,
-								},
-							},
-						},
-					},
+					Volumes: volumes,
 				},
 			},
 			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{
@@ -347,5 +532,43 @@ func (r *LightningNodeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&bitcoinv1alpha1.LightningNode{}).
 		Owns(&appsv1.StatefulSet{}).
 		Owns(&corev1.Service{}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.lightningNodesForSecret)).
+		Watches(&source.Kind{Type: &bitcoinv1alpha1.BitcoinNode{}}, handler.EnqueueRequestsFromMapFunc(r.lightningNodesForBitcoinNode)).
 		Complete(r)
 }
+
+// lightningNodesForSecret maps a Seed secret to the LightningNodes that initialize their wallet from it, so a
+// rotation is reported on the nodes
+func (r *LightningNodeReconciler) lightningNodesForSecret(secret client.Object) []reconcile.Request {
+	lightningNodes := &bitcoinv1alpha1.LightningNodeList{}
+	err := r.List(context.Background(), lightningNodes, client.InNamespace(secret.GetNamespace()))
+	if err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, l := range lightningNodes.Items {
+		if l.Spec.Wallet.Seed.SecretName == secret.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: l.Name, Namespace: l.Namespace}})
+		}
+	}
+	return requests
+}
+
+// lightningNodesForBitcoinNode maps a BitcoinNode to the LightningNodes that connect to it, so nodes waiting for it
+// are created once it exists
+func (r *LightningNodeReconciler) lightningNodesForBitcoinNode(bitcoinNode client.Object) []reconcile.Request {
+	lightningNodes := &bitcoinv1alpha1.LightningNodeList{}
+	err := r.List(context.Background(), lightningNodes, client.InNamespace(bitcoinNode.GetNamespace()))
+	if err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, l := range lightningNodes.Items {
+		if l.Spec.BitcoinConnection.BitcoinNode == bitcoinNode.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: l.Name, Namespace: l.Namespace}})
+		}
+	}
+	return requests
+}
@@ -1,11 +1,16 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -18,10 +23,13 @@ var _ = Describe("LightningNode controller", func() {
 
 	const Namespace = "test-namespace"
 	const LightningNodeName = "test"
+	const SeedSecretName = "mining-wallet"
+	const BitcoinNodeName = "bitcoin"
 
 	ctx := context.Background()
 	lightningNodeNamespaceName := types.NamespacedName{Namespace: Namespace, Name: LightningNodeName}
 	statefulSetNamespaceName := types.NamespacedName{Namespace: Namespace, Name: LightningNodeName}
+	seedSecretNamespaceName := types.NamespacedName{Namespace: Namespace, Name: SeedSecretName}
 
 	BeforeEach(func() {
 		By("creating namespace to perform the tests")
@@ -44,9 +52,174 @@ var _ = Describe("LightningNode controller", func() {
 		By("cleaning up StatefulSet")
 		statefulSet := &appsv1.StatefulSet{}
 		err = k8sClient.Get(ctx, statefulSetNamespaceName, statefulSet)
+		if err == nil {
+			err = k8sClient.Delete(ctx, statefulSet)
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		By("cleaning up Service")
+		service := &corev1.Service{}
+		err = k8sClient.Get(ctx, statefulSetNamespaceName, service)
+		if err == nil {
+			err = k8sClient.Delete(ctx, service)
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		By("cleaning up seed Secret")
+		seedSecret := &corev1.Secret{}
+		err = k8sClient.Get(ctx, seedSecretNamespaceName, seedSecret)
+		if err == nil {
+			err = k8sClient.Delete(ctx, seedSecret)
+			Expect(err).To(Not(HaveOccurred()))
+		}
+	})
+
+	It("refusing to initialize a wallet from a BIP39 seed", func() {
+		By("creating a seed secret that contains a BIP39 mnemonic")
+		err := k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedSecretName,
+				Namespace: Namespace,
+				Annotations: map[string]string{
+					bitcoinv1alpha1.SeedFormatAnnotation: bitcoinv1alpha1.SeedFormatBIP39,
+				},
+			},
+			StringData: map[string]string{
+				"mnemonic": "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			},
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		lightningNode := &bitcoinv1alpha1.LightningNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      LightningNodeName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.LightningNodeSpec{
+				BitcoinConnection: bitcoinv1alpha1.BitcoinConnection{
+					Host:    "btcd",
+					Network: "simnet",
+				},
+				Wallet: bitcoinv1alpha1.Wallet{
+					Seed: bitcoinv1alpha1.SeedImport{
+						SecretName: SeedSecretName,
+					},
+				},
+			},
+		}
+
+		By("creating the custom resource for the kind LightningNode")
+		err = k8sClient.Create(ctx, lightningNode)
 		Expect(err).To(Not(HaveOccurred()))
-		err = k8sClient.Delete(ctx, statefulSet)
+
+		By("reconciling the custom resource created")
+		lightningNodeReconciler := LightningNodeReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err = lightningNodeReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: lightningNodeNamespaceName,
+		})
 		Expect(err).To(Not(HaveOccurred()))
+
+		By("checking if the Ready condition reports the incompatible seed")
+		foundLightningNode := &bitcoinv1alpha1.LightningNode{}
+		Expect(k8sClient.Get(ctx, lightningNodeNamespaceName, foundLightningNode)).To(Succeed())
+		condition := meta.FindStatusCondition(foundLightningNode.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(condition).To(Not(BeNil()))
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(bitcoinv1alpha1.ReasonIncompatibleSeed))
+
+		By("checking that no statefulset was created")
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, statefulSetNamespaceName, &appsv1.StatefulSet{}))).To(BeTrue())
+	})
+
+	It("reporting stale key material after the Seed was rotated", func() {
+		rootKey := func(b byte) string {
+			key, err := hdkeychain.NewMaster(bytes.Repeat([]byte{b}, 32), &chaincfg.SimNetParams)
+			Expect(err).To(Not(HaveOccurred()))
+			return key.String()
+		}
+		activeRootKey := rootKey(1)
+		rotatedRootKey := rootKey(2)
+		activeFingerprint, err := rootKeyFingerprint(activeRootKey)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("creating a seed secret")
+		seedSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedSecretName,
+				Namespace: Namespace,
+			},
+			StringData: map[string]string{
+				"rootkey": activeRootKey,
+			},
+		}
+		Expect(k8sClient.Create(ctx, seedSecret)).To(Succeed())
+
+		lightningNode := &bitcoinv1alpha1.LightningNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      LightningNodeName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.LightningNodeSpec{
+				BitcoinConnection: bitcoinv1alpha1.BitcoinConnection{
+					Host:    "btcd",
+					Network: "simnet",
+				},
+				Wallet: bitcoinv1alpha1.Wallet{
+					Seed: bitcoinv1alpha1.SeedImport{
+						SecretName: SeedSecretName,
+					},
+				},
+			},
+		}
+
+		By("creating the custom resource for the kind LightningNode")
+		Expect(k8sClient.Create(ctx, lightningNode)).To(Succeed())
+
+		By("reconciling the custom resource until the StatefulSet and Service exist")
+		lightningNodeReconciler := LightningNodeReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		for i := 0; i < 3; i++ {
+			_, err = lightningNodeReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: lightningNodeNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		By("checking if the key material is reported as current")
+		foundLightningNode := &bitcoinv1alpha1.LightningNode{}
+		Expect(k8sClient.Get(ctx, lightningNodeNamespaceName, foundLightningNode)).To(Succeed())
+		Expect(foundLightningNode.Status.SeedFingerprint).To(Equal(activeFingerprint))
+		Expect(meta.IsStatusConditionFalse(foundLightningNode.Status.Conditions, bitcoinv1alpha1.ConditionKeyMaterialStale)).To(BeTrue())
+
+		By("rotating the key material in the seed secret")
+		Expect(k8sClient.Get(ctx, seedSecretNamespaceName, seedSecret)).To(Succeed())
+		seedSecret.Data["rootkey"] = []byte(rotatedRootKey)
+		Expect(k8sClient.Update(ctx, seedSecret)).To(Succeed())
+
+		_, err = lightningNodeReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: lightningNodeNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("checking if the key material is reported as stale")
+		Expect(k8sClient.Get(ctx, lightningNodeNamespaceName, foundLightningNode)).To(Succeed())
+		Expect(foundLightningNode.Status.SeedFingerprint).To(Equal(activeFingerprint))
+		condition := meta.FindStatusCondition(foundLightningNode.Status.Conditions, bitcoinv1alpha1.ConditionKeyMaterialStale)
+		Expect(condition).To(Not(BeNil()))
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(bitcoinv1alpha1.ReasonSeedRotated))
+
+		By("checking if comparing the key material again reports no change")
+		foundStatefulSet := &appsv1.StatefulSet{}
+		Expect(k8sClient.Get(ctx, statefulSetNamespaceName, foundStatefulSet)).To(Succeed())
+		_, changed, err := reconcileKeyMaterial(ctx, k8sClient, foundStatefulSet, SeedSecretName, &foundLightningNode.Status.Conditions)
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(changed).To(BeFalse())
 	})
 
 	It("should reconcile the LightningNode instance", func() {
@@ -71,7 +244,7 @@ var _ = Describe("LightningNode controller", func() {
 						SecretKey:  "password",
 					},
 					Seed: bitcoinv1alpha1.SeedImport{
-						SecretName: "mining-wallet",
+						SecretName: SeedSecretName,
 					},
 				},
 			},
@@ -280,4 +453,200 @@ var _ = Describe("LightningNode controller", func() {
 		}, time.Minute, time.Second).Should(Succeed())
 	})
 
+	DescribeTable("using the backend of the referenced BitcoinNode",
+		func(implementation string, network string, expectedHost string, expectedArgs []string, certMounted bool) {
+			By("creating the BitcoinNode the LightningNode connects to")
+			bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      BitcoinNodeName,
+					Namespace: Namespace,
+				},
+				Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+					Implementation: implementation,
+					Network:        network,
+				},
+			}
+			Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(k8sClient.Delete(ctx, bitcoinNode)).To(Succeed())
+			})
+
+			lightningNode := &bitcoinv1alpha1.LightningNode{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      LightningNodeName,
+					Namespace: Namespace,
+				},
+				Spec: bitcoinv1alpha1.LightningNodeSpec{
+					BitcoinConnection: bitcoinv1alpha1.BitcoinConnection{
+						BitcoinNode:          BitcoinNodeName,
+						Network:              network,
+						CertSecret:           "btcd-rpc-tls",
+						ApiAuthSecretName:    "btcd-rpc-creds",
+						ApiUserSecretKey:     "username",
+						ApiPasswordSecretKey: "password",
+					},
+				},
+			}
+
+			By("creating the custom resource for the kind LightningNode")
+			Expect(k8sClient.Create(ctx, lightningNode)).To(Succeed())
+
+			By("reconciling the custom resource created")
+			lightningNodeReconciler := LightningNodeReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err := lightningNodeReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: lightningNodeNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("checking the backend lnd is configured for")
+			foundStatefulSet := &appsv1.StatefulSet{}
+			Expect(k8sClient.Get(ctx, statefulSetNamespaceName, foundStatefulSet)).To(Succeed())
+			lnd := foundStatefulSet.Spec.Template.Spec.Containers[0]
+			env := map[string]string{}
+			for _, e := range lnd.Env {
+				env[e.Name] = e.Value
+			}
+			Expect(env["BACKEND"]).To(Equal(implementation))
+			Expect(env["RPCHOST"]).To(Equal(expectedHost))
+			Expect(lnd.Args).To(ContainElements(expectedArgs))
+
+			certMountExists := false
+			for _, volumeMount := range lnd.VolumeMounts {
+				if volumeMount.Name == "rpc-cert" {
+					certMountExists = true
+				}
+			}
+			Expect(certMountExists).To(Equal(certMounted))
+		},
+		Entry(
+			"when the BitcoinNode runs btcd",
+			bitcoinv1alpha1.ImplementationBtcd,
+			"simnet",
+			"bitcoin.test-namespace.svc.cluster.local:18556",
+			[]string{"--$(BACKEND).rpccert=/rpc/rpc.cert"},
+			true,
+		),
+		Entry(
+			"when the BitcoinNode runs bitcoind",
+			bitcoinv1alpha1.ImplementationBitcoind,
+			"regtest",
+			"bitcoin.test-namespace.svc.cluster.local:18443",
+			[]string{
+				"--bitcoind.zmqpubrawblock=tcp://bitcoin.test-namespace.svc.cluster.local:28332",
+				"--bitcoind.zmqpubrawtx=tcp://bitcoin.test-namespace.svc.cluster.local:28333",
+			},
+			false,
+		),
+	)
+
+	It("running on the network of the referenced BitcoinNode and updating its StatefulSet", func() {
+		By("creating a regtest BitcoinNode the LightningNode connects to")
+		bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BitcoinNodeName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+				Implementation: bitcoinv1alpha1.ImplementationBitcoind,
+				Network:        "regtest",
+			},
+		}
+		Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+		DeferCleanup(func() {
+			Expect(k8sClient.Delete(ctx, bitcoinNode)).To(Succeed())
+		})
+
+		lightningNode := &bitcoinv1alpha1.LightningNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      LightningNodeName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.LightningNodeSpec{
+				BitcoinConnection: bitcoinv1alpha1.BitcoinConnection{
+					BitcoinNode: BitcoinNodeName,
+					Network:     "simnet",
+				},
+				ContainerImages: bitcoinv1alpha1.LNDContainerImages{
+					LndImage: "docker.io/lightninglabs/lnd:v0.15.5-beta",
+				},
+			},
+		}
+
+		By("creating the custom resource for the kind LightningNode")
+		Expect(k8sClient.Create(ctx, lightningNode)).To(Succeed())
+
+		By("reconciling the custom resource created")
+		lightningNodeReconciler := LightningNodeReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		for i := 0; i < 2; i++ {
+			_, err := lightningNodeReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: lightningNodeNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		By("checking if lnd runs on the network of the BitcoinNode")
+		foundStatefulSet := &appsv1.StatefulSet{}
+		Expect(k8sClient.Get(ctx, statefulSetNamespaceName, foundStatefulSet)).To(Succeed())
+		lnd := foundStatefulSet.Spec.Template.Spec.Containers[0]
+		Expect(lnd.Env).To(ContainElement(corev1.EnvVar{Name: "NETWORK", Value: "regtest"}))
+
+		By("changing the lnd image")
+		Expect(k8sClient.Get(ctx, lightningNodeNamespaceName, lightningNode)).To(Succeed())
+		lightningNode.Spec.ContainerImages.LndImage = "docker.io/lightninglabs/lnd:v0.16.0-beta"
+		Expect(k8sClient.Update(ctx, lightningNode)).To(Succeed())
+
+		_, err := lightningNodeReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: lightningNodeNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("checking if the StatefulSet runs the new image")
+		Expect(k8sClient.Get(ctx, statefulSetNamespaceName, foundStatefulSet)).To(Succeed())
+		Expect(foundStatefulSet.Spec.Template.Spec.Containers[0].Image).To(Equal("docker.io/lightninglabs/lnd:v0.16.0-beta"))
+	})
+
+	It("waiting for a referenced BitcoinNode that does not exist", func() {
+		lightningNode := &bitcoinv1alpha1.LightningNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      LightningNodeName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.LightningNodeSpec{
+				BitcoinConnection: bitcoinv1alpha1.BitcoinConnection{
+					BitcoinNode: BitcoinNodeName,
+					Network:     "simnet",
+				},
+			},
+		}
+
+		By("creating the custom resource for the kind LightningNode")
+		Expect(k8sClient.Create(ctx, lightningNode)).To(Succeed())
+
+		By("reconciling the custom resource created")
+		lightningNodeReconciler := LightningNodeReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err := lightningNodeReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: lightningNodeNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("checking if the Ready condition reports the missing BitcoinNode")
+		foundLightningNode := &bitcoinv1alpha1.LightningNode{}
+		Expect(k8sClient.Get(ctx, lightningNodeNamespaceName, foundLightningNode)).To(Succeed())
+		condition := meta.FindStatusCondition(foundLightningNode.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(condition).To(Not(BeNil()))
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(bitcoinv1alpha1.ReasonBitcoinNodeUnavailable))
+
+		By("checking that no statefulset was created")
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, statefulSetNamespaceName, &appsv1.StatefulSet{}))).To(BeTrue())
+	})
 })
@@ -0,0 +1,266 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// MiningRequestReconciler reconciles a MiningRequest object
+type MiningRequestReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Dialer connects to the RPC servers of the nodes, rpcclient when nil
+	Dialer RPCDialer
+}
+
+//+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=miningrequests,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=miningrequests/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=miningrequests/finalizers,verbs=update
+//+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=bitcoinnodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+
+func (r *MiningRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrllog.FromContext(ctx)
+	request := &bitcoinv1alpha1.MiningRequest{}
+	err := r.Get(ctx, req.NamespacedName, request)
+
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("MiningRequest resource not found.")
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to get MiningRequest")
+		return ctrl.Result{}, err
+	}
+
+	if request.Status.CompletionTime != nil {
+		return ctrl.Result{}, nil
+	}
+
+	// Blocks are mined at most once. A request that started and did not record its blocks may have mined them, so
+	// it is not retried.
+	if request.Status.StartTime != nil {
+		ready := meta.FindStatusCondition(request.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		if ready != nil && ready.Reason != bitcoinv1alpha1.ReasonMining {
+			return ctrl.Result{}, nil
+		}
+		message := "mining started at height " + strconv.FormatInt(request.Status.StartHeight, 10) + " and did not complete, create another MiningRequest to mine the blocks"
+		return ctrl.Result{}, r.updateReadyCondition(ctx, request, metav1.ConditionFalse, bitcoinv1alpha1.ReasonMiningInterrupted, message)
+	}
+
+	bitcoinNode := &bitcoinv1alpha1.BitcoinNode{}
+	err = r.Get(ctx, types.NamespacedName{Name: request.Spec.BitcoinNodeName, Namespace: request.Namespace}, bitcoinNode)
+
+	if err != nil && errors.IsNotFound(err) {
+		return ctrl.Result{}, r.updateReadyCondition(ctx, request, metav1.ConditionFalse, bitcoinv1alpha1.ReasonBitcoinNodeUnavailable, "BitcoinNode "+request.Spec.BitcoinNodeName+" does not exist")
+	} else if err != nil {
+		log.Error(err, "Failed to get BitcoinNode")
+		return ctrl.Result{}, err
+	}
+
+	implementation := implementationOf(bitcoinNode)
+	transactions, err := validateMiningRequestSpec(request.Spec, implementation)
+
+	if err != nil {
+		log.Error(err, "Invalid MiningRequest spec")
+		return ctrl.Result{}, r.updateReadyCondition(ctx, request, metav1.ConditionFalse, bitcoinv1alpha1.ReasonInvalidSpec, err.Error())
+	}
+
+	network, err := networkForBitcoinNode(bitcoinNode)
+
+	if err != nil {
+		return ctrl.Result{}, r.updateReadyCondition(ctx, request, metav1.ConditionFalse, bitcoinv1alpha1.ReasonBitcoinNodeUnavailable, "BitcoinNode "+bitcoinNode.Name+": "+err.Error())
+	}
+
+	address, err := r.miningAddress(ctx, request, bitcoinNode, network)
+
+	if err != nil {
+		log.Error(err, "Failed to get the mining address")
+		reason := bitcoinv1alpha1.ReasonSecretRefUnavailable
+		if request.Spec.Address != "" {
+			reason = bitcoinv1alpha1.ReasonInvalidSpec
+		}
+		return ctrl.Result{}, r.updateReadyCondition(ctx, request, metav1.ConditionFalse, reason, err.Error())
+	}
+
+	connCfg, err := rpcConnConfig(ctx, r.Client, bitcoinNode)
+
+	if err != nil {
+		log.Error(err, "Failed to get the RPC secrets of the BitcoinNode")
+		return ctrl.Result{RequeueAfter: time.Second * 10}, r.updateReadyCondition(ctx, request, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSecretRefUnavailable, err.Error())
+	}
+
+	rpcClient, err := dialerOr(r.Dialer)(connCfg)
+
+	if err != nil {
+		log.Error(err, "Failed to connect to the BitcoinNode")
+		return ctrl.Result{RequeueAfter: time.Second * 10}, r.updateReadyCondition(ctx, request, metav1.ConditionFalse, bitcoinv1alpha1.ReasonRPCUnreachable, err.Error())
+	}
+	defer rpcClient.Shutdown()
+
+	startHeight, err := rpcClient.GetBlockCount()
+
+	if err != nil {
+		log.Error(err, "Failed to get the block count of the BitcoinNode")
+		return ctrl.Result{RequeueAfter: time.Second * 10}, r.updateReadyCondition(ctx, request, metav1.ConditionFalse, bitcoinv1alpha1.ReasonRPCUnreachable, err.Error())
+	}
+
+	// The start is recorded before anything is sent to the node, a conflicting update leaves the request unstarted
+	startTime := metav1.Now()
+	request.Status.StartTime = &startTime
+	request.Status.StartHeight = startHeight
+	err = r.updateReadyCondition(ctx, request, metav1.ConditionFalse, bitcoinv1alpha1.ReasonMining, "mining "+strconv.FormatInt(request.Spec.Blocks, 10)+" blocks on BitcoinNode "+bitcoinNode.Name)
+
+	if err != nil {
+		log.Error(err, "Failed to update MiningRequest status")
+		return ctrl.Result{}, err
+	}
+
+	for _, tx := range transactions {
+		txHash, err := rpcClient.SendRawTransaction(tx, false)
+		if err != nil {
+			log.Error(err, "Failed to send transaction", "Transaction", tx.TxHash().String())
+			return ctrl.Result{}, r.updateReadyCondition(ctx, request, metav1.ConditionFalse, bitcoinv1alpha1.ReasonMiningFailed, "transaction "+tx.TxHash().String()+" was rejected: "+err.Error())
+		}
+		request.Status.TransactionIDs = append(request.Status.TransactionIDs, txHash.String())
+	}
+
+	log.Info("Mining blocks", "BitcoinNode.Name", bitcoinNode.Name, "Blocks", request.Spec.Blocks)
+	hashes, err := generateBlocks(rpcClient, implementation, request.Spec.Blocks, address)
+
+	if err != nil {
+		log.Error(err, "Failed to mine blocks")
+		return ctrl.Result{}, r.updateReadyCondition(ctx, request, metav1.ConditionFalse, bitcoinv1alpha1.ReasonMiningFailed, err.Error())
+	}
+
+	request.Status.BlockHashes = nil
+	for _, hash := range hashes {
+		request.Status.BlockHashes = append(request.Status.BlockHashes, hash.String())
+	}
+
+	height, err := rpcClient.GetBlockCount()
+	if err != nil {
+		log.Error(err, "Failed to get the block count of the BitcoinNode")
+		height = startHeight + int64(len(hashes))
+	}
+
+	completionTime := metav1.Now()
+	request.Status.Height = height
+	request.Status.CompletionTime = &completionTime
+	message := fmt.Sprintf("mined %d blocks on BitcoinNode %s up to height %d", len(hashes), bitcoinNode.Name, height)
+	return ctrl.Result{}, r.updateReadyCondition(ctx, request, metav1.ConditionTrue, bitcoinv1alpha1.ReasonReconciled, message)
+}
+
+// miningAddress returns the address the blocks of a request are mined to: its own address, the address in its secret,
+// or the reward address of the node
+func (r *MiningRequestReconciler) miningAddress(ctx context.Context, m *bitcoinv1alpha1.MiningRequest, b *bitcoinv1alpha1.BitcoinNode, network bitcoinv1alpha1.BitcoinNetwork) (btcutil.Address, error) {
+	if m.Spec.Address != "" {
+		return decodeAddress(m.Spec.Address, network)
+	}
+	if m.Spec.AddressSecretRef.SecretName != "" {
+		return addressFromSecret(ctx, r.Client, m.Namespace, m.Spec.AddressSecretRef, network)
+	}
+	return rewardAddress(ctx, r.Client, b, network)
+}
+
+// validateMiningRequestSpec checks a MiningRequest against the implementation of its node and returns its decoded
+// transactions
+func validateMiningRequestSpec(spec bitcoinv1alpha1.MiningRequestSpec, implementation string) ([]*wire.MsgTx, error) {
+	if spec.Address != "" && spec.AddressSecretRef.SecretName != "" {
+		return nil, fmt.Errorf("address and addressSecretRef are mutually exclusive")
+	}
+	if implementation != bitcoinv1alpha1.ImplementationBitcoind && (spec.Address != "" || spec.AddressSecretRef.SecretName != "") {
+		return nil, fmt.Errorf("%s mines to the reward address of the BitcoinNode, an address can only be set for %s", implementation, bitcoinv1alpha1.ImplementationBitcoind)
+	}
+
+	var transactions []*wire.MsgTx
+	for i, raw := range spec.Transactions {
+		serialized, err := hex.DecodeString(raw)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d is not hex: %w", i, err)
+		}
+		tx := &wire.MsgTx{}
+		err = tx.Deserialize(bytes.NewReader(serialized))
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d is not a raw transaction: %w", i, err)
+		}
+		transactions = append(transactions, tx)
+	}
+	return transactions, nil
+}
+
+// updateReadyCondition records the Ready condition and the observed generation in the MiningRequest status
+func (r *MiningRequestReconciler) updateReadyCondition(ctx context.Context, m *bitcoinv1alpha1.MiningRequest, status metav1.ConditionStatus, reason string, message string) error {
+	m.Status.ObservedGeneration = m.Generation
+	meta.SetStatusCondition(&m.Status.Conditions, metav1.Condition{
+		Type:               bitcoinv1alpha1.ConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: m.Generation,
+	})
+	return r.Status().Update(ctx, m)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MiningRequestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&bitcoinv1alpha1.MiningRequest{}).
+		Watches(&source.Kind{Type: &bitcoinv1alpha1.BitcoinNode{}}, handler.EnqueueRequestsFromMapFunc(r.miningRequestsForBitcoinNode)).
+		Complete(r)
+}
+
+// miningRequestsForBitcoinNode maps a BitcoinNode to the MiningRequests that mine on it, so a request waiting for its
+// node is mined once the node exists
+func (r *MiningRequestReconciler) miningRequestsForBitcoinNode(node client.Object) []reconcile.Request {
+	requests := &bitcoinv1alpha1.MiningRequestList{}
+	err := r.List(context.Background(), requests, client.InNamespace(node.GetNamespace()))
+	if err != nil {
+		return nil
+	}
+
+	var result []reconcile.Request
+	for _, m := range requests.Items {
+		if m.Spec.BitcoinNodeName == node.GetName() && m.Status.CompletionTime == nil {
+			result = append(result, reconcile.Request{NamespacedName: types.NamespacedName{Name: m.Name, Namespace: m.Namespace}})
+		}
+	}
+	return result
+}
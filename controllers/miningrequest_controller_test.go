@@ -0,0 +1,235 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+var _ = Describe("MiningRequest controller", func() {
+
+	const Namespace = "test-mining-namespace"
+	const BitcoinNodeName = "miner"
+	const RequestName = "test-request"
+
+	ctx := context.Background()
+	requestNamespacedName := types.NamespacedName{Namespace: Namespace, Name: RequestName}
+
+	var node *fakeBitcoinRPC
+
+	reconcileRequest := func() {
+		requestReconciler := MiningRequestReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+			Dialer: node.dial,
+		}
+		_, err := requestReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: requestNamespacedName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+	}
+
+	createBitcoinNode := func(implementation string, network string) {
+		By("creating the BitcoinNode and its RPC secrets")
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: BitcoinNodeName + "-rpc-tls", Namespace: Namespace},
+			StringData: map[string]string{"ca.crt": "", "tls.crt": "", "tls.key": ""},
+		})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: BitcoinNodeName + "-rpc-creds", Namespace: Namespace},
+			StringData: map[string]string{"username": "kiln", "password": "secret"},
+		})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{Name: BitcoinNodeName, Namespace: Namespace},
+			Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+				Implementation: implementation,
+				Network:        network,
+			},
+		})).To(Succeed())
+	}
+
+	createRequest := func(spec bitcoinv1alpha1.MiningRequestSpec) {
+		By("creating the custom resource for the kind MiningRequest")
+		spec.BitcoinNodeName = BitcoinNodeName
+		Expect(k8sClient.Create(ctx, &bitcoinv1alpha1.MiningRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: RequestName, Namespace: Namespace},
+			Spec:       spec,
+		})).To(Succeed())
+	}
+
+	readyCondition := func() *metav1.Condition {
+		found := &bitcoinv1alpha1.MiningRequest{}
+		Expect(k8sClient.Get(ctx, requestNamespacedName, found)).To(Succeed())
+		return meta.FindStatusCondition(found.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+	}
+
+	BeforeEach(func() {
+		By("creating namespace to perform the tests")
+		_ = k8sClient.Create(ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      Namespace,
+				Namespace: Namespace,
+			},
+		})
+
+		node = newFakeBitcoinRPC(10)
+	})
+
+	AfterEach(func() {
+		By("cleaning up the MiningRequest, the BitcoinNode and its secrets")
+		objects := []client.Object{
+			&bitcoinv1alpha1.MiningRequest{ObjectMeta: metav1.ObjectMeta{Name: RequestName, Namespace: Namespace}},
+			&bitcoinv1alpha1.BitcoinNode{ObjectMeta: metav1.ObjectMeta{Name: BitcoinNodeName, Namespace: Namespace}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: BitcoinNodeName + "-rpc-tls", Namespace: Namespace}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: BitcoinNodeName + "-rpc-creds", Namespace: Namespace}},
+		}
+		for _, object := range objects {
+			_ = k8sClient.Delete(ctx, object)
+		}
+	})
+
+	It("mining the requested blocks with the requested transactions once", func() {
+		createBitcoinNode(bitcoinv1alpha1.ImplementationBtcd, "simnet")
+
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 0}, nil, nil))
+		tx.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+		var serialized bytes.Buffer
+		Expect(tx.Serialize(&serialized)).To(Succeed())
+
+		createRequest(bitcoinv1alpha1.MiningRequestSpec{
+			Blocks:       6,
+			Transactions: []string{hex.EncodeToString(serialized.Bytes())},
+		})
+
+		By("reconciling the MiningRequest")
+		reconcileRequest()
+
+		By("checking that the transactions were sent and the blocks were mined")
+		Expect(node.sent).To(HaveLen(1))
+		Expect(node.height()).To(Equal(int64(16)))
+
+		found := &bitcoinv1alpha1.MiningRequest{}
+		Expect(k8sClient.Get(ctx, requestNamespacedName, found)).To(Succeed())
+		Expect(found.Status.StartHeight).To(Equal(int64(10)))
+		Expect(found.Status.Height).To(Equal(int64(16)))
+		Expect(found.Status.TransactionIDs).To(Equal([]string{tx.TxHash().String()}))
+		Expect(found.Status.BlockHashes).To(HaveLen(6))
+		Expect(found.Status.BlockHashes[5]).To(Equal(node.bestBlockHash()))
+		Expect(found.Status.StartTime).To(Not(BeNil()))
+		Expect(found.Status.CompletionTime).To(Not(BeNil()))
+		Expect(meta.IsStatusConditionTrue(found.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeTrue())
+
+		By("reconciling the completed MiningRequest again")
+		reconcileRequest()
+		Expect(node.height()).To(Equal(int64(16)))
+		Expect(node.sent).To(HaveLen(1))
+	})
+
+	It("mining to the requested address on bitcoind", func() {
+		createBitcoinNode(bitcoinv1alpha1.ImplementationBitcoind, "regtest")
+		address, err := btcutil.NewAddressWitnessPubKeyHash(make([]byte, 20), &chaincfg.RegressionNetParams)
+		Expect(err).To(Not(HaveOccurred()))
+
+		createRequest(bitcoinv1alpha1.MiningRequestSpec{
+			Blocks:  3,
+			Address: address.EncodeAddress(),
+		})
+
+		By("reconciling the MiningRequest")
+		reconcileRequest()
+
+		By("checking that the blocks were mined to the address")
+		Expect(node.minedTo).To(Equal([]string{address.EncodeAddress(), address.EncodeAddress(), address.EncodeAddress()}))
+		Expect(readyCondition().Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	It("refusing an address for a btcd node", func() {
+		createBitcoinNode(bitcoinv1alpha1.ImplementationBtcd, "simnet")
+		address, err := btcutil.NewAddressWitnessPubKeyHash(make([]byte, 20), &chaincfg.SimNetParams)
+		Expect(err).To(Not(HaveOccurred()))
+
+		createRequest(bitcoinv1alpha1.MiningRequestSpec{
+			Blocks:  1,
+			Address: address.EncodeAddress(),
+		})
+
+		By("reconciling the MiningRequest")
+		reconcileRequest()
+
+		By("checking that no block was mined and the spec was refused")
+		Expect(node.height()).To(Equal(int64(10)))
+		ready := readyCondition()
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal(bitcoinv1alpha1.ReasonInvalidSpec))
+	})
+
+	It("waiting for a BitcoinNode that does not exist", func() {
+		createRequest(bitcoinv1alpha1.MiningRequestSpec{Blocks: 1})
+
+		By("reconciling the MiningRequest")
+		reconcileRequest()
+
+		ready := readyCondition()
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal(bitcoinv1alpha1.ReasonBitcoinNodeUnavailable))
+	})
+
+	It("not mining again after an interrupted attempt", func() {
+		createBitcoinNode(bitcoinv1alpha1.ImplementationBtcd, "simnet")
+		createRequest(bitcoinv1alpha1.MiningRequestSpec{Blocks: 2})
+
+		By("recording an attempt that started and did not complete")
+		found := &bitcoinv1alpha1.MiningRequest{}
+		Expect(k8sClient.Get(ctx, requestNamespacedName, found)).To(Succeed())
+		startTime := metav1.Now()
+		found.Status.StartTime = &startTime
+		found.Status.StartHeight = 10
+		meta.SetStatusCondition(&found.Status.Conditions, metav1.Condition{
+			Type:    bitcoinv1alpha1.ConditionReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  bitcoinv1alpha1.ReasonMining,
+			Message: "mining 2 blocks on BitcoinNode " + BitcoinNodeName,
+		})
+		Expect(k8sClient.Status().Update(ctx, found)).To(Succeed())
+
+		By("reconciling the MiningRequest")
+		reconcileRequest()
+
+		By("checking that no block was mined and the request was marked interrupted")
+		Expect(node.height()).To(Equal(int64(10)))
+		ready := readyCondition()
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal(bitcoinv1alpha1.ReasonMiningInterrupted))
+	})
+})
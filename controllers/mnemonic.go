@@ -1,43 +1,121 @@
 package controllers
 
 import (
-	"errors"
 	"fmt"
 	"github.com/lightningnetwork/lnd/aezeed"
-	"k8s.io/utils/strings/slices"
-	"math/rand"
+	"github.com/tyler-smith/go-bip39"
+	"io"
 	"strings"
 	"time"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
+// seedMaterial is the key material of a Seed, independent of the mnemonic format
+type seedMaterial struct {
+	format     string
+	mnemonic   []string
+	passphrase string
+
+	// seed is the input to BIP32 master key generation
+	seed []byte
 
-var letters = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890")
+	// cipherSeed is only set for aezeed mnemonics
+	cipherSeed *aezeed.CipherSeed
+}
 
-func randSeq(n int) string {
-	b := make([]rune, n)
-	for i := range b {
-		b[i] = letters[rand.Intn(len(letters))]
+// newSeedMaterial imports the given mnemonic in the requested format, or generates a new one from the generator
+// when it is empty. A generated aezeed mnemonic records the birthday.
+func newSeedMaterial(generator SecretGenerator, birthday time.Time, format string, mnemonicStr string, passphrase string, wordCount int) (*seedMaterial, error) {
+	switch format {
+	case "", bitcoinv1alpha1.SeedFormatAezeed:
+		return newAezeedMaterial(generator, birthday, mnemonicStr, passphrase)
+	case bitcoinv1alpha1.SeedFormatBIP39:
+		return newBIP39Material(generator, mnemonicStr, passphrase, wordCount)
+	default:
+		return nil, fmt.Errorf("unsupported seed format %q", format)
 	}
-	return string(b)
 }
 
-func initializeMnemonic(mnemonicStr string) (aezeed.Mnemonic, error) {
+func newAezeedMaterial(generator SecretGenerator, birthday time.Time, mnemonicStr string, passphrase string) (*seedMaterial, error) {
 	mnemonic := aezeed.Mnemonic{}
-	mnemonicSlice := strings.Fields(mnemonicStr)
 
-	if len(mnemonicSlice) != 24 {
-		return mnemonic, errors.New("mnemonic contains the wrong number of words")
+	if mnemonicStr == "" {
+		// The generator provides both the entropy and the salt of the cipher seed
+		cipherSeed, err := aezeed.New(0, nil, birthday, aezeed.WithRandomnessSource(generator))
+		if err != nil {
+			return nil, err
+		}
+
+		if passphrase == "" {
+			passphrase, err = generator.String(DefaultSecretLength, AlphanumericAlphabet)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		mnemonic, err = cipherSeed.ToMnemonic([]byte(passphrase))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		mnemonic, err = bitcoinv1alpha1.ParseAezeedMnemonic(mnemonicStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cipherSeed, err := mnemonic.ToCipherSeed([]byte(passphrase))
+	if err != nil {
+		return nil, err
 	}
 
-	for i, word := range mnemonicSlice {
-		if !slices.Contains(aezeed.DefaultWordList, word) {
-			return mnemonic, errors.New(fmt.Sprintf("mnemonic contains an invalid word at index %d", i))
+	return &seedMaterial{
+		format:     bitcoinv1alpha1.SeedFormatAezeed,
+		mnemonic:   mnemonic[:],
+		passphrase: passphrase,
+		seed:       cipherSeed.Entropy[:],
+		cipherSeed: cipherSeed,
+	}, nil
+}
+
+func newBIP39Material(generator SecretGenerator, mnemonicStr string, passphrase string, wordCount int) (*seedMaterial, error) {
+	if mnemonicStr == "" {
+		if wordCount == 0 {
+			wordCount = 24
+		}
+		if wordCount != 12 && wordCount != 24 {
+			return nil, fmt.Errorf("unsupported BIP39 word count %d", wordCount)
 		}
+
+		// Every 3 words encode 32 bits of entropy and 1 bit of checksum
+		entropy := make([]byte, wordCount/3*4)
+		if _, err := io.ReadFull(generator, entropy); err != nil {
+			return nil, err
+		}
+
+		var err error
+		mnemonicStr, err = bip39.NewMnemonic(entropy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mnemonic, err := bitcoinv1alpha1.ParseBIP39Mnemonic(mnemonicStr)
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := bip39.NewSeedWithErrorChecking(strings.Join(mnemonic, " "), passphrase)
+	if err != nil {
+		return nil, err
 	}
 
-	copy(mnemonic[:], mnemonicSlice)
-	return mnemonic, nil
+	return &seedMaterial{
+		format:     bitcoinv1alpha1.SeedFormatBIP39,
+		mnemonic:   mnemonic,
+		passphrase: passphrase,
+		seed:       seed,
+	}, nil
 }
@@ -0,0 +1,415 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// ReorgReconciler reconciles a Reorg object
+type ReorgReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Dialer connects to the RPC servers of the nodes, rpcclient when nil
+	Dialer RPCDialer
+}
+
+//+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=reorgs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=reorgs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=reorgs/finalizers,verbs=update
+//+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=bitcoinnodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+
+func (r *ReorgReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrllog.FromContext(ctx)
+	reorg := &bitcoinv1alpha1.Reorg{}
+	err := r.Get(ctx, req.NamespacedName, reorg)
+
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("Reorg resource not found.")
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to get Reorg")
+		return ctrl.Result{}, err
+	}
+
+	if reorg.Status.CompletionTime != nil {
+		return ctrl.Result{}, nil
+	}
+
+	// A chain is reorganized at most once. A Reorg that started and did not record its outcome may have invalidated
+	// blocks, so it is not retried.
+	if reorg.Status.StartTime != nil {
+		ready := meta.FindStatusCondition(reorg.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		if ready != nil && ready.Reason != bitcoinv1alpha1.ReasonReorganizing {
+			return ctrl.Result{}, nil
+		}
+		message := "the reorganization started at height " + strconv.FormatInt(reorg.Status.OldHeight, 10) + " and did not complete, create another Reorg to reorganize the chain"
+		return ctrl.Result{}, r.endReorg(ctx, reorg, bitcoinv1alpha1.ReasonReorgInterrupted, message)
+	}
+
+	bitcoinNode := &bitcoinv1alpha1.BitcoinNode{}
+	err = r.Get(ctx, types.NamespacedName{Name: reorg.Spec.BitcoinNodeName, Namespace: reorg.Namespace}, bitcoinNode)
+
+	if err != nil && errors.IsNotFound(err) {
+		return ctrl.Result{}, r.updateReadyCondition(ctx, reorg, metav1.ConditionFalse, bitcoinv1alpha1.ReasonBitcoinNodeUnavailable, "BitcoinNode "+reorg.Spec.BitcoinNodeName+" does not exist")
+	} else if err != nil {
+		log.Error(err, "Failed to get BitcoinNode")
+		return ctrl.Result{}, err
+	}
+
+	implementation := implementationOf(bitcoinNode)
+
+	if implementation != bitcoinv1alpha1.ImplementationBitcoind {
+		message := implementation + " does not implement invalidateblock, a Reorg needs a " + bitcoinv1alpha1.ImplementationBitcoind + " BitcoinNode"
+		return ctrl.Result{}, r.updateReadyCondition(ctx, reorg, metav1.ConditionFalse, bitcoinv1alpha1.ReasonInvalidSpec, message)
+	}
+
+	var isolated []*bitcoinv1alpha1.BitcoinNode
+	for _, name := range reorg.Spec.IsolatedNodes {
+		if name == bitcoinNode.Name {
+			return ctrl.Result{}, r.updateReadyCondition(ctx, reorg, metav1.ConditionFalse, bitcoinv1alpha1.ReasonInvalidSpec, "BitcoinNode "+name+" cannot be isolated from itself")
+		}
+		node := &bitcoinv1alpha1.BitcoinNode{}
+		err = r.Get(ctx, types.NamespacedName{Name: name, Namespace: reorg.Namespace}, node)
+		if err != nil && errors.IsNotFound(err) {
+			return ctrl.Result{}, r.updateReadyCondition(ctx, reorg, metav1.ConditionFalse, bitcoinv1alpha1.ReasonBitcoinNodeUnavailable, "BitcoinNode "+name+" does not exist")
+		} else if err != nil {
+			log.Error(err, "Failed to get BitcoinNode")
+			return ctrl.Result{}, err
+		}
+		isolated = append(isolated, node)
+	}
+
+	network, err := networkForBitcoinNode(bitcoinNode)
+
+	if err != nil {
+		return ctrl.Result{}, r.updateReadyCondition(ctx, reorg, metav1.ConditionFalse, bitcoinv1alpha1.ReasonBitcoinNodeUnavailable, "BitcoinNode "+bitcoinNode.Name+": "+err.Error())
+	}
+
+	address, err := rewardAddress(ctx, r.Client, bitcoinNode, network)
+
+	if err != nil {
+		log.Error(err, "Failed to get the reward address")
+		return ctrl.Result{}, r.updateReadyCondition(ctx, reorg, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSecretRefUnavailable, err.Error())
+	}
+
+	connCfg, err := rpcConnConfig(ctx, r.Client, bitcoinNode)
+
+	if err != nil {
+		log.Error(err, "Failed to get the RPC secrets of the BitcoinNode")
+		return ctrl.Result{RequeueAfter: time.Second * 10}, r.updateReadyCondition(ctx, reorg, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSecretRefUnavailable, err.Error())
+	}
+
+	rpcClient, err := dialerOr(r.Dialer)(connCfg)
+
+	if err != nil {
+		log.Error(err, "Failed to connect to the BitcoinNode")
+		return ctrl.Result{RequeueAfter: time.Second * 10}, r.updateReadyCondition(ctx, reorg, metav1.ConditionFalse, bitcoinv1alpha1.ReasonRPCUnreachable, err.Error())
+	}
+	defer rpcClient.Shutdown()
+
+	chainInfo, err := rpcClient.GetBlockChainInfo()
+
+	if err != nil {
+		log.Error(err, "Failed to get the chain of the BitcoinNode")
+		return ctrl.Result{RequeueAfter: time.Second * 10}, r.updateReadyCondition(ctx, reorg, metav1.ConditionFalse, bitcoinv1alpha1.ReasonRPCUnreachable, err.Error())
+	}
+
+	oldHeight := int64(chainInfo.Blocks)
+
+	// The genesis block cannot be invalidated
+	if reorg.Spec.Depth >= oldHeight {
+		message := fmt.Sprintf("cannot invalidate %d blocks, BitcoinNode %s has %d blocks after the genesis block", reorg.Spec.Depth, bitcoinNode.Name, oldHeight)
+		return ctrl.Result{}, r.updateReadyCondition(ctx, reorg, metav1.ConditionFalse, bitcoinv1alpha1.ReasonInvalidSpec, message)
+	}
+
+	// The start is recorded before anything is changed on the nodes, a conflicting update leaves the Reorg unstarted
+	startTime := metav1.Now()
+	reorg.Status.StartTime = &startTime
+	reorg.Status.OldTip = chainInfo.BestBlockHash
+	reorg.Status.OldHeight = oldHeight
+	err = r.updateReadyCondition(ctx, reorg, metav1.ConditionFalse, bitcoinv1alpha1.ReasonReorganizing, "reorganizing the chain of BitcoinNode "+bitcoinNode.Name)
+
+	if err != nil {
+		log.Error(err, "Failed to update Reorg status")
+		return ctrl.Result{}, err
+	}
+
+	reorg.Status.DisconnectedPeers, err = r.isolateNodes(ctx, rpcClient, bitcoinNode, isolated)
+
+	if err != nil {
+		log.Error(err, "Failed to isolate the BitcoinNodes")
+		return ctrl.Result{}, r.endReorg(ctx, reorg, bitcoinv1alpha1.ReasonReorgFailed, err.Error())
+	}
+
+	forkHeight := oldHeight - reorg.Spec.Depth + 1
+	forkHash, err := rpcClient.GetBlockHash(forkHeight)
+
+	if err == nil {
+		log.Info("Invalidating blocks", "BitcoinNode.Name", bitcoinNode.Name, "Height", forkHeight, "Hash", forkHash.String())
+		err = rpcClient.InvalidateBlock(forkHash)
+	}
+
+	if err != nil {
+		log.Error(err, "Failed to invalidate blocks")
+		return ctrl.Result{}, r.endReorg(ctx, reorg, bitcoinv1alpha1.ReasonReorgFailed, err.Error())
+	}
+
+	hashes, err := generateBlocks(rpcClient, implementation, reorg.Spec.Blocks, address)
+
+	if err != nil {
+		log.Error(err, "Failed to mine the competing blocks")
+		return ctrl.Result{}, r.endReorg(ctx, reorg, bitcoinv1alpha1.ReasonReorgFailed, err.Error())
+	}
+
+	reorg.Status.NewHeight = forkHeight - 1 + int64(len(hashes))
+	if len(hashes) > 0 {
+		reorg.Status.NewTip = hashes[len(hashes)-1].String()
+	}
+
+	chainInfo, err = rpcClient.GetBlockChainInfo()
+
+	if err != nil {
+		log.Error(err, "Failed to get the chain of the BitcoinNode")
+	} else {
+		reorg.Status.NewTip = chainInfo.BestBlockHash
+		reorg.Status.NewHeight = int64(chainInfo.Blocks)
+	}
+
+	completionTime := metav1.Now()
+	reorg.Status.Depth = reorg.Spec.Depth
+	reorg.Status.CompletionTime = &completionTime
+	message := fmt.Sprintf("replaced %d blocks of BitcoinNode %s with %d blocks, the tip moved from height %d to %d", reorg.Status.Depth, bitcoinNode.Name, len(hashes), reorg.Status.OldHeight, reorg.Status.NewHeight)
+	return ctrl.Result{}, r.updateReadyCondition(ctx, reorg, metav1.ConditionTrue, bitcoinv1alpha1.ReasonReconciled, message)
+}
+
+// isolateNodes disconnects the isolated BitcoinNodes from a node and removes the permanent peers between them in both
+// directions, so neither reconnects. It returns the addresses of the disconnected peers.
+func (r *ReorgReconciler) isolateNodes(ctx context.Context, c BitcoinRPC, b *bitcoinv1alpha1.BitcoinNode, isolated []*bitcoinv1alpha1.BitcoinNode) ([]string, error) {
+	implementation := implementationOf(b)
+	var disconnected []string
+
+	for _, node := range isolated {
+		for _, peer := range configuredPeers(b) {
+			if peerNodeName(peer) != node.Name {
+				continue
+			}
+			err := removePeer(c, implementation, peer)
+			if err != nil {
+				return disconnected, err
+			}
+		}
+
+		err := r.removeNodePeers(ctx, node, b.Name)
+		if err != nil {
+			return disconnected, err
+		}
+
+		ips, err := podIPs(ctx, r.Client, node)
+		if err != nil {
+			return disconnected, err
+		}
+
+		peers, err := c.GetPeerInfo()
+		if err != nil {
+			return disconnected, err
+		}
+
+		for _, peer := range peers {
+			host, _, err := net.SplitHostPort(peer.Addr)
+			if err != nil || !ips[host] {
+				continue
+			}
+			err = disconnectPeer(c, implementation, peer)
+			if err != nil {
+				return disconnected, err
+			}
+			disconnected = append(disconnected, peer.Addr)
+		}
+	}
+	return disconnected, nil
+}
+
+// removeNodePeers removes the permanent peers of a BitcoinNode that point to another BitcoinNode through its own RPC
+// server
+func (r *ReorgReconciler) removeNodePeers(ctx context.Context, b *bitcoinv1alpha1.BitcoinNode, peerName string) error {
+	var peers []string
+	for _, peer := range configuredPeers(b) {
+		if peerNodeName(peer) == peerName {
+			peers = append(peers, peer)
+		}
+	}
+	if len(peers) == 0 {
+		return nil
+	}
+
+	connCfg, err := rpcConnConfig(ctx, r.Client, b)
+	if err != nil {
+		return err
+	}
+
+	c, err := dialerOr(r.Dialer)(connCfg)
+	if err != nil {
+		return err
+	}
+	defer c.Shutdown()
+
+	for _, peer := range peers {
+		err = removePeer(c, implementationOf(b), peer)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// configuredPeers returns the peers of the spec of a BitcoinNode and the peers its selector connected it to
+func configuredPeers(b *bitcoinv1alpha1.BitcoinNode) []string {
+	var peers []string
+	seen := map[string]bool{}
+	addresses := append([]string{b.Spec.Peer}, b.Spec.Peers...)
+	for _, connection := range b.Status.PeerConnections {
+		addresses = append(addresses, connection.Address)
+	}
+	for _, address := range addresses {
+		if address != "" && !seen[address] {
+			seen[address] = true
+			peers = append(peers, address)
+		}
+	}
+	return peers
+}
+
+// podIPs returns the IP addresses of the pods of a BitcoinNode, which its peers see since its Service is headless
+func podIPs(ctx context.Context, c client.Client, b *bitcoinv1alpha1.BitcoinNode) (map[string]bool, error) {
+	pods := &corev1.PodList{}
+	err := c.List(ctx, pods, client.InNamespace(b.Namespace), client.MatchingLabels(labelsForBitcoinNode(b.Name)))
+	if err != nil {
+		return nil, err
+	}
+
+	ips := map[string]bool{}
+	for _, pod := range pods.Items {
+		if pod.Status.PodIP != "" {
+			ips[pod.Status.PodIP] = true
+		}
+		for _, ip := range pod.Status.PodIPs {
+			ips[ip.IP] = true
+		}
+	}
+	return ips, nil
+}
+
+// isolatedPeerNodes returns the names of the BitcoinNodes that a started Reorg keeps apart from a BitcoinNode, which
+// the BitcoinNode reconciler does not reconnect it to. A Reorg that completed or failed releases its nodes.
+func isolatedPeerNodes(ctx context.Context, c client.Client, b *bitcoinv1alpha1.BitcoinNode) (map[string]bool, error) {
+	reorgs := &bitcoinv1alpha1.ReorgList{}
+	err := c.List(ctx, reorgs, client.InNamespace(b.Namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	isolated := map[string]bool{}
+	for _, reorg := range reorgs.Items {
+		if reorg.Status.StartTime == nil || reorg.Status.CompletionTime != nil {
+			continue
+		}
+		for _, name := range reorg.Spec.IsolatedNodes {
+			if reorg.Spec.BitcoinNodeName == b.Name {
+				isolated[name] = true
+			} else if name == b.Name {
+				isolated[reorg.Spec.BitcoinNodeName] = true
+			}
+		}
+	}
+	return isolated, nil
+}
+
+// endReorg records the end of a started Reorg that did not complete, which releases its isolated BitcoinNodes
+func (r *ReorgReconciler) endReorg(ctx context.Context, reorg *bitcoinv1alpha1.Reorg, reason string, message string) error {
+	completionTime := metav1.Now()
+	reorg.Status.CompletionTime = &completionTime
+	return r.updateReadyCondition(ctx, reorg, metav1.ConditionFalse, reason, message)
+}
+
+// updateReadyCondition records the Ready condition and the observed generation in the Reorg status
+func (r *ReorgReconciler) updateReadyCondition(ctx context.Context, reorg *bitcoinv1alpha1.Reorg, status metav1.ConditionStatus, reason string, message string) error {
+	reorg.Status.ObservedGeneration = reorg.Generation
+	meta.SetStatusCondition(&reorg.Status.Conditions, metav1.Condition{
+		Type:               bitcoinv1alpha1.ConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: reorg.Generation,
+	})
+	return r.Status().Update(ctx, reorg)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ReorgReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&bitcoinv1alpha1.Reorg{}).
+		Watches(&source.Kind{Type: &bitcoinv1alpha1.BitcoinNode{}}, handler.EnqueueRequestsFromMapFunc(r.reorgsForBitcoinNode)).
+		Complete(r)
+}
+
+// reorgsForBitcoinNode maps a BitcoinNode to the Reorgs that wait for it, as the reorganized or an isolated node
+func (r *ReorgReconciler) reorgsForBitcoinNode(node client.Object) []reconcile.Request {
+	reorgs := &bitcoinv1alpha1.ReorgList{}
+	err := r.List(context.Background(), reorgs, client.InNamespace(node.GetNamespace()))
+	if err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, reorg := range reorgs.Items {
+		if reorg.Status.StartTime != nil {
+			continue
+		}
+		names := append([]string{reorg.Spec.BitcoinNodeName}, reorg.Spec.IsolatedNodes...)
+		for _, name := range names {
+			if name == node.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: reorg.Name, Namespace: reorg.Namespace}})
+				break
+			}
+		}
+	}
+	return requests
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+var _ = Describe("Reorg controller", func() {
+
+	const Namespace = "test-reorg-namespace"
+	const BitcoinNodeName = "forked"
+	const IsolatedNodeName = "isolated"
+	const RewardSecretName = "forked-reward"
+	const ReorgName = "test-reorg"
+
+	ctx := context.Background()
+	reorgNamespacedName := types.NamespacedName{Namespace: Namespace, Name: ReorgName}
+
+	var node *fakeBitcoinRPC
+
+	reconcileReorg := func() {
+		reorgReconciler := ReorgReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+			Dialer: node.dial,
+		}
+		_, err := reorgReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: reorgNamespacedName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+	}
+
+	createBitcoinNode := func(name string, implementation string, network string, peer string) *bitcoinv1alpha1.BitcoinNode {
+		By("creating the BitcoinNode " + name + " and its RPC secrets")
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-rpc-tls", Namespace: Namespace},
+			StringData: map[string]string{"ca.crt": "", "tls.crt": "", "tls.key": ""},
+		})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-rpc-creds", Namespace: Namespace},
+			StringData: map[string]string{"username": "kiln", "password": "secret"},
+		})).To(Succeed())
+		bitcoinNode := &bitcoinv1alpha1.BitcoinNode{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: Namespace},
+			Spec: bitcoinv1alpha1.BitcoinNodeSpec{
+				Implementation: implementation,
+				Network:        network,
+				Peer:           peer,
+				Mining: bitcoinv1alpha1.Mining{
+					RewardAddress: bitcoinv1alpha1.RewardAddress{SecretName: RewardSecretName, SecretKey: "np2wkhAddress"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, bitcoinNode)).To(Succeed())
+		return bitcoinNode
+	}
+
+	createReorg := func(spec bitcoinv1alpha1.ReorgSpec) {
+		By("creating the custom resource for the kind Reorg")
+		spec.BitcoinNodeName = BitcoinNodeName
+		Expect(k8sClient.Create(ctx, &bitcoinv1alpha1.Reorg{
+			ObjectMeta: metav1.ObjectMeta{Name: ReorgName, Namespace: Namespace},
+			Spec:       spec,
+		})).To(Succeed())
+	}
+
+	readyCondition := func() *metav1.Condition {
+		found := &bitcoinv1alpha1.Reorg{}
+		Expect(k8sClient.Get(ctx, reorgNamespacedName, found)).To(Succeed())
+		return meta.FindStatusCondition(found.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+	}
+
+	BeforeEach(func() {
+		By("creating namespace to perform the tests")
+		_ = k8sClient.Create(ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      Namespace,
+				Namespace: Namespace,
+			},
+		})
+
+		By("creating the reward address secret")
+		address, err := btcutil.NewAddressWitnessPubKeyHash(make([]byte, 20), &chaincfg.RegressionNetParams)
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: RewardSecretName, Namespace: Namespace},
+			StringData: map[string]string{"np2wkhAddress": address.EncodeAddress()},
+		})).To(Succeed())
+
+		node = newFakeBitcoinRPC(20)
+		node.chain = "regtest"
+	})
+
+	AfterEach(func() {
+		By("cleaning up the Reorg, the BitcoinNodes and their secrets and pods")
+		objects := []client.Object{
+			&bitcoinv1alpha1.Reorg{ObjectMeta: metav1.ObjectMeta{Name: ReorgName, Namespace: Namespace}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: RewardSecretName, Namespace: Namespace}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: IsolatedNodeName + "-0", Namespace: Namespace}},
+		}
+		for _, name := range []string{BitcoinNodeName, IsolatedNodeName} {
+			objects = append(objects,
+				&bitcoinv1alpha1.BitcoinNode{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: Namespace}},
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name + "-rpc-tls", Namespace: Namespace}},
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name + "-rpc-creds", Namespace: Namespace}},
+			)
+		}
+		for _, object := range objects {
+			_ = k8sClient.Delete(ctx, object, client.GracePeriodSeconds(0))
+		}
+	})
+
+	It("replacing the tip of a bitcoind node with competing blocks once", func() {
+		createBitcoinNode(BitcoinNodeName, bitcoinv1alpha1.ImplementationBitcoind, "regtest", "")
+		oldTip := node.bestBlockHash()
+		forkHash, err := node.GetBlockHash(18)
+		Expect(err).To(Not(HaveOccurred()))
+
+		createReorg(bitcoinv1alpha1.ReorgSpec{Depth: 3, Blocks: 4})
+
+		By("reconciling the Reorg")
+		reconcileReorg()
+
+		By("checking that the blocks were invalidated and replaced")
+		Expect(node.invalidated).To(Equal([]string{forkHash.String()}))
+		Expect(node.height()).To(Equal(int64(21)))
+		Expect(node.minedTo).To(HaveLen(4))
+
+		found := &bitcoinv1alpha1.Reorg{}
+		Expect(k8sClient.Get(ctx, reorgNamespacedName, found)).To(Succeed())
+		Expect(found.Status.OldTip).To(Equal(oldTip))
+		Expect(found.Status.OldHeight).To(Equal(int64(20)))
+		Expect(found.Status.NewTip).To(Equal(node.bestBlockHash()))
+		Expect(found.Status.NewTip).To(Not(Equal(oldTip)))
+		Expect(found.Status.NewHeight).To(Equal(int64(21)))
+		Expect(found.Status.Depth).To(Equal(int64(3)))
+		Expect(found.Status.CompletionTime).To(Not(BeNil()))
+		Expect(meta.IsStatusConditionTrue(found.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeTrue())
+
+		By("reconciling the completed Reorg again")
+		reconcileReorg()
+		Expect(node.invalidated).To(HaveLen(1))
+		Expect(node.height()).To(Equal(int64(21)))
+	})
+
+	It("isolating peered nodes before the reorganization", func() {
+		forkedPeer := BitcoinNodeName + "." + Namespace + ".svc.cluster.local:18444"
+		isolatedPeer := IsolatedNodeName + "." + Namespace + ".svc.cluster.local:18444"
+		forked := createBitcoinNode(BitcoinNodeName, bitcoinv1alpha1.ImplementationBitcoind, "regtest", isolatedPeer)
+		isolated := createBitcoinNode(IsolatedNodeName, bitcoinv1alpha1.ImplementationBtcd, "regtest", forkedPeer)
+
+		By("creating the pod of the isolated node")
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: IsolatedNodeName + "-0", Namespace: Namespace, Labels: labelsForBitcoinNode(IsolatedNodeName)},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "btcd", Image: "btcd"}}},
+		}
+		Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+		pod.Status.PodIP = "10.0.0.7"
+		Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+
+		node.peers = []btcjson.GetPeerInfoResult{
+			{ID: 1, Addr: "10.0.0.7:18444"},
+			{ID: 2, Addr: "10.0.0.8:18444"},
+		}
+
+		createReorg(bitcoinv1alpha1.ReorgSpec{Depth: 1, Blocks: 2, IsolatedNodes: []string{IsolatedNodeName}})
+
+		By("reconciling the Reorg")
+		reconcileReorg()
+
+		By("checking that the isolated node was disconnected in both directions")
+		Expect(node.disconnected).To(ConsistOf(isolatedPeer, forkedPeer, "1"))
+		Expect(node.peers).To(ConsistOf(HaveField("ID", int32(2))))
+
+		found := &bitcoinv1alpha1.Reorg{}
+		Expect(k8sClient.Get(ctx, reorgNamespacedName, found)).To(Succeed())
+		Expect(found.Status.DisconnectedPeers).To(Equal([]string{"10.0.0.7:18444"}))
+		Expect(meta.IsStatusConditionTrue(found.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeTrue())
+
+		By("checking that the BitcoinNodes reconnect once the Reorg completed")
+		for _, b := range []*bitcoinv1alpha1.BitcoinNode{forked, isolated} {
+			isolatedNodes, err := isolatedPeerNodes(ctx, k8sClient, b)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(isolatedNodes).To(BeEmpty())
+		}
+
+		By("checking that the BitcoinNodes do not reconnect while a started Reorg did not complete")
+		found.Status.CompletionTime = nil
+		Expect(k8sClient.Status().Update(ctx, found)).To(Succeed())
+		for _, b := range []*bitcoinv1alpha1.BitcoinNode{forked, isolated} {
+			isolatedNodes, err := isolatedPeerNodes(ctx, k8sClient, b)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(isolatedNodes).To(HaveKey(peerNodeName(b.Spec.Peer)))
+		}
+	})
+
+	It("reconnecting the isolated nodes when the reorganization failed", func() {
+		forkedPeer := BitcoinNodeName + "." + Namespace + ".svc.cluster.local:18444"
+		isolatedPeer := IsolatedNodeName + "." + Namespace + ".svc.cluster.local:18444"
+		forked := createBitcoinNode(BitcoinNodeName, bitcoinv1alpha1.ImplementationBitcoind, "regtest", isolatedPeer)
+		isolated := createBitcoinNode(IsolatedNodeName, bitcoinv1alpha1.ImplementationBtcd, "regtest", forkedPeer)
+		node.invalidateErr = fmt.Errorf("block not found")
+
+		createReorg(bitcoinv1alpha1.ReorgSpec{Depth: 1, Blocks: 2, IsolatedNodes: []string{IsolatedNodeName}})
+
+		By("reconciling the Reorg")
+		reconcileReorg()
+
+		By("checking that the Reorg failed after isolating the node")
+		Expect(node.disconnected).To(ConsistOf(isolatedPeer, forkedPeer))
+		Expect(node.invalidated).To(BeEmpty())
+		found := &bitcoinv1alpha1.Reorg{}
+		Expect(k8sClient.Get(ctx, reorgNamespacedName, found)).To(Succeed())
+		Expect(found.Status.CompletionTime).To(Not(BeNil()))
+		ready := meta.FindStatusCondition(found.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal(bitcoinv1alpha1.ReasonReorgFailed))
+
+		By("checking that the BitcoinNodes reconnect")
+		for _, b := range []*bitcoinv1alpha1.BitcoinNode{forked, isolated} {
+			isolatedNodes, err := isolatedPeerNodes(ctx, k8sClient, b)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(isolatedNodes).To(BeEmpty())
+		}
+
+		By("reconciling the failed Reorg again")
+		node.invalidateErr = nil
+		reconcileReorg()
+		Expect(node.invalidated).To(BeEmpty())
+	})
+
+	It("refusing to reorganize a btcd node", func() {
+		createBitcoinNode(BitcoinNodeName, bitcoinv1alpha1.ImplementationBtcd, "simnet", "")
+		createReorg(bitcoinv1alpha1.ReorgSpec{Depth: 1, Blocks: 2})
+
+		By("reconciling the Reorg")
+		reconcileReorg()
+
+		Expect(node.invalidated).To(BeEmpty())
+		ready := readyCondition()
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal(bitcoinv1alpha1.ReasonInvalidSpec))
+	})
+
+	It("refusing to invalidate the genesis block", func() {
+		node = newFakeBitcoinRPC(3)
+		createBitcoinNode(BitcoinNodeName, bitcoinv1alpha1.ImplementationBitcoind, "regtest", "")
+		createReorg(bitcoinv1alpha1.ReorgSpec{Depth: 3, Blocks: 4})
+
+		By("reconciling the Reorg")
+		reconcileReorg()
+
+		Expect(node.invalidated).To(BeEmpty())
+		Expect(node.height()).To(Equal(int64(3)))
+		ready := readyCondition()
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal(bitcoinv1alpha1.ReasonInvalidSpec))
+	})
+})
@@ -0,0 +1,376 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// seedBackupVersion is the version of the backup format, increased on incompatible changes
+const seedBackupVersion = 1
+
+// backupVolumeMountPath is where backup Jobs mount the PersistentVolumeClaim
+const backupVolumeMountPath = "/backup"
+
+// seedBackupKeys are the keys of the stored key material that are backed up, everything else is derived from them
+var seedBackupKeys = []string{"mnemonic", "passphrase", "rootkey"}
+
+// seedBackup is the plaintext of an encrypted Seed backup
+type seedBackup struct {
+	Version          int                      `json:"version"`
+	Name             string                   `json:"name"`
+	Spec             bitcoinv1alpha1.SeedSpec `json:"spec"`
+	Fingerprint      string                   `json:"fingerprint"`
+	Birthday         *metav1.Time             `json:"birthday,omitempty"`
+	Data             map[string]string        `json:"data"`
+	PreviousVersions []archivedSeedBackup     `json:"previousVersions,omitempty"`
+}
+
+// archivedSeedBackup is the key material of a version of the Seed that was retired by a rotation
+type archivedSeedBackup struct {
+	Version     int32             `json:"version"`
+	Fingerprint string            `json:"fingerprint"`
+	Data        map[string]string `json:"data"`
+}
+
+// newSeedBackup returns the backup of the stored key material of a Seed and its retired versions. Supplied key material
+// is recorded as part of the stored key material, so the restored Seed does not depend on the secrets or plaintext
+// values of its spec.
+func newSeedBackup(s *bitcoinv1alpha1.Seed, stored *StoredSeed, previous []archivedSeedBackup) *seedBackup {
+	spec := *s.Spec.DeepCopy()
+	spec.Mnemonic = ""
+	spec.MnemonicSecretRef = bitcoinv1alpha1.MnemonicSecretRef{}
+	spec.Passphrase = ""
+	spec.PassphraseSecretRef = bitcoinv1alpha1.PassphraseSecretRef{}
+
+	data := map[string]string{}
+	for _, key := range seedBackupKeys {
+		data[key] = stored.Data[key]
+	}
+
+	return &seedBackup{
+		Version:          seedBackupVersion,
+		Name:             s.Name,
+		Spec:             spec,
+		Fingerprint:      s.Status.Fingerprint,
+		Birthday:         s.Status.Birthday,
+		Data:             data,
+		PreviousVersions: previous,
+	}
+}
+
+// archivedSeedBackups reads the stored key material of the versions of a Seed retired by rotations. Versions that
+// are no longer stored, or no longer hold the recorded fingerprint, cannot be backed up and are left out.
+func archivedSeedBackups(ctx context.Context, store SeedStore, s *bitcoinv1alpha1.Seed) ([]archivedSeedBackup, error) {
+	log := ctrllog.FromContext(ctx)
+	var archived []archivedSeedBackup
+
+	for _, previous := range s.Status.PreviousVersions {
+		versioned := seedVersion(s, previous.Version)
+		stored, err := store.Get(ctx, versioned)
+		if isSeedNotStored(err) {
+			log.Info("Previous version of the Seed is no longer stored", "Location", store.Describe(versioned))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		fingerprint, err := storedFingerprint(stored)
+		if err != nil || fingerprint != previous.Fingerprint {
+			log.Info("Previous version of the Seed does not hold its recorded key material", "Location", store.Describe(versioned))
+			continue
+		}
+
+		data := map[string]string{}
+		for _, key := range seedBackupKeys {
+			data[key] = stored.Data[key]
+		}
+		archived = append(archived, archivedSeedBackup{
+			Version:     previous.Version,
+			Fingerprint: previous.Fingerprint,
+			Data:        data,
+		})
+	}
+
+	return archived, nil
+}
+
+// encryptSeedBackup encrypts a backup to the recipient and returns it in the ASCII armored age format
+func encryptSeedBackup(backup *seedBackup, recipient age.Recipient) (string, error) {
+	plaintext, err := json.Marshal(backup)
+	if err != nil {
+		return "", err
+	}
+
+	out := &bytes.Buffer{}
+	armored := armor.NewWriter(out)
+	w, err := age.Encrypt(armored, recipient)
+	if err != nil {
+		return "", err
+	}
+	if _, err = w.Write(plaintext); err != nil {
+		return "", err
+	}
+	if err = w.Close(); err != nil {
+		return "", err
+	}
+	if err = armored.Close(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// decryptSeedBackup decrypts an ASCII armored backup with the identity
+func decryptSeedBackup(encrypted string, identity age.Identity) (*seedBackup, error) {
+	r, err := age.Decrypt(armor.NewReader(strings.NewReader(strings.TrimSpace(encrypted))), identity)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	backup := &seedBackup{}
+	err = json.Unmarshal(plaintext, backup)
+	if err != nil {
+		return nil, err
+	}
+	if backup.Version != seedBackupVersion {
+		return nil, fmt.Errorf("unsupported backup version %d", backup.Version)
+	}
+	return backup, nil
+}
+
+// verifySeedBackup decodes the backed up mnemonic and checks that it derives the backed up root key, that the root
+// key has the recorded fingerprint and that the aezeed birthday matches the recorded birthday
+func verifySeedBackup(backup *seedBackup, network bitcoinv1alpha1.BitcoinNetwork) (*seedMaterial, *hdkeychain.ExtendedKey, error) {
+	if backup.Data["mnemonic"] == "" {
+		return nil, nil, errors.New("backup does not contain a mnemonic")
+	}
+
+	material, err := newSeedMaterial(NewSecretGenerator(), time.Now(), backup.Spec.Format, backup.Data["mnemonic"], backup.Data["passphrase"], backup.Spec.WordCount)
+	if err != nil {
+		return nil, nil, fmt.Errorf("backed up mnemonic cannot be decoded: %w", err)
+	}
+
+	hdkey, err := hdkeychain.NewMaster(material.seed, network.Params)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hdkey.String() != backup.Data["rootkey"] {
+		return nil, nil, errors.New("backed up mnemonic does not derive the backed up rootkey")
+	}
+
+	fingerprint, err := rootKeyFingerprint(backup.Data["rootkey"])
+	if err != nil {
+		return nil, nil, err
+	}
+	if fingerprint != backup.Fingerprint {
+		return nil, nil, fmt.Errorf("backed up rootkey has fingerprint %s but the backup records %s", fingerprint, backup.Fingerprint)
+	}
+
+	if material.cipherSeed != nil && backup.Birthday != nil && material.cipherSeed.BirthdayTime().Unix() != backup.Birthday.Unix() {
+		return nil, nil, fmt.Errorf("backed up mnemonic has birthday %s but the backup records %s", material.cipherSeed.BirthdayTime().UTC().Format(time.RFC3339), backup.Birthday.UTC().Format(time.RFC3339))
+	}
+
+	return material, hdkey, nil
+}
+
+// backupRecipient returns the age recipient configured by the encryption section of a SeedBackup
+func backupRecipient(ctx context.Context, c client.Reader, namespace string, encryption bitcoinv1alpha1.BackupEncryption) (age.Recipient, error) {
+	if encryption.Recipient != "" {
+		return age.ParseX25519Recipient(encryption.Recipient)
+	}
+
+	ref := encryption.PassphraseSecretRef
+	passphrase, err := secretValue(ctx, c, namespace, ref.SecretName, secretKeyOrDefault(ref.SecretKey, "passphrase"))
+	if err != nil {
+		return nil, err
+	}
+	return age.NewScryptRecipient(passphrase)
+}
+
+// backupIdentity returns the age identity configured by the decryption section of a SeedRestore
+func backupIdentity(ctx context.Context, c client.Reader, namespace string, decryption bitcoinv1alpha1.BackupDecryption) (age.Identity, error) {
+	if ref := decryption.IdentitySecretRef; ref.SecretName != "" {
+		identity, err := secretValue(ctx, c, namespace, ref.SecretName, secretKeyOrDefault(ref.SecretKey, "identity"))
+		if err != nil {
+			return nil, err
+		}
+		return age.ParseX25519Identity(identity)
+	}
+
+	ref := decryption.PassphraseSecretRef
+	passphrase, err := secretValue(ctx, c, namespace, ref.SecretName, secretKeyOrDefault(ref.SecretKey, "passphrase"))
+	if err != nil {
+		return nil, err
+	}
+	return age.NewScryptIdentity(passphrase)
+}
+
+func secretKeyOrDefault(key string, defaultKey string) string {
+	if key == "" {
+		return defaultKey
+	}
+	return key
+}
+
+// validateSeedBackupSpec checks a SeedBackup spec and the Seed it backs up
+func validateSeedBackupSpec(spec bitcoinv1alpha1.SeedBackupSpec, s *bitcoinv1alpha1.Seed) error {
+	if s.Spec.Sharding != nil {
+		return errors.New("sharded seeds are backed up by their shares")
+	}
+	if (spec.Encryption.Recipient == "") == (spec.Encryption.PassphraseSecretRef.SecretName == "") {
+		return errors.New("encryption requires either a recipient or a passphraseSecretRef")
+	}
+	return validateBackupLocation(spec.Destination)
+}
+
+// validateSeedRestoreSpec checks a SeedRestore spec for problems that can be detected without reading the backup
+func validateSeedRestoreSpec(spec bitcoinv1alpha1.SeedRestoreSpec) error {
+	if (spec.Decryption.IdentitySecretRef.SecretName == "") == (spec.Decryption.PassphraseSecretRef.SecretName == "") {
+		return errors.New("decryption requires either an identitySecretRef or a passphraseSecretRef")
+	}
+	return validateBackupLocation(spec.Source)
+}
+
+// validateBackupLocation checks that exactly one destination or source is configured
+func validateBackupLocation(location bitcoinv1alpha1.BackupLocation) error {
+	if (location.ConfigMap == nil) == (location.PersistentVolumeClaim == nil) {
+		return errors.New("backups are stored in either a configMap or a persistentVolumeClaim")
+	}
+	return nil
+}
+
+// backupFileName returns the ConfigMap key or file path of a backup, <seed name>.age unless configured
+func backupFileName(location bitcoinv1alpha1.BackupLocation, seedName string) string {
+	if location.ConfigMap != nil && location.ConfigMap.Key != "" {
+		return location.ConfigMap.Key
+	}
+	if location.PersistentVolumeClaim != nil && location.PersistentVolumeClaim.Path != "" {
+		return strings.TrimPrefix(path.Clean("/"+location.PersistentVolumeClaim.Path), "/")
+	}
+	return seedName + ".age"
+}
+
+// versionedBackupFileName returns the ConfigMap key or file path of the backup of a version of the key material. The
+// first version uses the configured file name, later versions insert -v<version> before its extension, so a rotation
+// never overwrites the backup of the retired key material.
+func versionedBackupFileName(fileName string, version int32) string {
+	if version <= 1 {
+		return fileName
+	}
+	ext := path.Ext(fileName)
+	return versionedSecretName(strings.TrimSuffix(fileName, ext), version) + ext
+}
+
+// describeBackupLocation returns a human readable location of a backup for conditions, logs and the status
+func describeBackupLocation(location bitcoinv1alpha1.BackupLocation, fileName string) string {
+	if location.ConfigMap != nil {
+		return "configmap " + location.ConfigMap.Name + " key " + fileName
+	}
+	return "persistentvolumeclaim " + location.PersistentVolumeClaim.ClaimName + " path " + fileName
+}
+
+// backupJob returns a Job that runs the script with the backup PersistentVolumeClaim mounted and the path of the
+// backup file in the BACKUP_FILE environment variable
+func backupJob(name string, namespace string, labels map[string]string, location bitcoinv1alpha1.BackupLocation, fileName string, readOnly bool, script string, env ...corev1.EnvVar) *batchv1.Job {
+	backoffLimit := int32(3)
+	pvc := location.PersistentVolumeClaim
+
+	env = append([]corev1.EnvVar{{
+		Name:  "BACKUP_FILE",
+		Value: path.Join(backupVolumeMountPath, fileName),
+	}}, env...)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{{
+						Name:    "backup",
+						Image:   pvc.Image,
+						Command: []string{"sh", "-c", script},
+						Env:     env,
+						VolumeMounts: []corev1.VolumeMount{{
+							Name:      "backup",
+							MountPath: backupVolumeMountPath,
+							ReadOnly:  readOnly,
+						}},
+					}},
+					Volumes: []corev1.Volume{{
+						Name: "backup",
+						VolumeSource: corev1.VolumeSource{
+							PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+								ClaimName: pvc.ClaimName,
+								ReadOnly:  readOnly,
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// jobFinished reports whether a Job completed and whether it succeeded
+func jobFinished(job *batchv1.Job) (bool, bool) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, true
+		case batchv1.JobFailed:
+			return true, false
+		}
+	}
+	return false, false
+}
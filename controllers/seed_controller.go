@@ -2,14 +2,15 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
-	"github.com/btcsuite/btcd/chaincfg"
-	"github.com/lightningnetwork/lnd/aezeed"
+	"reflect"
 	"strings"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -24,11 +25,15 @@ import (
 type SeedReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// Generator creates the entropy and passphrases of generated Seeds, crypto/rand when nil
+	Generator SecretGenerator
 }
 
 //+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=seeds,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=seeds/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=seeds/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=secrets;configmaps,verbs=get;list;watch;create;update;patch;delete
 
 func (r *SeedReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := ctrllog.FromContext(ctx)
@@ -44,102 +49,458 @@ func (r *SeedReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		return ctrl.Result{}, err
 	}
 
-	mnemonicStr := seed.Spec.Mnemonic
-	passphraseStr := seed.Spec.Passphrase
-	mnemonic := aezeed.Mnemonic{}
+	if !seed.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalizeSeed(ctx, seed)
+	}
+
+	err = r.reconcileFinalizer(ctx, seed)
+
+	if err != nil {
+		log.Error(err, "Failed to update Seed finalizers")
+		return ctrl.Result{}, err
+	}
+
+	network, err := bitcoinv1alpha1.LookupNetwork(seed.Spec.Network)
+
+	if err != nil {
+		log.Error(err, "Failed to look up network")
+		return ctrl.Result{}, r.updateReadyCondition(ctx, seed, metav1.ConditionFalse, bitcoinv1alpha1.ReasonUnsupportedNetwork, err.Error())
+	}
+
+	err = bitcoinv1alpha1.ValidateSeedSpec(seed.Spec, network)
+
+	if err != nil {
+		log.Error(err, "Invalid Seed spec")
+		return ctrl.Result{}, r.updateReadyCondition(ctx, seed, metav1.ConditionFalse, bitcoinv1alpha1.ReasonInvalidSpec, err.Error())
+	}
+
+	mnemonicStr, passphraseStr, err := r.materialFromSpec(ctx, seed)
+
+	if err != nil {
+		log.Error(err, "Failed to read seed material")
+		if statusErr := r.updateReadyCondition(ctx, seed, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSecretRefUnavailable, err.Error()); statusErr != nil {
+			log.Error(statusErr, "Failed to update Seed status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	store, err := storeForSeed(ctx, r.Client, r.Scheme, seed)
+
+	if err != nil {
+		log.Error(err, "Failed to configure Seed storage")
+		if statusErr := r.updateReadyCondition(ctx, seed, metav1.ConditionFalse, bitcoinv1alpha1.ReasonStorageUnavailable, err.Error()); statusErr != nil {
+			log.Error(statusErr, "Failed to update Seed status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	//Reconcile Secret
+	stored, err := store.Get(ctx, seed)
+	secretExists := err == nil
+
+	if err != nil && !isSeedNotStored(err) {
+		log.Error(err, "Failed to get stored Seed", "Location", store.Describe(seed))
+		if statusErr := r.updateReadyCondition(ctx, seed, metav1.ConditionFalse, bitcoinv1alpha1.ReasonStorageUnavailable, err.Error()); statusErr != nil {
+			log.Error(statusErr, "Failed to update Seed status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Generated material only exists in the secret or its shares and must never be generated again once it was recorded,
+	// except for deterministic seeds that derive the same material again, which the fingerprint check confirms
+	generated := mnemonicStr == ""
+	recovered := false
+
+	if generated && secretExists && stored.Owned && rotationPending(seed) {
+		return r.rotateSeed(ctx, seed, store, stored, passphraseStr, network)
+	}
 
-	if mnemonicStr == "" {
-		cipherSeed, err := aezeed.New(0, nil, time.Now())
+	var shares *seedShares
+	if generated && seed.Spec.Sharding != nil {
+		shares, err = r.readShares(ctx, seed)
 		if err != nil {
-			log.Error(err, "Failed to generate a random seed")
+			log.Error(err, "Failed to read share Secrets")
 			return ctrl.Result{}, err
 		}
 
+		// A split that was interrupted before the Seed recorded its key material anywhere is discarded and split again
+		if len(shares.found) > 0 && len(shares.found) < shares.threshold && !secretExists && seed.Status.Fingerprint == "" {
+			log.Info("Discarding incomplete shares of an interrupted split", "Found", len(shares.found), "Threshold", shares.threshold)
+			err = r.deleteShares(ctx, foundShareTargets(shares))
+			if err != nil {
+				log.Error(err, "Failed to delete incomplete share Secrets")
+				return ctrl.Result{}, err
+			}
+			shares.found = map[byte][]byte{}
+			shares.fingerprint = ""
+		}
+
+		if len(shares.found) > 0 {
+			mnemonicStr, passphraseStr, err = shares.combine()
+			if err != nil {
+				log.Error(err, "Failed to recover Seed from shares")
+				seed.Status.Shares = shareStatus(shares)
+				return ctrl.Result{}, r.updateReadyCondition(ctx, seed, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSharesUnavailable, err.Error())
+			}
+			recovered = true
+		}
+	}
+
+	if generated && !recovered && secretExists && !stored.Owned && !retainedFor(seed, stored) {
+		message := store.Describe(seed) + " exists and was not retained for this Seed"
+		log.Info("Refusing to take over stored Seed", "Location", store.Describe(seed))
+		return ctrl.Result{}, r.updateReadyCondition(ctx, seed, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSecretConflict, message)
+	}
+
+	if generated && !recovered && secretExists {
+		mnemonicStr = strings.TrimSpace(stored.Data["mnemonic"])
 		if passphraseStr == "" {
-			passphraseStr = randSeq(32)
+			passphraseStr = stored.Data["passphrase"]
+		}
+
+		if mnemonicStr == "" {
+			return ctrl.Result{}, r.flagUnrecoverableSecret(ctx, seed, store.Describe(seed)+" no longer contains the generated mnemonic")
+		}
+		recovered = true
+	} else if generated && !recovered && seed.Status.Fingerprint != "" && seed.Spec.Deterministic == nil {
+		return ctrl.Result{}, r.flagUnrecoverableSecret(ctx, seed, store.Describe(seed)+" with the generated mnemonic no longer exists")
+	}
+
+	generator, birthday := r.entropySource(seed)
+	material, err := newSeedMaterial(generator, birthday, seed.Spec.Format, mnemonicStr, passphraseStr, seed.Spec.WordCount)
+
+	if err != nil && recovered {
+		log.Error(err, "Failed to decode generated mnemonic")
+		return ctrl.Result{}, r.flagUnrecoverableSecret(ctx, seed, "generated mnemonic of Seed "+seed.Name+" cannot be decoded: "+err.Error())
+	} else if err != nil {
+		log.Error(err, "Failed to initialize mnemonic")
+		return ctrl.Result{}, r.updateReadyCondition(ctx, seed, metav1.ConditionFalse, bitcoinv1alpha1.ReasonInvalidMnemonic, err.Error())
+	}
+
+	hdkey, err := hdkeychain.NewMaster(material.seed, network.Params)
+
+	if err != nil {
+		log.Error(err, "Failed to get Seed")
+		return ctrl.Result{}, err
+	}
+
+	addresses, err := deriveAddresses(hdkey, seed.Spec.Addresses, network.Params)
+
+	if err != nil {
+		log.Error(err, "Failed to derive addresses")
+		return ctrl.Result{}, err
+	}
+
+	fingerprint, err := masterFingerprint(hdkey)
+
+	if err != nil {
+		log.Error(err, "Failed to compute master key fingerprint")
+		return ctrl.Result{}, err
+	}
+
+	if shares != nil {
+		if shares.fingerprint != "" && shares.fingerprint != fingerprint {
+			return ctrl.Result{}, r.flagUnrecoverableSecret(ctx, seed, "shares recorded fingerprint "+shares.fingerprint+" but recovered "+fingerprint)
+		}
+
+		// Shares are written before the Seed secret, which no longer holds the mnemonic once they exist
+		err = r.reconcileShares(ctx, seed, material, fingerprint, shares)
+		if err != nil {
+			log.Error(err, "Failed to reconcile share Secrets")
+			return ctrl.Result{}, err
+		}
+	}
+
+	expected, err := storedSeedFor(seed, material, hdkey, addresses)
+
+	if err != nil {
+		log.Error(err, "Failed to compute stored Seed")
+		return ctrl.Result{}, err
+	}
+
+	if !secretExists {
+		log.Info("Storing a new Seed", "Location", store.Describe(seed))
+		err = store.Create(ctx, seed, expected)
+		if err != nil {
+			log.Error(err, "Failed to store new Seed", "Location", store.Describe(seed))
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	if !stored.Owned {
+		if stored.Annotations[bitcoinv1alpha1.RetainedFingerprintAnnotation] != fingerprint {
+			message := store.Describe(seed) + " exists and does not belong to this Seed"
+			log.Info("Refusing to take over stored Seed", "Location", store.Describe(seed))
+			return ctrl.Result{}, r.updateReadyCondition(ctx, seed, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSecretConflict, message)
 		}
 
-		mnemonic, err = cipherSeed.ToMnemonic([]byte(passphraseStr))
+		log.Info("Adopting retained Seed", "Location", store.Describe(seed), "Fingerprint", fingerprint)
+		delete(stored.Annotations, bitcoinv1alpha1.RetainedFingerprintAnnotation)
+		delete(stored.Annotations, bitcoinv1alpha1.RetainedSeedAnnotation)
+		err = store.Update(ctx, seed, stored)
 		if err != nil {
-			log.Error(err, "Failed to generate a random seed")
+			log.Error(err, "Failed to adopt stored Seed", "Location", store.Describe(seed))
 			return ctrl.Result{}, err
 		}
+	}
+
+	if generated && seed.Status.Fingerprint != "" && seed.Status.Fingerprint != fingerprint {
+		return ctrl.Result{}, r.flagUnrecoverableSecret(ctx, seed, "generated mnemonic in "+store.Describe(seed)+" was replaced, expected fingerprint "+seed.Status.Fingerprint+" but found "+fingerprint)
+	}
+
+	drifted := secretDrift(expected, stored)
 
+	if len(drifted) > 0 && seed.Spec.DriftPolicy == bitcoinv1alpha1.DriftPolicyFlag {
+		message := store.Describe(seed) + " differs from the Seed in " + strings.Join(drifted, ", ")
+		log.Info("Stored Seed has drifted", "Location", store.Describe(seed), "Keys", drifted)
+		setSecretTamperedCondition(seed, metav1.ConditionTrue, bitcoinv1alpha1.ReasonSecretDrifted, message)
+		return ctrl.Result{}, r.updateReadyCondition(ctx, seed, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSecretDrifted, message)
+	} else if len(drifted) > 0 {
+		log.Info("Restoring drifted stored Seed", "Location", store.Describe(seed), "Keys", drifted)
+		restoreSecret(expected, stored)
+		err = store.Update(ctx, seed, stored)
+		if err != nil {
+			log.Error(err, "Failed to restore stored Seed", "Location", store.Describe(seed))
+			return ctrl.Result{}, err
+		}
+		setSecretTamperedCondition(seed, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSecretRestored, "restored "+strings.Join(drifted, ", "))
 	} else {
-		mnemonic, err = initializeMnemonic(mnemonicStr)
+		setSecretTamperedCondition(seed, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSecretInSync, "secret matches the Seed")
+	}
+
+	//Reconcile ConfigMap
+	previousConfigMapName := seed.Status.ConfigMapName
+	seed.Status.ConfigMapName = ""
+
+	if len(seed.Spec.Accounts) > 0 {
+		exports, err := exportAccounts(hdkey, seed.Spec.Accounts, network)
+
+		if err != nil {
+			log.Error(err, "Failed to export accounts")
+			return ctrl.Result{}, err
+		}
 
+		configMap := r.configMapForSeed(seed, exports)
+		foundConfigMap := &v1.ConfigMap{}
+		err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, foundConfigMap)
+
+		if err != nil && errors.IsNotFound(err) {
+			log.Info("Creating a new ConfigMap", "ConfigMap.Namespace", configMap.Namespace, "ConfigMap.Name", configMap.Name)
+			err = r.Create(ctx, configMap)
+			if err != nil {
+				log.Error(err, "Failed to create new ConfigMap", "ConfigMap.Namespace", configMap.Namespace, "ConfigMap.Name", configMap.Name)
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true}, nil
+		} else if err != nil {
+			log.Error(err, "Failed to get ConfigMap")
+			return ctrl.Result{}, err
+		}
+
+		if !reflect.DeepEqual(foundConfigMap.Data, configMap.Data) {
+			log.Info("Updating ConfigMap", "ConfigMap.Namespace", foundConfigMap.Namespace, "ConfigMap.Name", foundConfigMap.Name)
+			foundConfigMap.Data = configMap.Data
+			err = r.Update(ctx, foundConfigMap)
+			if err != nil {
+				log.Error(err, "Failed to update ConfigMap", "ConfigMap.Namespace", foundConfigMap.Namespace, "ConfigMap.Name", foundConfigMap.Name)
+				return ctrl.Result{}, err
+			}
+		}
+
+		seed.Status.ConfigMapName = configMap.Name
+	}
+
+	// The ConfigMap published earlier is removed when the accounts are removed or published under another name
+	if previousConfigMapName != "" && previousConfigMapName != seed.Status.ConfigMapName {
+		err = r.deleteConfigMap(ctx, seed, previousConfigMapName)
 		if err != nil {
-			log.Error(err, "Failed to initialize mnemonic")
+			log.Error(err, "Failed to delete ConfigMap", "ConfigMap.Namespace", seed.Namespace, "ConfigMap.Name", previousConfigMapName)
 			return ctrl.Result{}, err
 		}
 	}
 
-	pass := []byte(passphraseStr)
-	cipherSeed, err := mnemonic.ToCipherSeed(pass)
+	seed.Status.Fingerprint = fingerprint
+	seed.Status.SecretName = seed.Spec.SecretName
+	seed.Status.ObservedRotationTrigger = seed.Spec.RotationTrigger
+	seed.Status.Insecure = seed.Spec.Deterministic != nil
+	if seed.Status.Version == 0 {
+		seed.Status.Version = 1
+	}
+	seed.Status.AezeedVersion = nil
+	seed.Status.Birthday = nil
+
+	if material.cipherSeed != nil {
+		version := int32(material.cipherSeed.InternalVersion)
+		birthday := metav1.NewTime(material.cipherSeed.BirthdayTime())
+		seed.Status.AezeedVersion = &version
+		seed.Status.Birthday = &birthday
+	}
 
+	err = r.updateReadyCondition(ctx, seed, metav1.ConditionTrue, bitcoinv1alpha1.ReasonReconciled, "Seed secret is available")
 	if err != nil {
-		log.Error(err, "Failed to generate cipher seed")
+		log.Error(err, "Failed to update Seed status")
 		return ctrl.Result{}, err
 	}
 
-	var networkParams *chaincfg.Params
+	return ctrl.Result{}, nil
+}
+
+// materialFromSpec returns the mnemonic and passphrase supplied by the Seed, either in plaintext or through
+// secret references. Empty values mean the material should be generated.
+func (r *SeedReconciler) materialFromSpec(ctx context.Context, s *bitcoinv1alpha1.Seed) (string, string, error) {
+	mnemonic := s.Spec.Mnemonic
+	passphrase := s.Spec.Passphrase
+
+	if ref := s.Spec.MnemonicSecretRef; ref.SecretName != "" {
+		value, err := secretValue(ctx, r.Client, s.Namespace, ref.SecretName, ref.SecretKey)
+		if err != nil {
+			return "", "", err
+		}
+		mnemonic = value
+	}
+
+	if ref := s.Spec.PassphraseSecretRef; ref.SecretName != "" {
+		value, err := secretValue(ctx, r.Client, s.Namespace, ref.SecretName, ref.SecretKey)
+		if err != nil {
+			return "", "", err
+		}
+		passphrase = value
+	}
+
+	return mnemonic, passphrase, nil
+}
 
-	switch seed.Spec.Network {
-	case "simnet":
-		networkParams = &chaincfg.SimNetParams
-	case "mainnet":
-		networkParams = &chaincfg.MainNetParams
+// generator returns the SecretGenerator of the reconciler, crypto/rand unless a test injected another one
+func (r *SeedReconciler) generator() SecretGenerator {
+	if r.Generator == nil {
+		return NewSecretGenerator()
 	}
+	return r.Generator
+}
 
-	hdkey, err := hdkeychain.NewMaster(cipherSeed.Entropy[:], networkParams)
+// entropySource returns the generator and birthday of new key material for a Seed, which are derived from the
+// salt of a deterministic Seed
+func (r *SeedReconciler) entropySource(s *bitcoinv1alpha1.Seed) (SecretGenerator, time.Time) {
+	if s.Spec.Deterministic != nil {
+		return newDeterministicGenerator(s), deterministicBirthday(s)
+	}
+	return r.generator(), time.Now()
+}
 
+// secretValue reads a single key from a secret
+func secretValue(ctx context.Context, c client.Reader, namespace string, name string, key string) (string, error) {
+	secret := &v1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, secret)
 	if err != nil {
-		log.Error(err, "Failed to get Seed")
-		return ctrl.Result{}, err
+		return "", err
 	}
 
-	//Reconcile Secret
-	foundSecret := &v1.Secret{}
-	err = r.Get(ctx, types.NamespacedName{Name: seed.Spec.SecretName, Namespace: seed.Namespace}, foundSecret)
+	value, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s does not contain key %s", name, key)
+	}
+	return strings.TrimSpace(string(value)), nil
+}
+
+// flagUnrecoverableSecret reports generated key material that was changed or removed. The secret is left untouched
+// because the original material cannot be recovered and must not be replaced with new random material.
+func (r *SeedReconciler) flagUnrecoverableSecret(ctx context.Context, s *bitcoinv1alpha1.Seed, message string) error {
+	ctrllog.FromContext(ctx).Info("Generated key material is unrecoverable", "Secret.Name", s.Spec.SecretName, "Reason", message)
+	setSecretTamperedCondition(s, metav1.ConditionTrue, bitcoinv1alpha1.ReasonSecretUnrecoverable, message)
+	return r.updateReadyCondition(ctx, s, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSecretUnrecoverable, message)
+}
+
+// updateReadyCondition records the Ready condition and the observed generation in the Seed status
+func (r *SeedReconciler) updateReadyCondition(ctx context.Context, s *bitcoinv1alpha1.Seed, status metav1.ConditionStatus, reason string, message string) error {
+	s.Status.ObservedGeneration = s.Generation
+	meta.SetStatusCondition(&s.Status.Conditions, metav1.Condition{
+		Type:               bitcoinv1alpha1.ConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: s.Generation,
+	})
+	return r.Status().Update(ctx, s)
+}
 
-	if err != nil && errors.IsNotFound(err) {
-		secret := r.secretForSeed(seed, mnemonic, passphraseStr, hdkey)
-		log.Info("Creating a new Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
-		err = r.Create(ctx, secret)
+// storedSeedFor returns the key material that should be stored for a Seed. The private key material of a sharded
+// Seed only exists in its shares, so its secret only holds the extended public key and the fingerprint.
+func storedSeedFor(s *bitcoinv1alpha1.Seed, material *seedMaterial, hdkey *hdkeychain.ExtendedKey, addresses map[string]string) (*StoredSeed, error) {
+	stored := &StoredSeed{
+		Data: map[string]string{},
+		Annotations: map[string]string{
+			bitcoinv1alpha1.SeedFormatAnnotation: material.format,
+		},
+	}
+
+	if s.Spec.Sharding != nil {
+		xpub, err := hdkey.Neuter()
 		if err != nil {
-			log.Error(err, "Failed to create new Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
-			return ctrl.Result{}, err
+			return nil, err
 		}
-		return ctrl.Result{Requeue: true}, nil
-	} else if err != nil {
-		log.Error(err, "Failed to get Secret")
-		return ctrl.Result{}, nil
+		fingerprint, err := masterFingerprint(hdkey)
+		if err != nil {
+			return nil, err
+		}
+		stored.Data["xpub"] = xpub.String()
+		stored.Data["fingerprint"] = fingerprint
+		return stored, nil
 	}
 
-	return ctrl.Result{}, nil
+	stored.Data["rootkey"] = hdkey.String()
+	stored.Data["mnemonic"] = strings.Join(material.mnemonic, " ")
+	stored.Data["passphrase"] = material.passphrase
+	for key, address := range addresses {
+		stored.Data[key] = address
+	}
+	return stored, nil
 }
 
-func (r *SeedReconciler) secretForSeed(s *bitcoinv1alpha1.Seed, mnemonic aezeed.Mnemonic, passphrase string, hdkey *hdkeychain.ExtendedKey) *v1.Secret {
+func (r *SeedReconciler) configMapForSeed(s *bitcoinv1alpha1.Seed, exports map[string]string) *v1.ConfigMap {
 	ls := labelsForSeed(s.Name)
 
-	secret := v1.Secret{
+	name := s.Spec.ConfigMapName
+	if name == "" {
+		name = s.Name + "-accounts"
+	}
+
+	configMap := v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Labels:    ls,
-			Name:      s.Spec.SecretName,
+			Name:      name,
 			Namespace: s.Namespace,
 		},
-		StringData: map[string]string{
-			"mnemonic":   strings.Join(mnemonic[:], " "),
-			"passphrase": passphrase,
-			"rootkey":    hdkey.String(),
-		},
+		Data: exports,
 	}
 
-	err := ctrl.SetControllerReference(s, &secret, r.Scheme)
+	err := ctrl.SetControllerReference(s, &configMap, r.Scheme)
 	if err != nil {
 		return nil
 	}
-	return &secret
+	return &configMap
+}
+
+// deleteConfigMap deletes an accounts ConfigMap of a Seed, unless it no longer exists or belongs to something else
+func (r *SeedReconciler) deleteConfigMap(ctx context.Context, s *bitcoinv1alpha1.Seed, name string) error {
+	configMap := &v1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: s.Namespace}, configMap)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if !metav1.IsControlledBy(configMap, s) {
+		return nil
+	}
+
+	ctrllog.FromContext(ctx).Info("Deleting ConfigMap", "ConfigMap.Namespace", configMap.Namespace, "ConfigMap.Name", configMap.Name)
+	err = r.Delete(ctx, configMap)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
 }
 
 func labelsForSeed(name string) map[string]string {
@@ -150,5 +511,7 @@ func labelsForSeed(name string) map[string]string {
 func (r *SeedReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&bitcoinv1alpha1.Seed{}).
+		Owns(&v1.Secret{}).
+		Owns(&v1.ConfigMap{}).
 		Complete(r)
 }
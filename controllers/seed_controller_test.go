@@ -2,13 +2,20 @@ package controllers
 
 import (
 	"context"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/aezeed"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	mathrand "math/rand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,12 +27,30 @@ var _ = Describe("Seed controller", func() {
 	const Namespace = "test-namespace"
 	const SeedName = "test"
 	const SecretName = "seed"
+	const MaterialSecretName = "seed-material"
 	const Mnemonic = "above pioneer library glimpse exhibit analyst monitor holiday boil art ketchup mail hunt since now pattern vacant arch museum tourist brisk come pilot devote"
 	const Passphrase = "test"
 
 	ctx := context.Background()
 	seedNamespaceName := types.NamespacedName{Namespace: Namespace, Name: SeedName}
 	secretNamespacedName := types.NamespacedName{Namespace: Namespace, Name: SecretName}
+	materialSecretNamespacedName := types.NamespacedName{Namespace: Namespace, Name: MaterialSecretName}
+	configMapNamespacedName := types.NamespacedName{Namespace: Namespace, Name: SeedName + "-accounts"}
+
+	deleteSeed := func(namespacedName types.NamespacedName) {
+		By("reconciling the deletion of the Seed")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err := seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: namespacedName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+		Eventually(func() bool {
+			return errors.IsNotFound(k8sClient.Get(ctx, namespacedName, &bitcoinv1alpha1.Seed{}))
+		}, time.Minute, time.Second).Should(BeTrue())
+	}
 
 	BeforeEach(func() {
 		By("creating namespace to perform the tests")
@@ -41,16 +66,35 @@ var _ = Describe("Seed controller", func() {
 		By("cleaning up Seed")
 		seed := &bitcoinv1alpha1.Seed{}
 		err := k8sClient.Get(ctx, seedNamespaceName, seed)
-		Expect(err).To(Not(HaveOccurred()))
-		err = k8sClient.Delete(ctx, seed)
-		Expect(err).To(Not(HaveOccurred()))
+		if err == nil {
+			err = k8sClient.Delete(ctx, seed)
+			Expect(err).To(Not(HaveOccurred()))
+			deleteSeed(seedNamespaceName)
+		}
 
 		By("cleaning up Secret")
 		secret := &v1.Secret{}
 		err = k8sClient.Get(ctx, secretNamespacedName, secret)
-		Expect(err).To(Not(HaveOccurred()))
-		err = k8sClient.Delete(ctx, secret)
-		Expect(err).To(Not(HaveOccurred()))
+		if err == nil {
+			err = k8sClient.Delete(ctx, secret)
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		By("cleaning up ConfigMap")
+		configMap := &v1.ConfigMap{}
+		err = k8sClient.Get(ctx, configMapNamespacedName, configMap)
+		if err == nil {
+			err = k8sClient.Delete(ctx, configMap)
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		By("cleaning up material Secret")
+		materialSecret := &v1.Secret{}
+		err = k8sClient.Get(ctx, materialSecretNamespacedName, materialSecret)
+		if err == nil {
+			err = k8sClient.Delete(ctx, materialSecret)
+			Expect(err).To(Not(HaveOccurred()))
+		}
 	})
 
 	It("reconciling a Seed instance with no mnemonic", func() {
@@ -104,8 +148,265 @@ var _ = Describe("Seed controller", func() {
 
 	})
 
+	It("reconciling a Seed instance with a generated BIP39 mnemonic", func() {
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: SecretName,
+				Format:     bitcoinv1alpha1.SeedFormatBIP39,
+				WordCount:  12,
+				Network:    "simnet",
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err := k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource created")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("checking if a valid BIP39 mnemonic was generated")
+		foundSecret := &v1.Secret{}
+		Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+		Expect(foundSecret.Annotations).To(HaveKeyWithValue(bitcoinv1alpha1.SeedFormatAnnotation, bitcoinv1alpha1.SeedFormatBIP39))
+		Expect(strings.Fields(string(foundSecret.Data["mnemonic"]))).To(HaveLen(12))
+		_, err = bitcoinv1alpha1.ParseBIP39Mnemonic(string(foundSecret.Data["mnemonic"]))
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(foundSecret.Data["rootkey"]).To(Not(BeEmpty()))
+	})
+
+	It("generating a mnemonic from an injected secret generator", func() {
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: SecretName,
+				Format:     bitcoinv1alpha1.SeedFormatBIP39,
+				WordCount:  12,
+				Network:    "simnet",
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err := k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource with a deterministic generator")
+		seedReconciler := SeedReconciler{
+			Client:    k8sClient,
+			Scheme:    k8sClient.Scheme(),
+			Generator: &RandomSecretGenerator{Source: mathrand.New(mathrand.NewSource(1))},
+		}
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("checking if the mnemonic was generated from the injected generator")
+		foundSecret := &v1.Secret{}
+		Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+		Expect(string(foundSecret.Data["mnemonic"])).To(Equal("fancy useful achieve drink chaos pole flight typical auto spread mansion top"))
+	})
+
+	It("reconciling a Seed instance with an imported BIP39 mnemonic", func() {
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: SecretName,
+				Format:     bitcoinv1alpha1.SeedFormatBIP39,
+				Mnemonic:   "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+				Passphrase: "TREZOR",
+				Network:    "simnet",
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err := k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource created")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("checking if the root key matches the BIP39 test vector")
+		foundSecret := &v1.Secret{}
+		Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+		Expect(foundSecret.Annotations).To(HaveKeyWithValue(bitcoinv1alpha1.SeedFormatAnnotation, bitcoinv1alpha1.SeedFormatBIP39))
+		Expect(string(foundSecret.Data["rootkey"])).To(Equal("sprv8Erh3X3hFeKuoD653knTvhJHkiKLxbhym6yyMYfKJ9kPXc3AnztLtmAyv29tc6yQn95qGE6e6TmYRokeKRMdyBXuyXTihmcpwoqJJPtTyAy"))
+	})
+
+	It("rejecting a BIP39 mnemonic with an invalid checksum", func() {
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: SecretName,
+				Format:     bitcoinv1alpha1.SeedFormatBIP39,
+				Mnemonic:   "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+				Network:    "simnet",
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err := k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource created")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("checking if the Ready condition reports the invalid mnemonic")
+		foundSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		condition := meta.FindStatusCondition(foundSeed.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(condition).To(Not(BeNil()))
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(bitcoinv1alpha1.ReasonInvalidMnemonic))
+
+		By("checking that no secret was created")
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, secretNamespacedName, &v1.Secret{}))).To(BeTrue())
+	})
+
+	It("reconciling a Seed instance with receive addresses", func() {
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: SecretName,
+				Mnemonic:   Mnemonic,
+				Passphrase: Passphrase,
+				Network:    "simnet",
+				Addresses: []bitcoinv1alpha1.AddressDerivation{
+					{Type: "np2wkh"},
+					{Type: "p2wkh", Account: 1, Index: 2},
+					{Type: "p2tr"},
+				},
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err := k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource created")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("checking if a secret was successfully created in the reconciliation")
+		foundSecret := &v1.Secret{}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, secretNamespacedName, foundSecret)
+		}, time.Minute, time.Second).Should(Succeed())
+
+		By("checking if the expected addresses were derived from the seed")
+		Expect(string(foundSecret.Data["np2wkhAddress"])).To(Equal("rXTE5MsX6PFomDmW6mcxbsepT789WeN1WE"))
+		Expect(string(foundSecret.Data["np2wkhAddress-0-0"])).To(Equal("rXTE5MsX6PFomDmW6mcxbsepT789WeN1WE"))
+		Expect(string(foundSecret.Data["p2wkhAddress"])).To(Equal("sb1qujnz5gdcch78tsaywrnz06g76hxnn3sdevuwrq"))
+		Expect(string(foundSecret.Data["p2wkhAddress-1-2"])).To(Equal("sb1qujnz5gdcch78tsaywrnz06g76hxnn3sdevuwrq"))
+		Expect(string(foundSecret.Data["p2trAddress"])).To(Equal("sb1pv3hdsl33ahhmzrxedyzevvnws4krjsrpj27xm7kqnhwlyjvhrtuqclxfxh"))
+	})
+
+	It("publishing account extended public keys and descriptors", func() {
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: SecretName,
+				Mnemonic:   Mnemonic,
+				Passphrase: Passphrase,
+				Network:    "simnet",
+				Accounts: []bitcoinv1alpha1.AccountExport{
+					{Purpose: 84},
+				},
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err := k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource until the secret and configmap exist")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		for i := 0; i < 3; i++ {
+			_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: seedNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		By("checking if the account keys and descriptors were published")
+		foundConfigMap := &v1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, configMapNamespacedName, foundConfigMap)).To(Succeed())
+		Expect(foundConfigMap.OwnerReferences).To(HaveLen(1))
+		Expect(foundConfigMap.Data).To(HaveKeyWithValue("fingerprint", "51d9161a"))
+		Expect(foundConfigMap.Data).To(HaveKeyWithValue("bip84-0-xpub", "spub4bJtiSYVqfV4wFEy1bQ8vwVZvPacM9ULDf7HVuLzzUtePG7JRfwaHX8kKiHXFz15xEafhVfLVNeAYwPGfxohdQbLQJXh1udtggmgY65dYMc"))
+		Expect(foundConfigMap.Data).To(HaveKeyWithValue("bip84-0-zpub", "vpub5ZogdSZFHi7WV8pKWfm1ZCn5SJ1xB1Spxzfwr62f8W2HYZYzBeSG9i7APLAHFhxrB9w5wvT1sMhpCoDxq43k27e91Kcxme6MVrxcho3YwPd"))
+		Expect(foundConfigMap.Data).To(HaveKeyWithValue("bip84-0-receive-descriptor", "wpkh([51d9161a/84h/115h/0h]spub4bJtiSYVqfV4wFEy1bQ8vwVZvPacM9ULDf7HVuLzzUtePG7JRfwaHX8kKiHXFz15xEafhVfLVNeAYwPGfxohdQbLQJXh1udtggmgY65dYMc/0/*)#zq00nc5q"))
+		Expect(foundConfigMap.Data).To(HaveKeyWithValue("bip84-0-change-descriptor", "wpkh([51d9161a/84h/115h/0h]spub4bJtiSYVqfV4wFEy1bQ8vwVZvPacM9ULDf7HVuLzzUtePG7JRfwaHX8kKiHXFz15xEafhVfLVNeAYwPGfxohdQbLQJXh1udtggmgY65dYMc/1/*)#n52wwdyc"))
+
+		foundSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		Expect(foundSeed.Status.ConfigMapName).To(Equal(SeedName + "-accounts"))
+
+		By("removing the accounts from the Seed")
+		foundSeed.Spec.Accounts = nil
+		Expect(k8sClient.Update(ctx, foundSeed)).To(Succeed())
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("checking if the published ConfigMap was deleted")
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, configMapNamespacedName, &v1.ConfigMap{}))).To(BeTrue())
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		Expect(foundSeed.Status.ConfigMapName).To(BeEmpty())
+	})
+
 	DescribeTable("reconciling a Seed instance",
-		func(network string, hdkey string) {
+		func(network string, hdkey string, useSecretRefs bool) {
 
 			seed := &bitcoinv1alpha1.Seed{
 				ObjectMeta: metav1.ObjectMeta{
@@ -120,6 +421,26 @@ var _ = Describe("Seed controller", func() {
 				},
 			}
 
+			if useSecretRefs {
+				By("creating a secret that contains the seed material")
+				err := k8sClient.Create(ctx, &v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      MaterialSecretName,
+						Namespace: Namespace,
+					},
+					StringData: map[string]string{
+						"mnemonic":   Mnemonic,
+						"passphrase": Passphrase,
+					},
+				})
+				Expect(err).To(Not(HaveOccurred()))
+
+				seed.Spec.Mnemonic = ""
+				seed.Spec.Passphrase = ""
+				seed.Spec.MnemonicSecretRef = bitcoinv1alpha1.MnemonicSecretRef{SecretName: MaterialSecretName, SecretKey: "mnemonic"}
+				seed.Spec.PassphraseSecretRef = bitcoinv1alpha1.PassphraseSecretRef{SecretName: MaterialSecretName, SecretKey: "passphrase"}
+			}
+
 			By("creating the custom resource for the kind Seed")
 			err := k8sClient.Create(ctx, seed)
 			Expect(err).To(Not(HaveOccurred()))
@@ -162,11 +483,1327 @@ var _ = Describe("Seed controller", func() {
 			"when configuration specifies simnet",
 			"simnet",
 			"sprv8Erh3X3hFeKunHkJdgLsPuartHeq6F7hf7AbztZnBdVxpxt57x4vLpMB5JYhbryt5Ydn28XYEsMbhW4S1gUJpatAyZqCaco9fsvBfheXzE9",
+			false,
+		),
+		Entry(
+			"when configuration specifies simnet with secret references",
+			"simnet",
+			"sprv8Erh3X3hFeKunHkJdgLsPuartHeq6F7hf7AbztZnBdVxpxt57x4vLpMB5JYhbryt5Ydn28XYEsMbhW4S1gUJpatAyZqCaco9fsvBfheXzE9",
+			true,
 		),
 		Entry(
 			"when configuration specifies mainnet",
 			"mainnet",
 			"xprv9s21ZrQH143K2mhtoUGzSM4Nk8P4oM5CEfmhus3D5fPN6TxDPEtjT8dsBLLdbQFV7kDomWWLYB8M7w8FcAYNomJBKGKKWAtb2WEQcXrtiyY",
+			true,
+		),
+		Entry(
+			"when configuration specifies testnet",
+			"testnet",
+			"tprv8ZgxMBicQKsPdawRU38VbzgN4FoH2s7CaDgpnHTfZdsqt4hJNcEUxt1K6WWHbmdoVBkamc86hXi9anfzjNtKcpZmquXdAXcdwbyq4GyZa49",
+			false,
+		),
+		Entry(
+			"when configuration specifies regressionnet",
+			"regressionnet",
+			"tprv8ZgxMBicQKsPdawRU38VbzgN4FoH2s7CaDgpnHTfZdsqt4hJNcEUxt1K6WWHbmdoVBkamc86hXi9anfzjNtKcpZmquXdAXcdwbyq4GyZa49",
+			false,
+		),
+		Entry(
+			"when configuration specifies signet",
+			"signet",
+			"tprv8ZgxMBicQKsPdawRU38VbzgN4FoH2s7CaDgpnHTfZdsqt4hJNcEUxt1K6WWHbmdoVBkamc86hXi9anfzjNtKcpZmquXdAXcdwbyq4GyZa49",
+			false,
 		),
 	)
-})
+
+	It("reporting the Seed status", func() {
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: SecretName,
+				Mnemonic:   Mnemonic,
+				Passphrase: Passphrase,
+				Network:    "simnet",
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err := k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource until the secret exists")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		for i := 0; i < 2; i++ {
+			_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: seedNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		By("checking if the status describes the seed")
+		foundSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		Expect(foundSeed.Status.Fingerprint).To(Equal("51d9161a"))
+		Expect(foundSeed.Status.SecretName).To(Equal(SecretName))
+		Expect(foundSeed.Status.ObservedGeneration).To(Equal(foundSeed.Generation))
+		Expect(foundSeed.Status.AezeedVersion).To(Not(BeNil()))
+		Expect(*foundSeed.Status.AezeedVersion).To(Equal(int32(0)))
+		Expect(foundSeed.Status.Birthday).To(Not(BeNil()))
+		Expect(foundSeed.Status.Birthday.Time.Equal(aezeed.BitcoinGenesisDate)).To(BeTrue())
+
+		condition := meta.FindStatusCondition(foundSeed.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(condition).To(Not(BeNil()))
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(bitcoinv1alpha1.ReasonReconciled))
+	})
+
+	It("reporting a wrong passphrase in the Seed status", func() {
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: SecretName,
+				Mnemonic:   Mnemonic,
+				Passphrase: "wrong",
+				Network:    "simnet",
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err := k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource created")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("checking if the Ready condition reports the invalid mnemonic")
+		foundSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		condition := meta.FindStatusCondition(foundSeed.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(condition).To(Not(BeNil()))
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(bitcoinv1alpha1.ReasonInvalidMnemonic))
+		Expect(condition.Message).To(ContainSubstring("invalid passphrase"))
+		Expect(foundSeed.Status.Fingerprint).To(BeEmpty())
+	})
+
+	DescribeTable("reconciling a Seed secret that drifted",
+		func(driftPolicy string, expectedReason string, expectRestored bool) {
+			seed := &bitcoinv1alpha1.Seed{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      SeedName,
+					Namespace: Namespace,
+				},
+				Spec: bitcoinv1alpha1.SeedSpec{
+					SecretName:  SecretName,
+					Mnemonic:    Mnemonic,
+					Passphrase:  Passphrase,
+					Network:     "simnet",
+					DriftPolicy: driftPolicy,
+				},
+			}
+
+			By("creating the custom resource for the kind Seed")
+			err := k8sClient.Create(ctx, seed)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("reconciling the custom resource until the secret exists")
+			seedReconciler := SeedReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			for i := 0; i < 2; i++ {
+				_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: seedNamespaceName,
+				})
+				Expect(err).To(Not(HaveOccurred()))
+			}
+
+			By("tampering with the secret")
+			foundSecret := &v1.Secret{}
+			Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+			rootKey := string(foundSecret.Data["rootkey"])
+			foundSecret.Data["rootkey"] = []byte("tampered")
+			Expect(k8sClient.Update(ctx, foundSecret)).To(Succeed())
+
+			By("reconciling the custom resource again")
+			_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: seedNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("checking if the drift was handled according to the policy")
+			foundSeed := &bitcoinv1alpha1.Seed{}
+			Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+			condition := meta.FindStatusCondition(foundSeed.Status.Conditions, bitcoinv1alpha1.ConditionSecretTampered)
+			Expect(condition).To(Not(BeNil()))
+			Expect(condition.Reason).To(Equal(expectedReason))
+			Expect(condition.Message).To(ContainSubstring("rootkey"))
+
+			Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+			if expectRestored {
+				Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+				Expect(string(foundSecret.Data["rootkey"])).To(Equal(rootKey))
+			} else {
+				Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+				Expect(string(foundSecret.Data["rootkey"])).To(Equal("tampered"))
+				Expect(meta.IsStatusConditionFalse(foundSeed.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeTrue())
+			}
+		},
+		Entry(
+			"when the drift policy is Restore",
+			bitcoinv1alpha1.DriftPolicyRestore,
+			bitcoinv1alpha1.ReasonSecretRestored,
+			true,
+		),
+		Entry(
+			"when the drift policy is Flag",
+			bitcoinv1alpha1.DriftPolicyFlag,
+			bitcoinv1alpha1.ReasonSecretDrifted,
+			false,
+		),
+	)
+
+	It("never regenerating a generated mnemonic over an existing secret", func() {
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: SecretName,
+				Network:    "simnet",
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err := k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource until the secret exists")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		for i := 0; i < 2; i++ {
+			_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: seedNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		By("checking if the generated mnemonic is stable across reconciliations")
+		foundSecret := &v1.Secret{}
+		Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+		mnemonic := string(foundSecret.Data["mnemonic"])
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+		Expect(string(foundSecret.Data["mnemonic"])).To(Equal(mnemonic))
+
+		By("truncating the generated mnemonic")
+		foundSecret.Data["mnemonic"] = []byte{}
+		Expect(k8sClient.Update(ctx, foundSecret)).To(Succeed())
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("checking if the secret was flagged instead of regenerated")
+		foundSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		condition := meta.FindStatusCondition(foundSeed.Status.Conditions, bitcoinv1alpha1.ConditionSecretTampered)
+		Expect(condition).To(Not(BeNil()))
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(bitcoinv1alpha1.ReasonSecretUnrecoverable))
+		Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+		Expect(foundSecret.Data["mnemonic"]).To(BeEmpty())
+	})
+
+	It("deriving the same mnemonic again for a deterministic Seed", func() {
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName:    SecretName,
+				Network:       "regtest",
+				Deterministic: &bitcoinv1alpha1.DeterministicSeed{Salt: "fixture"},
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err := k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource until the secret exists")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		for i := 0; i < 2; i++ {
+			_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: seedNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		By("checking if the Seed is reported as insecure with the derived birthday")
+		foundSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		Expect(foundSeed.Status.Insecure).To(BeTrue())
+		Expect(foundSeed.Status.Birthday).To(Not(BeNil()))
+		Expect(foundSeed.Status.Birthday.Unix()).To(Equal(deterministicBirthday(seed).Unix()))
+		fingerprint := foundSeed.Status.Fingerprint
+
+		foundSecret := &v1.Secret{}
+		Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+		mnemonic := string(foundSecret.Data["mnemonic"])
+		Expect(mnemonic).To(Not(BeEmpty()))
+
+		By("deleting the secret and reconciling again")
+		Expect(k8sClient.Delete(ctx, foundSecret)).To(Succeed())
+		for i := 0; i < 2; i++ {
+			_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: seedNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		By("checking if the same mnemonic was derived again")
+		Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+		Expect(string(foundSecret.Data["mnemonic"])).To(Equal(mnemonic))
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		Expect(foundSeed.Status.Fingerprint).To(Equal(fingerprint))
+		Expect(meta.IsStatusConditionTrue(foundSeed.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeTrue())
+	})
+
+	DescribeTable("deleting a Seed instance",
+		func(deletionPolicy string, expectOwned bool, expectAnnotation bool) {
+			seed := &bitcoinv1alpha1.Seed{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      SeedName,
+					Namespace: Namespace,
+				},
+				Spec: bitcoinv1alpha1.SeedSpec{
+					SecretName:     SecretName,
+					Mnemonic:       Mnemonic,
+					Passphrase:     Passphrase,
+					Network:        "simnet",
+					DeletionPolicy: deletionPolicy,
+				},
+			}
+
+			By("creating the custom resource for the kind Seed")
+			err := k8sClient.Create(ctx, seed)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("reconciling the custom resource until the secret exists")
+			seedReconciler := SeedReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			for i := 0; i < 2; i++ {
+				_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: seedNamespaceName,
+				})
+				Expect(err).To(Not(HaveOccurred()))
+			}
+
+			By("deleting the custom resource")
+			Expect(k8sClient.Get(ctx, seedNamespaceName, seed)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, seed)).To(Succeed())
+			deleteSeed(seedNamespaceName)
+
+			By("checking if the secret was released according to the deletion policy")
+			foundSecret := &v1.Secret{}
+			Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+			Expect(metav1.IsControlledBy(foundSecret, seed)).To(Equal(expectOwned))
+			if expectAnnotation {
+				Expect(foundSecret.Annotations).To(HaveKeyWithValue(bitcoinv1alpha1.RetainedFingerprintAnnotation, "51d9161a"))
+				Expect(foundSecret.Annotations).To(HaveKeyWithValue(bitcoinv1alpha1.RetainedSeedAnnotation, SeedName))
+			} else {
+				Expect(foundSecret.Annotations).To(Not(HaveKey(bitcoinv1alpha1.RetainedFingerprintAnnotation)))
+			}
+		},
+		Entry(
+			"when the deletion policy is Retain",
+			bitcoinv1alpha1.DeletionPolicyRetain,
+			false,
+			true,
+		),
+		Entry(
+			"when the deletion policy is Orphan",
+			bitcoinv1alpha1.DeletionPolicyOrphan,
+			false,
+			false,
+		),
+		Entry(
+			"when the deletion policy is Delete",
+			bitcoinv1alpha1.DeletionPolicyDelete,
+			true,
+			false,
+		),
+	)
+
+	It("adopting a retained secret with the same fingerprint", func() {
+		By("creating a retained secret")
+		err := k8sClient.Create(ctx, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SecretName,
+				Namespace: Namespace,
+				Annotations: map[string]string{
+					bitcoinv1alpha1.RetainedFingerprintAnnotation: "51d9161a",
+				},
+			},
+			StringData: map[string]string{
+				"mnemonic":   Mnemonic,
+				"passphrase": Passphrase,
+			},
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: SecretName,
+				Mnemonic:   Mnemonic,
+				Passphrase: Passphrase,
+				Network:    "simnet",
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err = k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource created")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("checking if the secret was adopted and completed")
+		Expect(k8sClient.Get(ctx, seedNamespaceName, seed)).To(Succeed())
+		foundSecret := &v1.Secret{}
+		Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+		Expect(metav1.IsControlledBy(foundSecret, seed)).To(BeTrue())
+		Expect(foundSecret.Annotations).To(Not(HaveKey(bitcoinv1alpha1.RetainedFingerprintAnnotation)))
+		Expect(foundSecret.Data["rootkey"]).To(Not(BeEmpty()))
+		Expect(meta.IsStatusConditionTrue(seed.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeTrue())
+	})
+
+	It("refusing to take over a secret that belongs to something else", func() {
+		By("creating an unrelated secret with the same name")
+		err := k8sClient.Create(ctx, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SecretName,
+				Namespace: Namespace,
+			},
+			StringData: map[string]string{
+				"mnemonic": "unrelated",
+			},
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: SecretName,
+				Mnemonic:   Mnemonic,
+				Passphrase: Passphrase,
+				Network:    "simnet",
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err = k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource created")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("checking if the conflict was reported and the secret left untouched")
+		Expect(k8sClient.Get(ctx, seedNamespaceName, seed)).To(Succeed())
+		condition := meta.FindStatusCondition(seed.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(condition).To(Not(BeNil()))
+		Expect(condition.Reason).To(Equal(bitcoinv1alpha1.ReasonSecretConflict))
+		foundSecret := &v1.Secret{}
+		Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+		Expect(string(foundSecret.Data["mnemonic"])).To(Equal("unrelated"))
+	})
+
+	It("refusing to adopt a secret retained for another generated Seed", func() {
+		By("creating a secret retained for a different Seed")
+		err := k8sClient.Create(ctx, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SecretName,
+				Namespace: Namespace,
+				Annotations: map[string]string{
+					bitcoinv1alpha1.RetainedFingerprintAnnotation: "51d9161a",
+					bitcoinv1alpha1.RetainedSeedAnnotation:        "other-seed",
+				},
+			},
+			StringData: map[string]string{
+				"mnemonic":   Mnemonic,
+				"passphrase": Passphrase,
+			},
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: SecretName,
+				Network:    "simnet",
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err = k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource created")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("checking if the conflict was reported and the secret left untouched")
+		Expect(k8sClient.Get(ctx, seedNamespaceName, seed)).To(Succeed())
+		condition := meta.FindStatusCondition(seed.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(condition).To(Not(BeNil()))
+		Expect(condition.Reason).To(Equal(bitcoinv1alpha1.ReasonSecretConflict))
+		Expect(seed.Status.Fingerprint).To(BeEmpty())
+		foundSecret := &v1.Secret{}
+		Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+		Expect(metav1.IsControlledBy(foundSecret, seed)).To(BeFalse())
+		Expect(foundSecret.Annotations).To(HaveKeyWithValue(bitcoinv1alpha1.RetainedSeedAnnotation, "other-seed"))
+	})
+
+	It("rotating a generated seed and keeping the previous version", func() {
+		archivedSecretNamespacedName := types.NamespacedName{Namespace: Namespace, Name: SecretName + "-v1"}
+		DeferCleanup(func() {
+			archivedSecret := &v1.Secret{}
+			if k8sClient.Get(ctx, archivedSecretNamespacedName, archivedSecret) == nil {
+				Expect(k8sClient.Delete(ctx, archivedSecret)).To(Succeed())
+			}
+		})
+
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: SecretName,
+				Network:    "simnet",
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err := k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource until the Seed is ready")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		reconcileSeed := func() {
+			for i := 0; i < 3; i++ {
+				_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: seedNamespaceName,
+				})
+				Expect(err).To(Not(HaveOccurred()))
+			}
+		}
+		reconcileSeed()
+
+		foundSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		Expect(foundSeed.Status.Version).To(Equal(int32(1)))
+		previousFingerprint := foundSeed.Status.Fingerprint
+		foundSecret := &v1.Secret{}
+		Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+		previousMnemonic := string(foundSecret.Data["mnemonic"])
+
+		By("changing the rotation trigger")
+		foundSeed.Spec.RotationTrigger = "2023-06"
+		Expect(k8sClient.Update(ctx, foundSeed)).To(Succeed())
+		reconcileSeed()
+
+		By("checking if the status lists the active and previous fingerprints")
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		Expect(foundSeed.Status.Version).To(Equal(int32(2)))
+		Expect(foundSeed.Status.ObservedRotationTrigger).To(Equal("2023-06"))
+		Expect(foundSeed.Status.Fingerprint).To(Not(Equal(previousFingerprint)))
+		Expect(foundSeed.Status.PreviousVersions).To(HaveLen(1))
+		Expect(foundSeed.Status.PreviousVersions[0].Version).To(Equal(int32(1)))
+		Expect(foundSeed.Status.PreviousVersions[0].Fingerprint).To(Equal(previousFingerprint))
+		Expect(foundSeed.Status.PreviousVersions[0].SecretName).To(Equal(archivedSecretNamespacedName.Name))
+		Expect(meta.IsStatusConditionTrue(foundSeed.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeTrue())
+
+		By("checking if the secret holds a new mnemonic")
+		Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+		Expect(string(foundSecret.Data["mnemonic"])).To(Not(Equal(previousMnemonic)))
+		Expect(seedSecretFingerprint(foundSecret)).To(Equal(foundSeed.Status.Fingerprint))
+
+		By("checking if the previous version is still readable")
+		archivedSecret := &v1.Secret{}
+		Expect(k8sClient.Get(ctx, archivedSecretNamespacedName, archivedSecret)).To(Succeed())
+		Expect(string(archivedSecret.Data["mnemonic"])).To(Equal(previousMnemonic))
+		Expect(seedSecretFingerprint(archivedSecret)).To(Equal(previousFingerprint))
+		Expect(metav1.IsControlledBy(archivedSecret, foundSeed)).To(BeTrue())
+	})
+
+	It("splitting a generated seed into shares and recovering it", func() {
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName:     SecretName,
+				Network:        "simnet",
+				DeletionPolicy: bitcoinv1alpha1.DeletionPolicyRetain,
+				Sharding: &bitcoinv1alpha1.SeedSharding{
+					Threshold: 2,
+					Shares:    3,
+				},
+			},
+		}
+		shareNamespacedNames := []types.NamespacedName{
+			{Namespace: Namespace, Name: SecretName + "-share-1"},
+			{Namespace: Namespace, Name: SecretName + "-share-2"},
+			{Namespace: Namespace, Name: SecretName + "-share-3"},
+		}
+		defer func() {
+			By("cleaning up share Secrets")
+			for _, namespacedName := range shareNamespacedNames {
+				share := &v1.Secret{}
+				if k8sClient.Get(ctx, namespacedName, share) == nil {
+					Expect(k8sClient.Delete(ctx, share)).To(Succeed())
+				}
+			}
+		}()
+
+		By("creating the custom resource for the kind Seed")
+		err := k8sClient.Create(ctx, seed.DeepCopy())
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource until the secret exists")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		for i := 0; i < 2; i++ {
+			_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: seedNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		By("checking if the shares were written instead of the mnemonic")
+		foundSecret := &v1.Secret{}
+		Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+		Expect(foundSecret.Data).To(Not(HaveKey("mnemonic")))
+		Expect(foundSecret.Data).To(Not(HaveKey("passphrase")))
+		for i, namespacedName := range shareNamespacedNames {
+			share := &v1.Secret{}
+			Expect(k8sClient.Get(ctx, namespacedName, share)).To(Succeed())
+			Expect(share.Data["share"]).To(Not(BeEmpty()))
+			Expect(string(share.Data["index"])).To(Equal(strconv.Itoa(i + 1)))
+			Expect(string(share.Data["threshold"])).To(Equal("2"))
+		}
+
+		foundSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		Expect(foundSeed.Status.Shares).To(HaveLen(3))
+		for _, share := range foundSeed.Status.Shares {
+			Expect(share.Available).To(BeTrue())
+		}
+		fingerprint := foundSeed.Status.Fingerprint
+		Expect(fingerprint).To(Not(BeEmpty()))
+
+		By("checking if the secret only holds public key material")
+		Expect(foundSecret.Data).To(Not(HaveKey("rootkey")))
+		Expect(string(foundSecret.Data["fingerprint"])).To(Equal(fingerprint))
+		xpub, err := hdkeychain.NewKeyFromString(string(foundSecret.Data["xpub"]))
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(xpub.IsPrivate()).To(BeFalse())
+
+		By("deleting the Seed, its secret and one of the shares")
+		Expect(k8sClient.Delete(ctx, foundSeed)).To(Succeed())
+		deleteSeed(seedNamespaceName)
+		for _, namespacedName := range shareNamespacedNames {
+			share := &v1.Secret{}
+			Expect(k8sClient.Get(ctx, namespacedName, share)).To(Succeed())
+			Expect(share.Annotations).To(HaveKeyWithValue(bitcoinv1alpha1.RetainedFingerprintAnnotation, fingerprint))
+		}
+		Expect(k8sClient.Get(ctx, secretNamespacedName, foundSecret)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, foundSecret)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: shareNamespacedNames[0].Name, Namespace: Namespace}})).To(Succeed())
+
+		By("recovering the Seed from the remaining shares")
+		err = k8sClient.Create(ctx, seed.DeepCopy())
+		Expect(err).To(Not(HaveOccurred()))
+		for i := 0; i < 2; i++ {
+			_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: seedNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		Expect(foundSeed.Status.Fingerprint).To(Equal(fingerprint))
+		Expect(meta.IsStatusConditionTrue(foundSeed.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeTrue())
+
+		By("checking if the lost share was recreated")
+		Expect(k8sClient.Get(ctx, shareNamespacedNames[0], &v1.Secret{})).To(Succeed())
+	})
+
+	It("deleting the shares of a deleted sharded Seed", func() {
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName:     SecretName,
+				Network:        "simnet",
+				DeletionPolicy: bitcoinv1alpha1.DeletionPolicyDelete,
+				Sharding: &bitcoinv1alpha1.SeedSharding{
+					Threshold: 2,
+					Shares:    3,
+					Targets: []bitcoinv1alpha1.ShareTarget{
+						{SecretName: SecretName + "-share-1"},
+						{SecretName: SecretName + "-share-2"},
+						{SecretName: SecretName + "-share-3", Namespace: "default"},
+					},
+				},
+			},
+		}
+		shareNamespacedNames := []types.NamespacedName{
+			{Namespace: Namespace, Name: SecretName + "-share-1"},
+			{Namespace: Namespace, Name: SecretName + "-share-2"},
+			{Namespace: "default", Name: SecretName + "-share-3"},
+		}
+		defer func() {
+			By("cleaning up share Secrets")
+			for _, namespacedName := range shareNamespacedNames {
+				share := &v1.Secret{}
+				if k8sClient.Get(ctx, namespacedName, share) == nil {
+					Expect(k8sClient.Delete(ctx, share)).To(Succeed())
+				}
+			}
+		}()
+
+		By("creating the custom resource for the kind Seed")
+		Expect(k8sClient.Create(ctx, seed)).To(Succeed())
+
+		By("reconciling the custom resource until the shares exist")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		for i := 0; i < 2; i++ {
+			_, err := seedReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: seedNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+		for _, namespacedName := range shareNamespacedNames {
+			Expect(k8sClient.Get(ctx, namespacedName, &v1.Secret{})).To(Succeed())
+		}
+
+		By("checking that the Seed has a finalizer although its secret is garbage collected")
+		foundSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		Expect(foundSeed.Finalizers).To(ContainElement(seedFinalizer))
+
+		By("deleting the custom resource")
+		Expect(k8sClient.Delete(ctx, foundSeed)).To(Succeed())
+		deleteSeed(seedNamespaceName)
+
+		By("checking that no share was left behind")
+		for _, namespacedName := range shareNamespacedNames {
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, namespacedName, &v1.Secret{}))).To(BeTrue())
+		}
+	})
+
+	It("removing the shares of a split that failed part way", func() {
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: SecretName,
+				Network:    "simnet",
+				Sharding: &bitcoinv1alpha1.SeedSharding{
+					Threshold: 2,
+					Shares:    3,
+				},
+			},
+		}
+		shareNamespacedNames := []types.NamespacedName{
+			{Namespace: Namespace, Name: SecretName + "-share-1"},
+			{Namespace: Namespace, Name: SecretName + "-share-2"},
+			{Namespace: Namespace, Name: SecretName + "-share-3"},
+		}
+		defer func() {
+			By("cleaning up share Secrets")
+			for _, namespacedName := range shareNamespacedNames {
+				share := &v1.Secret{}
+				if k8sClient.Get(ctx, namespacedName, share) == nil {
+					Expect(k8sClient.Delete(ctx, share)).To(Succeed())
+				}
+			}
+		}()
+
+		By("creating the custom resource for the kind Seed")
+		err := k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource while the second share cannot be created")
+		seedReconciler := SeedReconciler{
+			Client: &failingCreateClient{Client: k8sClient, n: 2},
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(HaveOccurred())
+
+		By("checking that no share of the incomplete split was left behind")
+		for _, namespacedName := range shareNamespacedNames {
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, namespacedName, &v1.Secret{}))).To(BeTrue())
+		}
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, secretNamespacedName, &v1.Secret{}))).To(BeTrue())
+
+		By("reconciling the custom resource once the shares can be created")
+		seedReconciler.Client = k8sClient
+		for i := 0; i < 2; i++ {
+			_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: seedNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		foundSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(foundSeed.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeTrue())
+		for _, namespacedName := range shareNamespacedNames {
+			Expect(k8sClient.Get(ctx, namespacedName, &v1.Secret{})).To(Succeed())
+		}
+	})
+
+	It("storing a generated seed in Vault", func() {
+		const VaultToken = "test-token"
+		vault := newFakeVault(VaultToken)
+		DeferCleanup(vault.Close)
+
+		By("creating the Vault token secret")
+		err := k8sClient.Create(ctx, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      MaterialSecretName,
+				Namespace: Namespace,
+			},
+			StringData: map[string]string{
+				"token": VaultToken,
+			},
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName:     SecretName,
+				Network:        "simnet",
+				DeletionPolicy: bitcoinv1alpha1.DeletionPolicyDelete,
+				Storage: bitcoinv1alpha1.SeedStorage{
+					Type:    bitcoinv1alpha1.StorageTypeVault,
+					Address: vault.URL,
+					AuthSecretRef: bitcoinv1alpha1.AuthSecretRef{
+						SecretName: MaterialSecretName,
+					},
+				},
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err = k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource while Vault fails the second write of the seed")
+		vault.failWrite = 2
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(HaveOccurred())
+
+		By("reconciling the custom resource until the seed is stored")
+		for i := 0; i < 2; i++ {
+			_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: seedNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		By("checking if the mnemonic was written to Vault instead of a secret")
+		foundSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		stored := vault.secret(Namespace + "/" + SecretName)
+		Expect(stored).To(Not(BeNil()))
+		Expect(strings.Fields(stored.data["mnemonic"])).To(HaveLen(24))
+		Expect(stored.data["rootkey"]).To(Not(BeEmpty()))
+		Expect(stored.customMetadata).To(HaveKeyWithValue(bitcoinv1alpha1.SeedFormatAnnotation, bitcoinv1alpha1.SeedFormatAezeed))
+		Expect(stored.customMetadata).To(HaveKeyWithValue(vaultOwnerMetadata, string(foundSeed.UID)))
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, secretNamespacedName, &v1.Secret{}))).To(BeTrue())
+
+		By("checking if the Seed is ready and keeps its Vault finalizer")
+		Expect(foundSeed.Status.Fingerprint).To(Not(BeEmpty()))
+		Expect(meta.IsStatusConditionTrue(foundSeed.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeTrue())
+		Expect(foundSeed.Finalizers).To(ContainElement(seedFinalizer))
+
+		By("reconciling again without regenerating the mnemonic")
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(vault.secret(Namespace + "/" + SecretName).data).To(Equal(stored.data))
+
+		By("deleting the custom resource")
+		Expect(k8sClient.Delete(ctx, foundSeed)).To(Succeed())
+		deleteSeed(seedNamespaceName)
+
+		By("checking if the seed was removed from Vault")
+		Expect(vault.secret(Namespace + "/" + SecretName)).To(BeNil())
+	})
+
+	It("deleting a Vault Seed after its token secret was deleted", func() {
+		const VaultToken = "test-token"
+		vault := newFakeVault(VaultToken)
+		DeferCleanup(vault.Close)
+
+		By("creating the Vault token secret")
+		tokenSecret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      MaterialSecretName,
+				Namespace: Namespace,
+			},
+			StringData: map[string]string{
+				"token": VaultToken,
+			},
+		}
+		Expect(k8sClient.Create(ctx, tokenSecret)).To(Succeed())
+
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName:     SecretName,
+				Mnemonic:       Mnemonic,
+				Passphrase:     Passphrase,
+				Network:        "simnet",
+				DeletionPolicy: bitcoinv1alpha1.DeletionPolicyDelete,
+				Storage: bitcoinv1alpha1.SeedStorage{
+					Type:    bitcoinv1alpha1.StorageTypeVault,
+					Address: vault.URL,
+					AuthSecretRef: bitcoinv1alpha1.AuthSecretRef{
+						SecretName: MaterialSecretName,
+					},
+				},
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err := k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource until the seed is stored")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		for i := 0; i < 2; i++ {
+			_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: seedNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+		Expect(vault.secret(Namespace + "/" + SecretName)).To(Not(BeNil()))
+
+		By("deleting the Vault token secret before the custom resource")
+		Expect(k8sClient.Get(ctx, materialSecretNamespacedName, tokenSecret)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, tokenSecret)).To(Succeed())
+		Expect(k8sClient.Get(ctx, seedNamespaceName, seed)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, seed)).To(Succeed())
+		deleteSeed(seedNamespaceName)
+
+		By("checking if the seed was left behind in Vault")
+		Expect(vault.secret(Namespace + "/" + SecretName)).To(Not(BeNil()))
+	})
+
+	It("keeping the Vault secret of another Seed when a conflicting Seed is deleted", func() {
+		const VaultToken = "test-token"
+		const OtherSeedName = "test-other"
+		vault := newFakeVault(VaultToken)
+		DeferCleanup(vault.Close)
+
+		By("creating the Vault token secret")
+		err := k8sClient.Create(ctx, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      MaterialSecretName,
+				Namespace: Namespace,
+			},
+			StringData: map[string]string{
+				"token": VaultToken,
+			},
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		seedOf := func(name string) *bitcoinv1alpha1.Seed {
+			return &bitcoinv1alpha1.Seed{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: Namespace,
+				},
+				Spec: bitcoinv1alpha1.SeedSpec{
+					SecretName:     SecretName,
+					Network:        "simnet",
+					DeletionPolicy: bitcoinv1alpha1.DeletionPolicyDelete,
+					Storage: bitcoinv1alpha1.SeedStorage{
+						Type:    bitcoinv1alpha1.StorageTypeVault,
+						Address: vault.URL,
+						AuthSecretRef: bitcoinv1alpha1.AuthSecretRef{
+							SecretName: MaterialSecretName,
+						},
+					},
+				},
+			}
+		}
+
+		By("creating and reconciling the Seed that stores the seed in Vault")
+		Expect(k8sClient.Create(ctx, seedOf(SeedName))).To(Succeed())
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		for i := 0; i < 2; i++ {
+			_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: seedNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+		stored := vault.secret(Namespace + "/" + SecretName)
+		Expect(stored).To(Not(BeNil()))
+
+		By("creating and reconciling a second Seed with the same Vault path")
+		otherNamespacedName := types.NamespacedName{Namespace: Namespace, Name: OtherSeedName}
+		Expect(k8sClient.Create(ctx, seedOf(OtherSeedName))).To(Succeed())
+		for i := 0; i < 2; i++ {
+			_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: otherNamespacedName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+		otherSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, otherNamespacedName, otherSeed)).To(Succeed())
+		condition := meta.FindStatusCondition(otherSeed.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(condition).To(Not(BeNil()))
+		Expect(condition.Reason).To(Equal(bitcoinv1alpha1.ReasonSecretConflict))
+
+		By("deleting the second Seed")
+		Expect(k8sClient.Delete(ctx, otherSeed)).To(Succeed())
+		deleteSeed(otherNamespacedName)
+
+		By("checking if the seed of the first Seed is still in Vault")
+		Expect(vault.secret(Namespace + "/" + SecretName)).To(Equal(stored))
+
+		By("deleting the first Seed")
+		foundSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, foundSeed)).To(Succeed())
+		deleteSeed(seedNamespaceName)
+		Expect(vault.secret(Namespace + "/" + SecretName)).To(BeNil())
+	})
+
+	It("leaving the metadata of a Vault path with deleted versions untouched", func() {
+		const VaultToken = "test-token"
+		vault := newFakeVault(VaultToken)
+		DeferCleanup(vault.Close)
+
+		By("creating a Vault secret whose only version was deleted")
+		vault.secrets[Namespace+"/"+SecretName] = &fakeVaultSecret{
+			customMetadata: map[string]string{"team": "payments"},
+			versions:       1,
+		}
+
+		By("creating the Vault token secret")
+		err := k8sClient.Create(ctx, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      MaterialSecretName,
+				Namespace: Namespace,
+			},
+			StringData: map[string]string{
+				"token": VaultToken,
+			},
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName:     SecretName,
+				Network:        "simnet",
+				DeletionPolicy: bitcoinv1alpha1.DeletionPolicyDelete,
+				Storage: bitcoinv1alpha1.SeedStorage{
+					Type:    bitcoinv1alpha1.StorageTypeVault,
+					Address: vault.URL,
+					AuthSecretRef: bitcoinv1alpha1.AuthSecretRef{
+						SecretName: MaterialSecretName,
+					},
+				},
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		Expect(k8sClient.Create(ctx, seed)).To(Succeed())
+
+		By("reconciling the custom resource created")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(HaveOccurred())
+
+		By("checking if the Vault secret was left untouched")
+		stored := vault.secret(Namespace + "/" + SecretName)
+		Expect(stored.versions).To(Equal(1))
+		Expect(stored.customMetadata).To(Equal(map[string]string{"team": "payments"}))
+	})
+
+	It("reporting a Vault token that is not accepted", func() {
+		const VaultToken = "test-token"
+		vault := newFakeVault(VaultToken)
+		DeferCleanup(vault.Close)
+
+		By("creating a secret with the wrong Vault token")
+		tokenSecret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      MaterialSecretName,
+				Namespace: Namespace,
+			},
+			StringData: map[string]string{
+				"vault-token": "wrong-token",
+			},
+		}
+		Expect(k8sClient.Create(ctx, tokenSecret)).To(Succeed())
+
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: SecretName,
+				Mnemonic:   Mnemonic,
+				Passphrase: Passphrase,
+				Network:    "simnet",
+				Storage: bitcoinv1alpha1.SeedStorage{
+					Type:    bitcoinv1alpha1.StorageTypeVault,
+					Address: vault.URL,
+					AuthSecretRef: bitcoinv1alpha1.AuthSecretRef{
+						SecretName: MaterialSecretName,
+						SecretKey:  "vault-token",
+					},
+				},
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err := k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource created")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(HaveOccurred())
+
+		By("checking if the Ready condition reports the unavailable storage")
+		foundSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		condition := meta.FindStatusCondition(foundSeed.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(condition).To(Not(BeNil()))
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(bitcoinv1alpha1.ReasonStorageUnavailable))
+		Expect(vault.secret(Namespace + "/" + SecretName)).To(BeNil())
+
+		By("fixing the Vault token so the Seed can be cleaned up")
+		Expect(k8sClient.Get(ctx, materialSecretNamespacedName, tokenSecret)).To(Succeed())
+		tokenSecret.Data["vault-token"] = []byte(VaultToken)
+		Expect(k8sClient.Update(ctx, tokenSecret)).To(Succeed())
+	})
+
+	It("rejecting a plaintext mnemonic on mainnet", func() {
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: SecretName,
+				Mnemonic:   Mnemonic,
+				Passphrase: Passphrase,
+				Network:    "mainnet",
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err := k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource created")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("checking if the Ready condition reports the invalid spec")
+		foundSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		condition := meta.FindStatusCondition(foundSeed.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(condition).To(Not(BeNil()))
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(bitcoinv1alpha1.ReasonInvalidSpec))
+
+		By("checking that no secret was created")
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, secretNamespacedName, &v1.Secret{}))).To(BeTrue())
+	})
+
+	It("reconciling a Seed instance with an unsupported network", func() {
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: SecretName,
+				Mnemonic:   Mnemonic,
+				Passphrase: Passphrase,
+				Network:    "litecoin",
+			},
+		}
+
+		By("creating the custom resource for the kind Seed")
+		err := k8sClient.Create(ctx, seed)
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("reconciling the custom resource created")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err = seedReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: seedNamespaceName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("checking if the Ready condition reports the unsupported network")
+		foundSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		condition := meta.FindStatusCondition(foundSeed.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(condition).To(Not(BeNil()))
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(bitcoinv1alpha1.ReasonUnsupportedNetwork))
+
+		By("checking that no secret was created")
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, secretNamespacedName, &v1.Secret{}))).To(BeTrue())
+	})
+})
+
+// failingCreateClient fails the nth Secret it is asked to create
+type failingCreateClient struct {
+	client.Client
+	n       int
+	creates int
+}
+
+func (c *failingCreateClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if _, ok := obj.(*v1.Secret); ok {
+		c.creates++
+		if c.creates == c.n {
+			return errors.NewServiceUnavailable("injected create failure")
+		}
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// seedFinalizer keeps a Seed around until its secret was released according to the deletion policy
+const seedFinalizer = "bitcoin.kiln-fired.github.io/seed-secret"
+
+// reconcileFinalizer adds the finalizer when the deletion policy keeps the secret, or when deleting it requires more
+// than Kubernetes garbage collection, and removes it otherwise. Share secrets are not owned by the Seed, so a sharded
+// Seed always has the finalizer.
+func (r *SeedReconciler) reconcileFinalizer(ctx context.Context, s *bitcoinv1alpha1.Seed) error {
+	garbageCollected := (s.Spec.Storage.Type == "" || s.Spec.Storage.Type == bitcoinv1alpha1.StorageTypeKubernetes) && s.Spec.Sharding == nil
+
+	if s.Spec.DeletionPolicy == bitcoinv1alpha1.DeletionPolicyDelete && garbageCollected {
+		if controllerutil.RemoveFinalizer(s, seedFinalizer) {
+			return r.Update(ctx, s)
+		}
+		return nil
+	}
+
+	if controllerutil.AddFinalizer(s, seedFinalizer) {
+		return r.Update(ctx, s)
+	}
+	return nil
+}
+
+// finalizeSeed handles the stored key material of a deleted Seed, including the versions kept by rotations and the
+// shares of a sharded Seed, according to its deletion policy and removes the finalizer
+func (r *SeedReconciler) finalizeSeed(ctx context.Context, s *bitcoinv1alpha1.Seed) error {
+	log := ctrllog.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(s, seedFinalizer) {
+		return nil
+	}
+
+	if s.Spec.Sharding != nil {
+		err := r.finalizeShares(ctx, s)
+		if err != nil {
+			log.Error(err, "Failed to finalize share Secrets")
+			return err
+		}
+	}
+
+	// Storage that cannot be configured anymore, e.g. because its token secret was deleted first, would block the
+	// deletion forever, so the stored key material is left behind instead
+	store, err := storeForSeed(ctx, r.Client, r.Scheme, s)
+	if err != nil {
+		log.Error(err, "Failed to configure Seed storage, leaving the stored key material behind")
+		controllerutil.RemoveFinalizer(s, seedFinalizer)
+		return r.Update(ctx, s)
+	}
+
+	versions := []*bitcoinv1alpha1.Seed{s}
+	for _, previous := range s.Status.PreviousVersions {
+		versions = append(versions, seedVersion(s, previous.Version))
+	}
+
+	for _, version := range versions {
+		err = r.finalizeStoredSeed(ctx, store, version)
+		if err != nil {
+			return err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(s, seedFinalizer)
+	return r.Update(ctx, s)
+}
+
+// finalizeStoredSeed deletes or releases one version of the stored key material of a deleted Seed
+func (r *SeedReconciler) finalizeStoredSeed(ctx context.Context, store SeedStore, s *bitcoinv1alpha1.Seed) error {
+	log := ctrllog.FromContext(ctx)
+
+	stored, err := store.Get(ctx, s)
+	if isSeedNotStored(err) {
+		return nil
+	}
+	if err != nil {
+		log.Error(err, "Failed to get stored Seed", "Location", store.Describe(s))
+		return err
+	}
+
+	// Key material of another Seed, like the one a Seed with a SecretConflict found at its location, is left alone
+	if !stored.Owned {
+		return nil
+	}
+
+	if s.Spec.DeletionPolicy == bitcoinv1alpha1.DeletionPolicyDelete {
+		log.Info("Deleting stored Seed", "Location", store.Describe(s))
+		err := store.Delete(ctx, s)
+		if err != nil && !isSeedNotStored(err) {
+			log.Error(err, "Failed to delete stored Seed", "Location", store.Describe(s))
+			return err
+		}
+		return nil
+	}
+
+	if stored.Annotations == nil {
+		stored.Annotations = map[string]string{}
+	}
+
+	if s.Spec.DeletionPolicy == bitcoinv1alpha1.DeletionPolicyRetain {
+		fingerprint, err := storedFingerprint(stored)
+		if err != nil {
+			log.Error(err, "Failed to compute fingerprint of retained Seed")
+			return err
+		}
+		stored.Annotations[bitcoinv1alpha1.RetainedFingerprintAnnotation] = fingerprint
+		stored.Annotations[bitcoinv1alpha1.RetainedSeedAnnotation] = s.Name
+	}
+
+	log.Info("Releasing stored Seed", "Location", store.Describe(s), "DeletionPolicy", s.Spec.DeletionPolicy)
+	err = store.Release(ctx, s, stored)
+	if err != nil {
+		log.Error(err, "Failed to release stored Seed", "Location", store.Describe(s))
+		return err
+	}
+	return nil
+}
+
+// storedFingerprint returns the fingerprint of stored key material, which only holds the extended public key of a
+// sharded Seed
+func storedFingerprint(stored *StoredSeed) (string, error) {
+	if rootKey, ok := stored.Data["rootkey"]; ok {
+		return rootKeyFingerprint(rootKey)
+	}
+	return rootKeyFingerprint(stored.Data["xpub"])
+}
+
+// rootKeyFingerprint returns the fingerprint of a serialized root key
+func rootKeyFingerprint(rootKey string) (string, error) {
+	key, err := hdkeychain.NewKeyFromString(rootKey)
+	if err != nil {
+		return "", err
+	}
+	return masterFingerprint(key)
+}
+
+// retainedFor reports whether unowned key material was retained for a Seed that generates its mnemonic, either
+// under the Seed's name or with the fingerprint the Seed already recorded
+func retainedFor(s *bitcoinv1alpha1.Seed, stored *StoredSeed) bool {
+	fingerprint, ok := stored.Annotations[bitcoinv1alpha1.RetainedFingerprintAnnotation]
+	if !ok {
+		return false
+	}
+	if s.Status.Fingerprint != "" {
+		return fingerprint == s.Status.Fingerprint
+	}
+	return stored.Annotations[bitcoinv1alpha1.RetainedSeedAnnotation] == s.Name
+}
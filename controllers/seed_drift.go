@@ -0,0 +1,93 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// privateSeedKeys are the keys of stored key material that must not be stored when the Seed does not expect them,
+// e.g. because a sharded Seed keeps them in its shares
+var privateSeedKeys = []string{"mnemonic", "passphrase", "rootkey"}
+
+// secretDrift returns the keys and annotations of the expected key material whose values differ in the stored
+// key material. Keys that only exist in the stored key material are not considered drift, except private key material.
+func secretDrift(expected *StoredSeed, stored *StoredSeed) []string {
+	var drifted []string
+
+	for _, key := range privateSeedKeys {
+		_, expectedOk := expected.Data[key]
+		_, storedOk := stored.Data[key]
+		if storedOk && !expectedOk {
+			drifted = append(drifted, key)
+		}
+	}
+
+	for key, value := range expected.Data {
+		if storedValue, ok := stored.Data[key]; !ok || storedValue != value {
+			drifted = append(drifted, key)
+		}
+	}
+
+	for key, value := range expected.Annotations {
+		if storedValue, ok := stored.Annotations[key]; !ok || storedValue != value {
+			drifted = append(drifted, key)
+		}
+	}
+
+	sort.Strings(drifted)
+	return drifted
+}
+
+// restoreSecret copies the expected key material into the stored key material and removes unexpected private key
+// material, leaving unrelated keys untouched
+func restoreSecret(expected *StoredSeed, stored *StoredSeed) {
+	if stored.Data == nil {
+		stored.Data = map[string]string{}
+	}
+	for _, key := range privateSeedKeys {
+		if _, ok := expected.Data[key]; !ok {
+			delete(stored.Data, key)
+		}
+	}
+	for key, value := range expected.Data {
+		stored.Data[key] = value
+	}
+
+	if stored.Annotations == nil {
+		stored.Annotations = map[string]string{}
+	}
+	for key, value := range expected.Annotations {
+		stored.Annotations[key] = value
+	}
+}
+
+// setSecretTamperedCondition records the SecretTampered condition in the Seed status without updating it
+func setSecretTamperedCondition(s *bitcoinv1alpha1.Seed, status metav1.ConditionStatus, reason string, message string) {
+	meta.SetStatusCondition(&s.Status.Conditions, metav1.Condition{
+		Type:               bitcoinv1alpha1.ConditionSecretTampered,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: s.Generation,
+	})
+}
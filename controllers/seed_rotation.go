@@ -0,0 +1,132 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// rotationPending reports whether the rotation trigger of a Seed changed since its key material was generated
+func rotationPending(s *bitcoinv1alpha1.Seed) bool {
+	return s.Status.Fingerprint != "" && s.Spec.RotationTrigger != s.Status.ObservedRotationTrigger
+}
+
+// versionedSecretName returns the name of the secret that keeps a previous version of the key material
+func versionedSecretName(secretName string, version int32) string {
+	return fmt.Sprintf("%s-v%d", secretName, version)
+}
+
+// seedVersion returns a copy of the Seed that addresses the stored key material of a previous version
+func seedVersion(s *bitcoinv1alpha1.Seed, version int32) *bitcoinv1alpha1.Seed {
+	versioned := s.DeepCopy()
+	versioned.Spec.SecretName = versionedSecretName(s.Spec.SecretName, version)
+	if versioned.Spec.Storage.Path != "" {
+		versioned.Spec.Storage.Path = versionedSecretName(s.Spec.Storage.Path, version)
+	}
+	return versioned
+}
+
+// rotateSeed copies the active key material to a versioned secret and replaces it with a new mnemonic. The
+// rotation trigger is recorded on the new key material first, so a rotation interrupted before the status update
+// completes without generating yet another mnemonic.
+func (r *SeedReconciler) rotateSeed(ctx context.Context, s *bitcoinv1alpha1.Seed, store SeedStore, stored *StoredSeed, passphrase string, network bitcoinv1alpha1.BitcoinNetwork) (ctrl.Result, error) {
+	log := ctrllog.FromContext(ctx)
+
+	version := s.Status.Version
+	if version == 0 {
+		version = 1
+	}
+	archived := seedVersion(s, version)
+
+	if trigger, ok := stored.Annotations[bitcoinv1alpha1.RotationTriggerAnnotation]; !ok || trigger != s.Spec.RotationTrigger {
+		_, err := store.Get(ctx, archived)
+		if isSeedNotStored(err) {
+			log.Info("Archiving Seed version", "Version", version, "Location", store.Describe(archived))
+			err = store.Create(ctx, archived, &StoredSeed{Data: stored.Data, Annotations: stored.Annotations})
+		}
+		if err != nil {
+			log.Error(err, "Failed to archive Seed version", "Version", version, "Location", store.Describe(archived))
+			return ctrl.Result{}, err
+		}
+
+		material, err := newSeedMaterial(r.generator(), time.Now(), s.Spec.Format, "", passphrase, s.Spec.WordCount)
+		if err != nil {
+			log.Error(err, "Failed to generate rotated mnemonic")
+			return ctrl.Result{}, err
+		}
+
+		hdkey, err := hdkeychain.NewMaster(material.seed, network.Params)
+		if err != nil {
+			log.Error(err, "Failed to get Seed")
+			return ctrl.Result{}, err
+		}
+
+		addresses, err := deriveAddresses(hdkey, s.Spec.Addresses, network.Params)
+		if err != nil {
+			log.Error(err, "Failed to derive addresses")
+			return ctrl.Result{}, err
+		}
+
+		rotated, err := storedSeedFor(s, material, hdkey, addresses)
+		if err != nil {
+			log.Error(err, "Failed to compute rotated Seed")
+			return ctrl.Result{}, err
+		}
+		rotated.Annotations[bitcoinv1alpha1.RotationTriggerAnnotation] = s.Spec.RotationTrigger
+
+		log.Info("Rotating Seed", "Location", store.Describe(s), "Version", version+1)
+		err = store.Update(ctx, s, rotated)
+		if err != nil {
+			log.Error(err, "Failed to store rotated Seed", "Location", store.Describe(s))
+			return ctrl.Result{}, err
+		}
+		stored = rotated
+	}
+
+	fingerprint, err := storedFingerprint(stored)
+	if err != nil {
+		log.Error(err, "Failed to compute fingerprint of rotated Seed")
+		return ctrl.Result{}, err
+	}
+
+	s.Status.PreviousVersions = append([]bitcoinv1alpha1.SeedVersionStatus{{
+		Version:     version,
+		Fingerprint: s.Status.Fingerprint,
+		SecretName:  archived.Spec.SecretName,
+		RetiredAt:   metav1.Now(),
+	}}, s.Status.PreviousVersions...)
+	s.Status.Fingerprint = fingerprint
+	s.Status.Version = version + 1
+	s.Status.ObservedRotationTrigger = s.Spec.RotationTrigger
+
+	message := fmt.Sprintf("rotated to version %d, version %d is kept in %s", version+1, version, store.Describe(archived))
+	err = r.updateReadyCondition(ctx, s, metav1.ConditionTrue, bitcoinv1alpha1.ReasonSeedRotated, message)
+	if err != nil {
+		log.Error(err, "Failed to update Seed status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{Requeue: true}, nil
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// seedShares are the secret shares of a sharded Seed that could be read
+type seedShares struct {
+	targets   []bitcoinv1alpha1.ShareTarget
+	threshold int
+
+	// found maps share indexes to share values
+	found map[byte][]byte
+
+	// fingerprint is the master key fingerprint recorded in the shares
+	fingerprint string
+}
+
+// shareTargets returns the secret of every share, in share order
+func shareTargets(s *bitcoinv1alpha1.Seed) []bitcoinv1alpha1.ShareTarget {
+	targets := make([]bitcoinv1alpha1.ShareTarget, s.Spec.Sharding.Shares)
+
+	for i := range targets {
+		if i < len(s.Spec.Sharding.Targets) {
+			targets[i] = s.Spec.Sharding.Targets[i]
+		} else {
+			targets[i].SecretName = fmt.Sprintf("%s-share-%d", s.Spec.SecretName, i+1)
+		}
+		if targets[i].Namespace == "" {
+			targets[i].Namespace = s.Namespace
+		}
+	}
+
+	return targets
+}
+
+// readShares reads every share secret of the Seed that exists. Shares that disagree with the first share about
+// the fingerprint of the seed are ignored.
+func (r *SeedReconciler) readShares(ctx context.Context, s *bitcoinv1alpha1.Seed) (*seedShares, error) {
+	log := ctrllog.FromContext(ctx)
+	shares := &seedShares{
+		targets:   shareTargets(s),
+		threshold: int(s.Spec.Sharding.Threshold),
+		found:     map[byte][]byte{},
+	}
+
+	for i, target := range shares.targets {
+		secret := &v1.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Name: target.SecretName, Namespace: target.Namespace}, secret)
+
+		if err != nil && apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		value, err := hex.DecodeString(string(secret.Data["share"]))
+		if err != nil || len(value) == 0 {
+			log.Info("Ignoring malformed share", "Secret.Namespace", target.Namespace, "Secret.Name", target.SecretName)
+			continue
+		}
+
+		fingerprint := string(secret.Data["fingerprint"])
+		if shares.fingerprint == "" {
+			shares.fingerprint = fingerprint
+		} else if fingerprint != shares.fingerprint {
+			log.Info("Ignoring share of a different seed", "Secret.Namespace", target.Namespace, "Secret.Name", target.SecretName)
+			continue
+		}
+
+		shares.found[byte(i+1)] = value
+	}
+
+	return shares, nil
+}
+
+// combine recovers the mnemonic and passphrase from the shares that were found
+func (s *seedShares) combine() (string, string, error) {
+	if len(s.found) < s.threshold {
+		return "", "", fmt.Errorf("found %d of the %d shares required to recover the seed", len(s.found), s.threshold)
+	}
+
+	payload, err := interpolateShares(s.found, 0)
+	if err != nil {
+		return "", "", err
+	}
+
+	mnemonic, passphrase, ok := strings.Cut(string(payload), "\n")
+	if !ok {
+		return "", "", errors.New("recovered share payload is malformed")
+	}
+	return mnemonic, passphrase, nil
+}
+
+// reconcileShares writes every missing share secret. New shares are split from the seed material when no share
+// exists yet, otherwise lost shares are recreated from the existing ones so that all shares remain compatible.
+func (r *SeedReconciler) reconcileShares(ctx context.Context, s *bitcoinv1alpha1.Seed, material *seedMaterial, fingerprint string, shares *seedShares) error {
+	log := ctrllog.FromContext(ctx)
+
+	values := shares.found
+	split := len(values) == 0
+	if split {
+		payload := []byte(strings.Join(material.mnemonic, " ") + "\n" + material.passphrase)
+		var err error
+		values, err = splitSecret(payload, len(shares.targets), shares.threshold, r.generator())
+		if err != nil {
+			return err
+		}
+	}
+
+	s.Status.Shares = nil
+	var created []bitcoinv1alpha1.ShareTarget
+
+	for i, target := range shares.targets {
+		index := byte(i + 1)
+
+		if _, ok := shares.found[index]; !ok {
+			value, ok := values[index]
+			if !ok {
+				var err error
+				value, err = interpolateShares(shares.found, index)
+				if err != nil {
+					return err
+				}
+			}
+
+			secret := shareSecretForSeed(s, target, index, value, fingerprint)
+			log.Info("Creating a new share Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+			err := r.Create(ctx, secret)
+			if err != nil {
+				log.Error(err, "Failed to create new share Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+				// A fresh split is only usable as a whole, fewer shares than the threshold could never be combined
+				if split {
+					if deleteErr := r.deleteShares(ctx, created); deleteErr != nil {
+						log.Error(deleteErr, "Failed to delete incomplete share Secrets")
+					}
+				}
+				return err
+			}
+			created = append(created, target)
+		}
+
+		s.Status.Shares = append(s.Status.Shares, bitcoinv1alpha1.SeedShareStatus{
+			Index:      int32(index),
+			SecretName: target.SecretName,
+			Namespace:  target.Namespace,
+			Available:  true,
+		})
+	}
+
+	return nil
+}
+
+// deleteShares deletes the share secrets of the given targets that exist
+func (r *SeedReconciler) deleteShares(ctx context.Context, targets []bitcoinv1alpha1.ShareTarget) error {
+	log := ctrllog.FromContext(ctx)
+
+	for _, target := range targets {
+		log.Info("Deleting share Secret", "Secret.Namespace", target.Namespace, "Secret.Name", target.SecretName)
+		secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: target.SecretName, Namespace: target.Namespace}}
+		err := r.Delete(ctx, secret)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// finalizeShares deletes the share secrets of a deleted sharded Seed for the Delete policy and annotates them with the
+// fingerprint of the Seed for the Retain policy, like the Seed secret. Shares of another seed are left alone.
+func (r *SeedReconciler) finalizeShares(ctx context.Context, s *bitcoinv1alpha1.Seed) error {
+	log := ctrllog.FromContext(ctx)
+	var owned []bitcoinv1alpha1.ShareTarget
+
+	for _, target := range shareTargets(s) {
+		secret := &v1.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Name: target.SecretName, Namespace: target.Namespace}, secret)
+
+		if err != nil && apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if !shareOwnedBy(s, secret) {
+			log.Info("Leaving share of a different seed", "Secret.Namespace", target.Namespace, "Secret.Name", target.SecretName)
+			continue
+		}
+
+		switch s.Spec.DeletionPolicy {
+		case bitcoinv1alpha1.DeletionPolicyDelete:
+			owned = append(owned, target)
+		case bitcoinv1alpha1.DeletionPolicyRetain:
+			if secret.Annotations == nil {
+				secret.Annotations = map[string]string{}
+			}
+			secret.Annotations[bitcoinv1alpha1.RetainedFingerprintAnnotation] = string(secret.Data["fingerprint"])
+			secret.Annotations[bitcoinv1alpha1.RetainedSeedAnnotation] = s.Name
+			log.Info("Retaining share Secret", "Secret.Namespace", target.Namespace, "Secret.Name", target.SecretName)
+			err = r.Update(ctx, secret)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return r.deleteShares(ctx, owned)
+}
+
+// shareOwnedBy reports whether a share secret belongs to a Seed, because it holds the fingerprint the Seed recorded
+// or, before the Seed recorded one, because it is labeled for the Seed
+func shareOwnedBy(s *bitcoinv1alpha1.Seed, secret *v1.Secret) bool {
+	if s.Status.Fingerprint != "" {
+		return string(secret.Data["fingerprint"]) == s.Status.Fingerprint
+	}
+	return secret.Labels["seed_cr"] == s.Name
+}
+
+// foundShareTargets returns the targets of the shares that were found
+func foundShareTargets(shares *seedShares) []bitcoinv1alpha1.ShareTarget {
+	var targets []bitcoinv1alpha1.ShareTarget
+	for i, target := range shares.targets {
+		if _, ok := shares.found[byte(i+1)]; ok {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// shareStatus reports which share secrets exist, for Seeds that cannot be recovered
+func shareStatus(shares *seedShares) []bitcoinv1alpha1.SeedShareStatus {
+	var status []bitcoinv1alpha1.SeedShareStatus
+	for i, target := range shares.targets {
+		_, available := shares.found[byte(i+1)]
+		status = append(status, bitcoinv1alpha1.SeedShareStatus{
+			Index:      int32(i + 1),
+			SecretName: target.SecretName,
+			Namespace:  target.Namespace,
+			Available:  available,
+		})
+	}
+	return status
+}
+
+func shareSecretForSeed(s *bitcoinv1alpha1.Seed, target bitcoinv1alpha1.ShareTarget, index byte, value []byte, fingerprint string) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:    labelsForSeed(s.Name),
+			Name:      target.SecretName,
+			Namespace: target.Namespace,
+		},
+		StringData: map[string]string{
+			"share":       hex.EncodeToString(value),
+			"index":       strconv.Itoa(int(index)),
+			"threshold":   strconv.Itoa(int(s.Spec.Sharding.Threshold)),
+			"fingerprint": fingerprint,
+		},
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// ErrSeedNotStored is returned by a SeedStore when no key material is stored for a Seed
+var ErrSeedNotStored = errors.New("seed is not stored")
+
+// isSeedNotStored reports whether a SeedStore error means no key material is stored
+func isSeedNotStored(err error) bool {
+	return errors.Is(err, ErrSeedNotStored)
+}
+
+// StoredSeed is the key material written for a Seed, along with its metadata
+type StoredSeed struct {
+	// Data maps keys such as mnemonic and rootkey to their values
+	Data map[string]string
+
+	// Annotations describe the key material, e.g. its mnemonic format
+	Annotations map[string]string
+
+	// Owned reports whether the key material belongs to the Seed it was read for
+	Owned bool
+}
+
+// SeedStore persists the key material of Seeds
+type SeedStore interface {
+	// Get returns the key material stored for the Seed, or ErrSeedNotStored
+	Get(ctx context.Context, s *bitcoinv1alpha1.Seed) (*StoredSeed, error)
+
+	// Create stores key material for a Seed that has none yet
+	Create(ctx context.Context, s *bitcoinv1alpha1.Seed, stored *StoredSeed) error
+
+	// Update replaces the stored key material and makes the Seed its owner
+	Update(ctx context.Context, s *bitcoinv1alpha1.Seed, stored *StoredSeed) error
+
+	// Release replaces the annotations of the stored key material and removes the Seed as its owner, so the
+	// key material outlives the Seed
+	Release(ctx context.Context, s *bitcoinv1alpha1.Seed, stored *StoredSeed) error
+
+	// Delete removes the stored key material
+	Delete(ctx context.Context, s *bitcoinv1alpha1.Seed) error
+
+	// Describe returns a human readable location of the key material for conditions and logs
+	Describe(s *bitcoinv1alpha1.Seed) string
+}
+
+// storeForSeed returns the store configured by the storage section of the Seed
+func storeForSeed(ctx context.Context, c client.Client, scheme *runtime.Scheme, s *bitcoinv1alpha1.Seed) (SeedStore, error) {
+	switch s.Spec.Storage.Type {
+	case "", bitcoinv1alpha1.StorageTypeKubernetes:
+		return &kubernetesSeedStore{client: c, scheme: scheme}, nil
+	case bitcoinv1alpha1.StorageTypeVault:
+		ref := s.Spec.Storage.AuthSecretRef
+		if ref.SecretKey == "" {
+			ref.SecretKey = "token"
+		}
+		token, err := secretValue(ctx, c, s.Namespace, ref.SecretName, ref.SecretKey)
+		if err != nil {
+			return nil, err
+		}
+		return newVaultSeedStore(s.Spec.Storage, token), nil
+	default:
+		return nil, fmt.Errorf("unsupported storage type %q", s.Spec.Storage.Type)
+	}
+}
+
+// kubernetesSeedStore stores key material in a Secret named after the Seed secretName and owned by the Seed. Key
+// material created for a Seed that does not exist yet, as done by a restore, has no owner until the Seed adopts it.
+type kubernetesSeedStore struct {
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+func (k *kubernetesSeedStore) Get(ctx context.Context, s *bitcoinv1alpha1.Seed) (*StoredSeed, error) {
+	secret, err := k.secret(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]string{}
+	for key, value := range secret.Data {
+		data[key] = string(value)
+	}
+
+	return &StoredSeed{
+		Data:        data,
+		Annotations: secret.Annotations,
+		Owned:       metav1.IsControlledBy(secret, s),
+	}, nil
+}
+
+func (k *kubernetesSeedStore) Create(ctx context.Context, s *bitcoinv1alpha1.Seed, stored *StoredSeed) error {
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      labelsForSeed(s.Name),
+			Annotations: stored.Annotations,
+			Name:        s.Spec.SecretName,
+			Namespace:   s.Namespace,
+		},
+		StringData: stored.Data,
+	}
+
+	if s.UID != "" {
+		err := ctrl.SetControllerReference(s, &secret, k.scheme)
+		if err != nil {
+			return err
+		}
+	}
+	return k.client.Create(ctx, &secret)
+}
+
+func (k *kubernetesSeedStore) Update(ctx context.Context, s *bitcoinv1alpha1.Seed, stored *StoredSeed) error {
+	secret, err := k.secret(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	secret.Data = map[string][]byte{}
+	for key, value := range stored.Data {
+		secret.Data[key] = []byte(value)
+	}
+	secret.Annotations = stored.Annotations
+
+	err = ctrl.SetControllerReference(s, secret, k.scheme)
+	if err != nil {
+		return err
+	}
+	return k.client.Update(ctx, secret)
+}
+
+func (k *kubernetesSeedStore) Release(ctx context.Context, s *bitcoinv1alpha1.Seed, stored *StoredSeed) error {
+	secret, err := k.secret(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	var ownerReferences []metav1.OwnerReference
+	for _, ref := range secret.OwnerReferences {
+		if ref.UID != s.UID {
+			ownerReferences = append(ownerReferences, ref)
+		}
+	}
+	secret.OwnerReferences = ownerReferences
+	secret.Annotations = stored.Annotations
+
+	return k.client.Update(ctx, secret)
+}
+
+func (k *kubernetesSeedStore) Delete(ctx context.Context, s *bitcoinv1alpha1.Seed) error {
+	secret, err := k.secret(ctx, s)
+	if err != nil {
+		return err
+	}
+	return k.client.Delete(ctx, secret)
+}
+
+func (k *kubernetesSeedStore) Describe(s *bitcoinv1alpha1.Seed) string {
+	return "secret " + s.Spec.SecretName
+}
+
+func (k *kubernetesSeedStore) secret(ctx context.Context, s *bitcoinv1alpha1.Seed) (*v1.Secret, error) {
+	secret := &v1.Secret{}
+	err := k.client.Get(ctx, types.NamespacedName{Name: s.Spec.SecretName, Namespace: s.Namespace}, secret)
+	if apierrors.IsNotFound(err) {
+		return nil, ErrSeedNotStored
+	}
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
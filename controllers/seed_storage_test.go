@@ -0,0 +1,195 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// fakeVaultSecret is a secret held by fakeVault
+type fakeVaultSecret struct {
+	data           map[string]string
+	customMetadata map[string]string
+	versions       int
+}
+
+// fakeVault is an in-process HashiCorp Vault server implementing the subset of the KV version 2 API used by
+// vaultSeedStore
+type fakeVault struct {
+	*httptest.Server
+
+	token   string
+	mount   string
+	mu      sync.Mutex
+	secrets map[string]*fakeVaultSecret
+
+	// failWrite is the number of the data or metadata write that fails, counting from 1
+	failWrite int
+	writes    int
+}
+
+func newFakeVault(token string) *fakeVault {
+	v := &fakeVault{
+		token:   token,
+		mount:   "secret",
+		secrets: map[string]*fakeVaultSecret{},
+	}
+	v.Server = httptest.NewServer(http.HandlerFunc(v.serve))
+	return v
+}
+
+// secret returns a copy of the secret stored at path, or nil when there is none
+func (v *fakeVault) secret(path string) *fakeVaultSecret {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	secret, ok := v.secrets[path]
+	if !ok {
+		return nil
+	}
+
+	copied := &fakeVaultSecret{
+		data:           map[string]string{},
+		customMetadata: map[string]string{},
+		versions:       secret.versions,
+	}
+	for key, value := range secret.data {
+		copied.data[key] = value
+	}
+	for key, value := range secret.customMetadata {
+		copied.customMetadata[key] = value
+	}
+	return copied
+}
+
+func (v *fakeVault) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != v.token {
+		http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+		return
+	}
+
+	prefix := "/v1/" + v.mount + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.NotFound(w, r)
+		return
+	}
+	api, path, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	secret, exists := v.secrets[path]
+
+	if r.Method == http.MethodPost || r.Method == http.MethodPut {
+		v.writes++
+		if v.writes == v.failWrite {
+			http.Error(w, `{"errors":["internal error"]}`, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	switch {
+	case api == "data" && r.Method == http.MethodGet:
+		if !exists || secret.versions == 0 {
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+			return
+		}
+		response := map[string]interface{}{
+			"data": map[string]interface{}{
+				"data": secret.data,
+				"metadata": map[string]interface{}{
+					"custom_metadata": secret.customMetadata,
+					"version":         secret.versions,
+				},
+			},
+		}
+		_ = json.NewEncoder(w).Encode(response)
+
+	case api == "data" && (r.Method == http.MethodPost || r.Method == http.MethodPut):
+		request := struct {
+			Data    map[string]string `json:"data"`
+			Options struct {
+				CAS *int `json:"cas"`
+			} `json:"options"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, `{"errors":["invalid request"]}`, http.StatusBadRequest)
+			return
+		}
+
+		versions := 0
+		if exists {
+			versions = secret.versions
+		}
+		if request.Options.CAS != nil && *request.Options.CAS != versions {
+			http.Error(w, `{"errors":["check-and-set parameter did not match the current version"]}`, http.StatusBadRequest)
+			return
+		}
+
+		if !exists {
+			secret = &fakeVaultSecret{customMetadata: map[string]string{}}
+			v.secrets[path] = secret
+		}
+		secret.data = request.Data
+		secret.versions++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"version": secret.versions}})
+
+	case api == "metadata" && r.Method == http.MethodGet:
+		if !exists {
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+			return
+		}
+		response := map[string]interface{}{
+			"data": map[string]interface{}{
+				"current_version": secret.versions,
+				"custom_metadata": secret.customMetadata,
+			},
+		}
+		_ = json.NewEncoder(w).Encode(response)
+
+	case api == "metadata" && (r.Method == http.MethodPost || r.Method == http.MethodPut):
+		request := struct {
+			CustomMetadata map[string]string `json:"custom_metadata"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, `{"errors":["invalid request"]}`, http.StatusBadRequest)
+			return
+		}
+
+		if !exists {
+			secret = &fakeVaultSecret{}
+			v.secrets[path] = secret
+		}
+		secret.customMetadata = request.CustomMetadata
+		w.WriteHeader(http.StatusNoContent)
+
+	case api == "metadata" && r.Method == http.MethodDelete:
+		delete(v.secrets, path)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, `{"errors":["unsupported path"]}`, http.StatusMethodNotAllowed)
+	}
+}
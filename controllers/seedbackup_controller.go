@@ -0,0 +1,359 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// backupRevisionAnnotation records on a backup Job the SeedBackup generation and Seed fingerprint it writes
+const backupRevisionAnnotation = "bitcoin.kiln-fired.github.io/backup-revision"
+
+// backupFingerprintsAnnotation records on a backup ConfigMap the Seed fingerprint of every key, as a JSON object
+const backupFingerprintsAnnotation = "bitcoin.kiln-fired.github.io/backup-fingerprints"
+
+// SeedBackupReconciler reconciles a SeedBackup object
+type SeedBackupReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=seedbackups,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=seedbackups/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=seedbackups/finalizers,verbs=update
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+
+func (r *SeedBackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrllog.FromContext(ctx)
+	backup := &bitcoinv1alpha1.SeedBackup{}
+	err := r.Get(ctx, req.NamespacedName, backup)
+
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("SeedBackup resource not found.")
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to get SeedBackup")
+		return ctrl.Result{}, err
+	}
+
+	seed := &bitcoinv1alpha1.Seed{}
+	err = r.Get(ctx, types.NamespacedName{Name: backup.Spec.SeedName, Namespace: backup.Namespace}, seed)
+
+	if err != nil && errors.IsNotFound(err) {
+		return ctrl.Result{}, r.updateReadyCondition(ctx, backup, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSeedUnavailable, "Seed "+backup.Spec.SeedName+" does not exist")
+	} else if err != nil {
+		log.Error(err, "Failed to get Seed")
+		return ctrl.Result{}, err
+	}
+
+	if seed.Status.Fingerprint == "" {
+		return ctrl.Result{}, r.updateReadyCondition(ctx, backup, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSeedUnavailable, "Seed "+seed.Name+" has no key material yet")
+	}
+
+	// A backup is taken once per generation and taken again when the Seed was rotated
+	if backupComplete(backup, seed) {
+		return ctrl.Result{}, nil
+	}
+
+	err = validateSeedBackupSpec(backup.Spec, seed)
+
+	if err != nil {
+		log.Error(err, "Invalid SeedBackup spec")
+		return ctrl.Result{}, r.updateReadyCondition(ctx, backup, metav1.ConditionFalse, bitcoinv1alpha1.ReasonInvalidSpec, err.Error())
+	}
+
+	// Every version of the key material is backed up to its own key, so the backups of retired versions are kept
+	key := versionedBackupFileName(backupFileName(backup.Spec.Destination, seed.Name), seed.Status.Version)
+	location := describeBackupLocation(backup.Spec.Destination, key)
+	revision := strconv.FormatInt(backup.Generation, 10) + "-" + seed.Status.Fingerprint
+	var job *batchv1.Job
+
+	if backup.Spec.Destination.PersistentVolumeClaim != nil {
+		job = &batchv1.Job{}
+		err = r.Get(ctx, types.NamespacedName{Name: backup.Name + "-backup", Namespace: backup.Namespace}, job)
+
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to get Job")
+			return ctrl.Result{}, err
+		} else if err == nil && job.Annotations[backupRevisionAnnotation] != revision {
+			log.Info("Deleting outdated backup Job", "Job.Namespace", job.Namespace, "Job.Name", job.Name)
+			err = r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if err != nil && !errors.IsNotFound(err) {
+				log.Error(err, "Failed to delete Job", "Job.Namespace", job.Namespace, "Job.Name", job.Name)
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true}, nil
+		} else if err == nil {
+			finished, succeeded := jobFinished(job)
+			if !finished {
+				return ctrl.Result{}, r.updateReadyCondition(ctx, backup, metav1.ConditionFalse, bitcoinv1alpha1.ReasonJobRunning, "waiting for Job "+job.Name+" to write the backup to "+location)
+			}
+			if !succeeded {
+				message := "Job " + job.Name + " failed to write the backup to " + location + ", it refuses to replace the backup of a different seed"
+				return ctrl.Result{}, r.updateReadyCondition(ctx, backup, metav1.ConditionFalse, bitcoinv1alpha1.ReasonJobFailed, message)
+			}
+			return ctrl.Result{}, r.completeBackup(ctx, backup, seed, location)
+		}
+	}
+
+	recipient, err := backupRecipient(ctx, r.Client, backup.Namespace, backup.Spec.Encryption)
+
+	if err != nil {
+		log.Error(err, "Failed to configure backup encryption")
+		if statusErr := r.updateReadyCondition(ctx, backup, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSecretRefUnavailable, err.Error()); statusErr != nil {
+			log.Error(statusErr, "Failed to update SeedBackup status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	store, err := storeForSeed(ctx, r.Client, r.Scheme, seed)
+
+	if err != nil {
+		log.Error(err, "Failed to configure Seed storage")
+		if statusErr := r.updateReadyCondition(ctx, backup, metav1.ConditionFalse, bitcoinv1alpha1.ReasonStorageUnavailable, err.Error()); statusErr != nil {
+			log.Error(statusErr, "Failed to update SeedBackup status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	stored, err := store.Get(ctx, seed)
+
+	if err != nil {
+		log.Error(err, "Failed to get stored Seed", "Location", store.Describe(seed))
+		if statusErr := r.updateReadyCondition(ctx, backup, metav1.ConditionFalse, bitcoinv1alpha1.ReasonStorageUnavailable, err.Error()); statusErr != nil {
+			log.Error(statusErr, "Failed to update SeedBackup status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Only key material that still matches the Seed is backed up, a drifted secret must not replace a good backup
+	fingerprint, err := rootKeyFingerprint(stored.Data["rootkey"])
+	if err != nil || fingerprint != seed.Status.Fingerprint {
+		message := store.Describe(seed) + " does not hold the key material of Seed " + seed.Name + " with fingerprint " + seed.Status.Fingerprint
+		return ctrl.Result{}, r.updateReadyCondition(ctx, backup, metav1.ConditionFalse, bitcoinv1alpha1.ReasonFingerprintMismatch, message)
+	}
+
+	previous, err := archivedSeedBackups(ctx, store, seed)
+
+	if err != nil {
+		log.Error(err, "Failed to get previous versions of the stored Seed")
+		if statusErr := r.updateReadyCondition(ctx, backup, metav1.ConditionFalse, bitcoinv1alpha1.ReasonStorageUnavailable, err.Error()); statusErr != nil {
+			log.Error(statusErr, "Failed to update SeedBackup status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	encrypted, err := encryptSeedBackup(newSeedBackup(seed, stored, previous), recipient)
+
+	if err != nil {
+		log.Error(err, "Failed to encrypt backup")
+		return ctrl.Result{}, err
+	}
+
+	if backup.Spec.Destination.PersistentVolumeClaim != nil {
+		job = r.jobForSeedBackup(backup, key, fingerprint, encrypted, revision)
+		log.Info("Creating a new Job", "Job.Namespace", job.Namespace, "Job.Name", job.Name)
+		err = r.Create(ctx, job)
+		if err != nil {
+			log.Error(err, "Failed to create new Job", "Job.Namespace", job.Namespace, "Job.Name", job.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.updateReadyCondition(ctx, backup, metav1.ConditionFalse, bitcoinv1alpha1.ReasonJobRunning, "waiting for Job "+job.Name+" to write the backup to "+location)
+	}
+
+	existing, err := r.writeConfigMap(ctx, backup, key, fingerprint, encrypted)
+
+	if err != nil {
+		log.Error(err, "Failed to write backup", "Location", location)
+		return ctrl.Result{}, err
+	} else if existing != "" {
+		message := location + " holds the backup of seed " + existing + " and is not replaced with the backup of seed " + fingerprint
+		return ctrl.Result{}, r.updateReadyCondition(ctx, backup, metav1.ConditionFalse, bitcoinv1alpha1.ReasonFingerprintMismatch, message)
+	}
+
+	return ctrl.Result{}, r.completeBackup(ctx, backup, seed, location)
+}
+
+// backupComplete reports whether the backup of the current generation was written for the active key material
+func backupComplete(b *bitcoinv1alpha1.SeedBackup, s *bitcoinv1alpha1.Seed) bool {
+	return b.Status.ObservedGeneration == b.Generation &&
+		b.Status.Fingerprint == s.Status.Fingerprint &&
+		meta.IsStatusConditionTrue(b.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+}
+
+// writeConfigMap writes the encrypted backup to its key of the destination ConfigMap, creating the ConfigMap when it
+// does not exist. The ConfigMap is not owned by the SeedBackup so that deleting the SeedBackup keeps the backup. A key
+// that holds the backup of a different fingerprint is never replaced, its fingerprint is returned instead.
+func (r *SeedBackupReconciler) writeConfigMap(ctx context.Context, b *bitcoinv1alpha1.SeedBackup, key string, fingerprint string, encrypted string) (string, error) {
+	log := ctrllog.FromContext(ctx)
+	destination := b.Spec.Destination.ConfigMap
+
+	configMap := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Name: destination.Name, Namespace: b.Namespace}, configMap)
+
+	if err != nil && errors.IsNotFound(err) {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:    labelsForSeedBackup(b.Name),
+				Name:      destination.Name,
+				Namespace: b.Namespace,
+			},
+			Data: map[string]string{key: encrypted},
+		}
+		err = setBackupFingerprint(configMap, map[string]string{}, key, fingerprint)
+		if err != nil {
+			return "", err
+		}
+		log.Info("Creating a new ConfigMap", "ConfigMap.Namespace", configMap.Namespace, "ConfigMap.Name", configMap.Name)
+		return "", r.Create(ctx, configMap)
+	} else if err != nil {
+		return "", err
+	}
+
+	// Backups written before fingerprints were recorded are assumed to belong to the Seed
+	fingerprints := map[string]string{}
+	if value, ok := configMap.Annotations[backupFingerprintsAnnotation]; ok {
+		err = json.Unmarshal([]byte(value), &fingerprints)
+		if err != nil {
+			return "", fmt.Errorf("configmap %s has malformed annotation %s: %w", configMap.Name, backupFingerprintsAnnotation, err)
+		}
+	}
+	if existing := fingerprints[key]; existing != "" && existing != fingerprint {
+		return existing, nil
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[key] = encrypted
+	err = setBackupFingerprint(configMap, fingerprints, key, fingerprint)
+	if err != nil {
+		return "", err
+	}
+	log.Info("Updating ConfigMap", "ConfigMap.Namespace", configMap.Namespace, "ConfigMap.Name", configMap.Name)
+	return "", r.Update(ctx, configMap)
+}
+
+// setBackupFingerprint adds the fingerprint of the backup in a key to the fingerprints recorded on the ConfigMap
+func setBackupFingerprint(configMap *corev1.ConfigMap, fingerprints map[string]string, key string, fingerprint string) error {
+	fingerprints[key] = fingerprint
+
+	value, err := json.Marshal(fingerprints)
+	if err != nil {
+		return err
+	}
+	if configMap.Annotations == nil {
+		configMap.Annotations = map[string]string{}
+	}
+	configMap.Annotations[backupFingerprintsAnnotation] = string(value)
+	return nil
+}
+
+// jobForSeedBackup returns a Job that writes the encrypted backup to the destination PersistentVolumeClaim. The file
+// is written next to the previous backup and renamed over it, so an interrupted Job never leaves a partial backup. The
+// fingerprint is recorded in a .fingerprint file next to the backup, and the Job fails instead of replacing the
+// backup of a different fingerprint.
+func (r *SeedBackupReconciler) jobForSeedBackup(b *bitcoinv1alpha1.SeedBackup, key string, fingerprint string, encrypted string, revision string) *batchv1.Job {
+	script := `if [ -f "$BACKUP_FILE.fingerprint" ] && [ "$(cat "$BACKUP_FILE.fingerprint")" != "$FINGERPRINT" ]; then ` +
+		`echo "$BACKUP_FILE holds the backup of seed $(cat "$BACKUP_FILE.fingerprint")" > /dev/termination-log; exit 1; fi && ` +
+		`mkdir -p "$(dirname "$BACKUP_FILE")" && printf '%s\n' "$BACKUP" > "$BACKUP_FILE.tmp" && mv "$BACKUP_FILE.tmp" "$BACKUP_FILE" && ` +
+		`printf '%s\n' "$FINGERPRINT" > "$BACKUP_FILE.fingerprint"`
+	job := backupJob(b.Name+"-backup", b.Namespace, labelsForSeedBackup(b.Name), b.Spec.Destination, key, false, script,
+		corev1.EnvVar{Name: "BACKUP", Value: encrypted},
+		corev1.EnvVar{Name: "FINGERPRINT", Value: fingerprint})
+	job.Annotations = map[string]string{backupRevisionAnnotation: revision}
+
+	err := ctrl.SetControllerReference(b, job, r.Scheme)
+	if err != nil {
+		return nil
+	}
+	return job
+}
+
+// completeBackup records the backed up key material and the backup location in the SeedBackup status
+func (r *SeedBackupReconciler) completeBackup(ctx context.Context, b *bitcoinv1alpha1.SeedBackup, s *bitcoinv1alpha1.Seed, location string) error {
+	now := metav1.Now()
+	b.Status.Fingerprint = s.Status.Fingerprint
+	b.Status.Birthday = s.Status.Birthday
+	b.Status.Location = location
+	b.Status.CompletionTime = &now
+	return r.updateReadyCondition(ctx, b, metav1.ConditionTrue, bitcoinv1alpha1.ReasonReconciled, "backup of Seed "+s.Name+" written to "+location)
+}
+
+// updateReadyCondition records the Ready condition and the observed generation in the SeedBackup status
+func (r *SeedBackupReconciler) updateReadyCondition(ctx context.Context, b *bitcoinv1alpha1.SeedBackup, status metav1.ConditionStatus, reason string, message string) error {
+	b.Status.ObservedGeneration = b.Generation
+	meta.SetStatusCondition(&b.Status.Conditions, metav1.Condition{
+		Type:               bitcoinv1alpha1.ConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: b.Generation,
+	})
+	return r.Status().Update(ctx, b)
+}
+
+func labelsForSeedBackup(name string) map[string]string {
+	return map[string]string{"app": "seedbackup", "seedbackup_cr": name}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SeedBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&bitcoinv1alpha1.SeedBackup{}).
+		Owns(&batchv1.Job{}).
+		Watches(&source.Kind{Type: &bitcoinv1alpha1.Seed{}}, handler.EnqueueRequestsFromMapFunc(r.seedBackupsForSeed)).
+		Complete(r)
+}
+
+// seedBackupsForSeed maps a Seed to the SeedBackups that back it up, so a backup is taken once the Seed has key
+// material and again after a rotation
+func (r *SeedBackupReconciler) seedBackupsForSeed(seed client.Object) []reconcile.Request {
+	backups := &bitcoinv1alpha1.SeedBackupList{}
+	err := r.List(context.Background(), backups, client.InNamespace(seed.GetNamespace()))
+	if err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, b := range backups.Items {
+		if b.Spec.SeedName == seed.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: b.Name, Namespace: b.Namespace}})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,405 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	"filippo.io/age"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+var _ = Describe("SeedBackup controller", func() {
+
+	const Namespace = "test-backup-namespace"
+	const SeedName = "test"
+	const SecretName = "seed"
+	const BackupName = "test-backup"
+	const BackupConfigMapName = "seed-backups"
+	const PassphraseSecretName = "backup-passphrase"
+
+	ctx := context.Background()
+	seedNamespaceName := types.NamespacedName{Namespace: Namespace, Name: SeedName}
+	backupNamespacedName := types.NamespacedName{Namespace: Namespace, Name: BackupName}
+
+	reconcileSeed := func() {
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		for i := 0; i < 3; i++ {
+			_, err := seedReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: seedNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+	}
+
+	reconcileBackup := func() {
+		backupReconciler := SeedBackupReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err := backupReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: backupNamespacedName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+	}
+
+	BeforeEach(func() {
+		By("creating namespace to perform the tests")
+		_ = k8sClient.Create(ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      Namespace,
+				Namespace: Namespace,
+			},
+		})
+
+		By("creating a generated Seed to back up")
+		seed := &bitcoinv1alpha1.Seed{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SeedName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName:     SecretName,
+				Network:        "simnet",
+				DeletionPolicy: bitcoinv1alpha1.DeletionPolicyDelete,
+			},
+		}
+		Expect(k8sClient.Create(ctx, seed)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		By("cleaning up the Seed, the SeedBackup and what they created")
+		objects := []client.Object{
+			&bitcoinv1alpha1.SeedBackup{ObjectMeta: metav1.ObjectMeta{Name: BackupName, Namespace: Namespace}},
+			&bitcoinv1alpha1.Seed{ObjectMeta: metav1.ObjectMeta{Name: SeedName, Namespace: Namespace}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: SecretName, Namespace: Namespace}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: PassphraseSecretName, Namespace: Namespace}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: BackupConfigMapName, Namespace: Namespace}},
+			&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: BackupName + "-backup", Namespace: Namespace}},
+		}
+		for _, object := range objects {
+			_ = k8sClient.Delete(ctx, object, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		}
+	})
+
+	It("encrypting a Seed backup to an age recipient in a ConfigMap", func() {
+		identity, err := age.GenerateX25519Identity()
+		Expect(err).To(Not(HaveOccurred()))
+
+		backup := &bitcoinv1alpha1.SeedBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BackupName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedBackupSpec{
+				SeedName: SeedName,
+				Encryption: bitcoinv1alpha1.BackupEncryption{
+					Recipient: identity.Recipient().String(),
+				},
+				Destination: bitcoinv1alpha1.BackupLocation{
+					ConfigMap: &bitcoinv1alpha1.ConfigMapBackupLocation{Name: BackupConfigMapName},
+				},
+			},
+		}
+
+		By("creating the custom resource for the kind SeedBackup")
+		Expect(k8sClient.Create(ctx, backup)).To(Succeed())
+
+		By("waiting for the Seed to have key material")
+		reconcileBackup()
+		foundBackup := &bitcoinv1alpha1.SeedBackup{}
+		Expect(k8sClient.Get(ctx, backupNamespacedName, foundBackup)).To(Succeed())
+		readyCondition := meta.FindStatusCondition(foundBackup.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(readyCondition).To(Not(BeNil()))
+		Expect(readyCondition.Reason).To(Equal(bitcoinv1alpha1.ReasonSeedUnavailable))
+
+		By("reconciling the Seed and the SeedBackup")
+		reconcileSeed()
+		reconcileBackup()
+
+		foundSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		Expect(k8sClient.Get(ctx, backupNamespacedName, foundBackup)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(foundBackup.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeTrue())
+		Expect(foundBackup.Status.Fingerprint).To(Equal(foundSeed.Status.Fingerprint))
+		Expect(foundBackup.Status.Birthday).To(Not(BeNil()))
+		Expect(foundBackup.Status.Birthday.Unix()).To(Equal(foundSeed.Status.Birthday.Unix()))
+		Expect(foundBackup.Status.Location).To(Equal("configmap " + BackupConfigMapName + " key " + SeedName + ".age"))
+
+		By("checking if the ConfigMap holds a backup that only the identity decrypts")
+		configMap := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: Namespace, Name: BackupConfigMapName}, configMap)).To(Succeed())
+		encrypted := configMap.Data[SeedName+".age"]
+		Expect(encrypted).To(HavePrefix("-----BEGIN AGE ENCRYPTED FILE-----"))
+
+		secret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: Namespace, Name: SecretName}, secret)).To(Succeed())
+		Expect(encrypted).To(Not(ContainSubstring(strings.Fields(string(secret.Data["mnemonic"]))[0] + " ")))
+
+		decrypted, err := decryptSeedBackup(encrypted, identity)
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(decrypted.Name).To(Equal(SeedName))
+		Expect(decrypted.Fingerprint).To(Equal(foundSeed.Status.Fingerprint))
+		Expect(decrypted.Data["mnemonic"]).To(Equal(string(secret.Data["mnemonic"])))
+		Expect(decrypted.Data["rootkey"]).To(Equal(string(secret.Data["rootkey"])))
+
+		otherIdentity, err := age.GenerateX25519Identity()
+		Expect(err).To(Not(HaveOccurred()))
+		_, err = decryptSeedBackup(encrypted, otherIdentity)
+		Expect(err).To(HaveOccurred())
+
+		By("checking if the backup is not taken again")
+		reconcileBackup()
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: Namespace, Name: BackupConfigMapName}, configMap)).To(Succeed())
+		Expect(configMap.Data[SeedName+".age"]).To(Equal(encrypted))
+	})
+
+	It("keeping the backup of the retired key material after a rotation", func() {
+		DeferCleanup(func() {
+			_ = k8sClient.Delete(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: SecretName + "-v1", Namespace: Namespace}})
+		})
+
+		identity, err := age.GenerateX25519Identity()
+		Expect(err).To(Not(HaveOccurred()))
+
+		backup := &bitcoinv1alpha1.SeedBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BackupName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedBackupSpec{
+				SeedName: SeedName,
+				Encryption: bitcoinv1alpha1.BackupEncryption{
+					Recipient: identity.Recipient().String(),
+				},
+				Destination: bitcoinv1alpha1.BackupLocation{
+					ConfigMap: &bitcoinv1alpha1.ConfigMapBackupLocation{Name: BackupConfigMapName},
+				},
+			},
+		}
+
+		By("backing up the first version of the Seed")
+		Expect(k8sClient.Create(ctx, backup)).To(Succeed())
+		reconcileSeed()
+		reconcileBackup()
+
+		foundSeed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		previousFingerprint := foundSeed.Status.Fingerprint
+		configMap := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: Namespace, Name: BackupConfigMapName}, configMap)).To(Succeed())
+		previousBackup := configMap.Data[SeedName+".age"]
+		Expect(previousBackup).To(Not(BeEmpty()))
+
+		By("rotating the Seed and backing it up again")
+		foundSeed.Spec.RotationTrigger = "2023-06"
+		Expect(k8sClient.Update(ctx, foundSeed)).To(Succeed())
+		reconcileSeed()
+		reconcileBackup()
+
+		Expect(k8sClient.Get(ctx, seedNamespaceName, foundSeed)).To(Succeed())
+		Expect(foundSeed.Status.Fingerprint).To(Not(Equal(previousFingerprint)))
+		foundBackup := &bitcoinv1alpha1.SeedBackup{}
+		Expect(k8sClient.Get(ctx, backupNamespacedName, foundBackup)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(foundBackup.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeTrue())
+		Expect(foundBackup.Status.Location).To(Equal("configmap " + BackupConfigMapName + " key " + SeedName + "-v2.age"))
+
+		By("checking if the backup of the first version was kept and the new backup includes it")
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: Namespace, Name: BackupConfigMapName}, configMap)).To(Succeed())
+		Expect(configMap.Data[SeedName+".age"]).To(Equal(previousBackup))
+		decrypted, err := decryptSeedBackup(configMap.Data[SeedName+"-v2.age"], identity)
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(decrypted.Fingerprint).To(Equal(foundSeed.Status.Fingerprint))
+		Expect(decrypted.PreviousVersions).To(HaveLen(1))
+		Expect(decrypted.PreviousVersions[0].Version).To(Equal(int32(1)))
+		Expect(decrypted.PreviousVersions[0].Fingerprint).To(Equal(previousFingerprint))
+		Expect(decrypted.PreviousVersions[0].Data["mnemonic"]).To(Not(BeEmpty()))
+	})
+
+	It("refusing to replace the backup of a different seed", func() {
+		identity, err := age.GenerateX25519Identity()
+		Expect(err).To(Not(HaveOccurred()))
+
+		By("creating a ConfigMap that holds the backup of another seed")
+		Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BackupConfigMapName,
+				Namespace: Namespace,
+				Annotations: map[string]string{
+					backupFingerprintsAnnotation: `{"` + SeedName + `.age":"deadbeef"}`,
+				},
+			},
+			Data: map[string]string{SeedName + ".age": "other backup"},
+		})).To(Succeed())
+
+		backup := &bitcoinv1alpha1.SeedBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BackupName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedBackupSpec{
+				SeedName: SeedName,
+				Encryption: bitcoinv1alpha1.BackupEncryption{
+					Recipient: identity.Recipient().String(),
+				},
+				Destination: bitcoinv1alpha1.BackupLocation{
+					ConfigMap: &bitcoinv1alpha1.ConfigMapBackupLocation{Name: BackupConfigMapName},
+				},
+			},
+		}
+
+		By("reconciling the Seed and the SeedBackup")
+		Expect(k8sClient.Create(ctx, backup)).To(Succeed())
+		reconcileSeed()
+		reconcileBackup()
+
+		By("checking if the mismatch was reported and the other backup kept")
+		foundBackup := &bitcoinv1alpha1.SeedBackup{}
+		Expect(k8sClient.Get(ctx, backupNamespacedName, foundBackup)).To(Succeed())
+		condition := meta.FindStatusCondition(foundBackup.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(condition).To(Not(BeNil()))
+		Expect(condition.Reason).To(Equal(bitcoinv1alpha1.ReasonFingerprintMismatch))
+		configMap := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: Namespace, Name: BackupConfigMapName}, configMap)).To(Succeed())
+		Expect(configMap.Data[SeedName+".age"]).To(Equal("other backup"))
+	})
+
+	It("writing a passphrase encrypted Seed backup to a PersistentVolumeClaim with a Job", func() {
+		passphraseSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      PassphraseSecretName,
+				Namespace: Namespace,
+			},
+			StringData: map[string]string{"passphrase": "correct horse battery staple"},
+		}
+		Expect(k8sClient.Create(ctx, passphraseSecret)).To(Succeed())
+
+		backup := &bitcoinv1alpha1.SeedBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BackupName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedBackupSpec{
+				SeedName: SeedName,
+				Encryption: bitcoinv1alpha1.BackupEncryption{
+					PassphraseSecretRef: bitcoinv1alpha1.PassphraseSecretRef{SecretName: PassphraseSecretName},
+				},
+				Destination: bitcoinv1alpha1.BackupLocation{
+					PersistentVolumeClaim: &bitcoinv1alpha1.PersistentVolumeClaimBackupLocation{
+						ClaimName: "backups",
+						Path:      "wallets/lnd.age",
+					},
+				},
+			},
+		}
+
+		By("creating the custom resource for the kind SeedBackup")
+		Expect(k8sClient.Create(ctx, backup)).To(Succeed())
+
+		By("reconciling the Seed and the SeedBackup")
+		reconcileSeed()
+		reconcileBackup()
+
+		By("checking if a Job was created to write the backup")
+		job := &batchv1.Job{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: Namespace, Name: BackupName + "-backup"}, job)).To(Succeed())
+		container := job.Spec.Template.Spec.Containers[0]
+		Expect(container.Image).To(Equal("busybox:1.36"))
+		Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "BACKUP_FILE", Value: "/backup/wallets/lnd.age"}))
+		Expect(job.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName).To(Equal("backups"))
+
+		var encrypted string
+		for _, env := range container.Env {
+			if env.Name == "BACKUP" {
+				encrypted = env.Value
+			}
+		}
+		identity, err := age.NewScryptIdentity("correct horse battery staple")
+		Expect(err).To(Not(HaveOccurred()))
+		decrypted, err := decryptSeedBackup(encrypted, identity)
+		Expect(err).To(Not(HaveOccurred()))
+		Expect(decrypted.Name).To(Equal(SeedName))
+
+		foundBackup := &bitcoinv1alpha1.SeedBackup{}
+		Expect(k8sClient.Get(ctx, backupNamespacedName, foundBackup)).To(Succeed())
+		readyCondition := meta.FindStatusCondition(foundBackup.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(readyCondition).To(Not(BeNil()))
+		Expect(readyCondition.Reason).To(Equal(bitcoinv1alpha1.ReasonJobRunning))
+
+		By("completing the Job")
+		now := metav1.Now()
+		job.Status.StartTime = &now
+		job.Status.CompletionTime = &now
+		job.Status.Succeeded = 1
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+		reconcileBackup()
+
+		Expect(k8sClient.Get(ctx, backupNamespacedName, foundBackup)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(foundBackup.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeTrue())
+		Expect(foundBackup.Status.Location).To(Equal("persistentvolumeclaim backups path wallets/lnd.age"))
+		Expect(foundBackup.Status.CompletionTime).To(Not(BeNil()))
+	})
+
+	It("refusing to back up a sharded Seed", func() {
+		seed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, seed)).To(Succeed())
+		seed.Status.Fingerprint = "00000000"
+		Expect(k8sClient.Status().Update(ctx, seed)).To(Succeed())
+		seed.Spec.Sharding = &bitcoinv1alpha1.SeedSharding{Threshold: 2, Shares: 3}
+		Expect(k8sClient.Update(ctx, seed)).To(Succeed())
+
+		backup := &bitcoinv1alpha1.SeedBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BackupName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedBackupSpec{
+				SeedName: SeedName,
+				Encryption: bitcoinv1alpha1.BackupEncryption{
+					Recipient: "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p",
+				},
+				Destination: bitcoinv1alpha1.BackupLocation{
+					ConfigMap: &bitcoinv1alpha1.ConfigMapBackupLocation{Name: BackupConfigMapName},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, backup)).To(Succeed())
+		reconcileBackup()
+
+		foundBackup := &bitcoinv1alpha1.SeedBackup{}
+		Expect(k8sClient.Get(ctx, backupNamespacedName, foundBackup)).To(Succeed())
+		readyCondition := meta.FindStatusCondition(foundBackup.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(readyCondition).To(Not(BeNil()))
+		Expect(readyCondition.Reason).To(Equal(bitcoinv1alpha1.ReasonInvalidSpec))
+		Expect(readyCondition.Message).To(ContainSubstring("sharded"))
+	})
+})
@@ -0,0 +1,364 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// maxTerminationMessageBytes is the size Kubernetes truncates container termination messages to
+const maxTerminationMessageBytes = 4096
+
+// restoreGenerationAnnotation records on a restore Job the SeedRestore generation it reads the backup for
+const restoreGenerationAnnotation = "bitcoin.kiln-fired.github.io/restore-generation"
+
+// SeedRestoreReconciler reconciles a SeedRestore object
+type SeedRestoreReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=seedrestores,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=seedrestores/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=bitcoin.kiln-fired.github.io,resources=seedrestores/finalizers,verbs=update
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+
+func (r *SeedRestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrllog.FromContext(ctx)
+	restore := &bitcoinv1alpha1.SeedRestore{}
+	err := r.Get(ctx, req.NamespacedName, restore)
+
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("SeedRestore resource not found.")
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to get SeedRestore")
+		return ctrl.Result{}, err
+	}
+
+	// A restore runs once per generation, the restored Seed is not touched afterwards
+	if restore.Status.ObservedGeneration == restore.Generation && meta.IsStatusConditionTrue(restore.Status.Conditions, bitcoinv1alpha1.ConditionReady) {
+		return ctrl.Result{}, nil
+	}
+
+	err = validateSeedRestoreSpec(restore.Spec)
+
+	if err != nil {
+		log.Error(err, "Invalid SeedRestore spec")
+		return ctrl.Result{}, r.updateReadyCondition(ctx, restore, metav1.ConditionFalse, bitcoinv1alpha1.ReasonInvalidSpec, err.Error())
+	}
+
+	seedName := restore.Spec.SeedName
+	if seedName == "" {
+		seedName = restore.Name
+	}
+	location := describeBackupLocation(restore.Spec.Source, backupFileName(restore.Spec.Source, seedName))
+
+	encrypted, reason, message, err := r.readBackup(ctx, restore, seedName, location)
+
+	if err != nil {
+		log.Error(err, "Failed to read backup", "Location", location)
+		return ctrl.Result{}, err
+	} else if encrypted == "" {
+		return ctrl.Result{}, r.updateReadyCondition(ctx, restore, metav1.ConditionFalse, reason, message)
+	}
+
+	identity, err := backupIdentity(ctx, r.Client, restore.Namespace, restore.Spec.Decryption)
+
+	if err != nil {
+		log.Error(err, "Failed to configure backup decryption")
+		if statusErr := r.updateReadyCondition(ctx, restore, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSecretRefUnavailable, err.Error()); statusErr != nil {
+			log.Error(statusErr, "Failed to update SeedRestore status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	backup, err := decryptSeedBackup(encrypted, identity)
+
+	if err != nil {
+		log.Error(err, "Failed to decrypt backup", "Location", location)
+		return ctrl.Result{}, r.updateReadyCondition(ctx, restore, metav1.ConditionFalse, bitcoinv1alpha1.ReasonBackupUnavailable, "backup in "+location+" cannot be decrypted: "+err.Error())
+	}
+
+	network, err := bitcoinv1alpha1.LookupNetwork(backup.Spec.Network)
+
+	if err != nil {
+		log.Error(err, "Failed to look up network")
+		return ctrl.Result{}, r.updateReadyCondition(ctx, restore, metav1.ConditionFalse, bitcoinv1alpha1.ReasonUnsupportedNetwork, err.Error())
+	}
+
+	material, hdkey, err := verifySeedBackup(backup, network)
+
+	if err != nil {
+		log.Error(err, "Backup failed verification", "Location", location)
+		return ctrl.Result{}, r.updateReadyCondition(ctx, restore, metav1.ConditionFalse, bitcoinv1alpha1.ReasonFingerprintMismatch, err.Error())
+	}
+
+	restore.Status.SeedName = seedName
+	restore.Status.Fingerprint = backup.Fingerprint
+	restore.Status.Birthday = nil
+	if material.cipherSeed != nil {
+		birthday := metav1.NewTime(material.cipherSeed.BirthdayTime())
+		restore.Status.Birthday = &birthday
+	}
+
+	seed := r.seedForSeedRestore(restore, seedName, backup)
+	foundSeed := &bitcoinv1alpha1.Seed{}
+	err = r.Get(ctx, types.NamespacedName{Name: seed.Name, Namespace: seed.Namespace}, foundSeed)
+
+	if err == nil {
+		if foundSeed.Status.Fingerprint == backup.Fingerprint {
+			now := metav1.Now()
+			restore.Status.CompletionTime = &now
+			return ctrl.Result{}, r.updateReadyCondition(ctx, restore, metav1.ConditionTrue, bitcoinv1alpha1.ReasonReconciled, "Seed "+seed.Name+" restored from "+location)
+		}
+		if foundSeed.Annotations[bitcoinv1alpha1.RestoredFromAnnotation] != restore.Name {
+			return ctrl.Result{}, r.updateReadyCondition(ctx, restore, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSecretConflict, "Seed "+seed.Name+" already exists and was not restored from this backup")
+		}
+		return ctrl.Result{}, r.updateReadyCondition(ctx, restore, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSeedUnavailable, "waiting for Seed "+seed.Name+" to adopt the restored key material")
+	} else if !errors.IsNotFound(err) {
+		log.Error(err, "Failed to get Seed")
+		return ctrl.Result{}, err
+	}
+
+	store, err := storeForSeed(ctx, r.Client, r.Scheme, seed)
+
+	if err != nil {
+		log.Error(err, "Failed to configure Seed storage")
+		if statusErr := r.updateReadyCondition(ctx, restore, metav1.ConditionFalse, bitcoinv1alpha1.ReasonStorageUnavailable, err.Error()); statusErr != nil {
+			log.Error(statusErr, "Failed to update SeedRestore status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	// The key material is stored before the Seed exists and marked as retained, so the new Seed adopts it instead
+	// of generating a mnemonic of its own
+	stored, err := store.Get(ctx, seed)
+
+	if err != nil && isSeedNotStored(err) {
+		addresses, err := deriveAddresses(hdkey, seed.Spec.Addresses, network.Params)
+		if err != nil {
+			log.Error(err, "Failed to derive addresses")
+			return ctrl.Result{}, err
+		}
+
+		expected, err := storedSeedFor(seed, material, hdkey, addresses)
+		if err != nil {
+			log.Error(err, "Failed to compute stored Seed")
+			return ctrl.Result{}, err
+		}
+		expected.Annotations[bitcoinv1alpha1.RetainedFingerprintAnnotation] = backup.Fingerprint
+		expected.Annotations[bitcoinv1alpha1.RetainedSeedAnnotation] = seed.Name
+
+		log.Info("Storing restored Seed", "Location", store.Describe(seed))
+		err = store.Create(ctx, seed, expected)
+		if err != nil {
+			log.Error(err, "Failed to store restored Seed", "Location", store.Describe(seed))
+			return ctrl.Result{}, err
+		}
+	} else if err != nil {
+		log.Error(err, "Failed to get stored Seed", "Location", store.Describe(seed))
+		if statusErr := r.updateReadyCondition(ctx, restore, metav1.ConditionFalse, bitcoinv1alpha1.ReasonStorageUnavailable, err.Error()); statusErr != nil {
+			log.Error(statusErr, "Failed to update SeedRestore status")
+		}
+		return ctrl.Result{}, err
+	} else if stored.Annotations[bitcoinv1alpha1.RetainedFingerprintAnnotation] != backup.Fingerprint {
+		message := store.Describe(seed) + " exists and does not hold the backed up key material"
+		return ctrl.Result{}, r.updateReadyCondition(ctx, restore, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSecretConflict, message)
+	}
+
+	log.Info("Creating a new Seed", "Seed.Namespace", seed.Namespace, "Seed.Name", seed.Name)
+	err = r.Create(ctx, seed)
+	if err != nil {
+		log.Error(err, "Failed to create new Seed", "Seed.Namespace", seed.Namespace, "Seed.Name", seed.Name)
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, r.updateReadyCondition(ctx, restore, metav1.ConditionFalse, bitcoinv1alpha1.ReasonSeedUnavailable, "waiting for Seed "+seed.Name+" to adopt the restored key material")
+}
+
+// readBackup returns the encrypted backup. While the backup cannot be read it returns the reason and a message that
+// explains why instead.
+func (r *SeedRestoreReconciler) readBackup(ctx context.Context, restore *bitcoinv1alpha1.SeedRestore, seedName string, location string) (string, string, string, error) {
+	log := ctrllog.FromContext(ctx)
+	source := restore.Spec.Source
+	key := backupFileName(source, seedName)
+
+	if source.ConfigMap != nil {
+		configMap := &corev1.ConfigMap{}
+		err := r.Get(ctx, types.NamespacedName{Name: source.ConfigMap.Name, Namespace: restore.Namespace}, configMap)
+		if err != nil && errors.IsNotFound(err) {
+			return "", bitcoinv1alpha1.ReasonBackupUnavailable, "configmap " + source.ConfigMap.Name + " does not exist", nil
+		} else if err != nil {
+			return "", "", "", err
+		}
+
+		encrypted, ok := configMap.Data[key]
+		if !ok {
+			return "", bitcoinv1alpha1.ReasonBackupUnavailable, "configmap " + source.ConfigMap.Name + " does not contain key " + key, nil
+		}
+		return encrypted, "", "", nil
+	}
+
+	generation := strconv.FormatInt(restore.Generation, 10)
+	job := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: restore.Name + "-restore", Namespace: restore.Namespace}, job)
+
+	if err != nil && errors.IsNotFound(err) {
+		job = r.jobForSeedRestore(restore, seedName, generation)
+		log.Info("Creating a new Job", "Job.Namespace", job.Namespace, "Job.Name", job.Name)
+		err = r.Create(ctx, job)
+		if err != nil {
+			return "", "", "", err
+		}
+		return "", bitcoinv1alpha1.ReasonJobRunning, "waiting for Job " + job.Name + " to read the backup", nil
+	} else if err != nil {
+		return "", "", "", err
+	}
+
+	if job.Annotations[restoreGenerationAnnotation] != generation {
+		log.Info("Deleting outdated restore Job", "Job.Namespace", job.Namespace, "Job.Name", job.Name)
+		err = r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil && !errors.IsNotFound(err) {
+			return "", "", "", err
+		}
+		return "", bitcoinv1alpha1.ReasonJobRunning, "waiting for outdated Job " + job.Name + " to be deleted", nil
+	}
+
+	finished, succeeded := jobFinished(job)
+	if !finished {
+		return "", bitcoinv1alpha1.ReasonJobRunning, "waiting for Job " + job.Name + " to read the backup", nil
+	}
+
+	// The Job reports the backup, or why it could not read it, in the termination message of its container
+	pods := &corev1.PodList{}
+	err = r.List(ctx, pods, client.InNamespace(restore.Namespace), client.MatchingLabels{"job-name": job.Name})
+	if err != nil {
+		return "", "", "", err
+	}
+
+	message := "Job " + job.Name + " failed to read the backup from " + location
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.State.Terminated
+			if terminated == nil || terminated.Message == "" {
+				continue
+			}
+			if succeeded && terminated.ExitCode == 0 {
+				return terminated.Message, "", "", nil
+			}
+			if !succeeded && terminated.ExitCode != 0 {
+				message += ": " + strings.TrimSpace(terminated.Message)
+			}
+		}
+	}
+	return "", bitcoinv1alpha1.ReasonJobFailed, message, nil
+}
+
+// jobForSeedRestore returns a Job that reads the backup from the source PersistentVolumeClaim and reports it in its
+// termination message. Kubernetes truncates termination messages to 4096 bytes, so the Job fails with a message that
+// explains why instead of reporting a larger backup.
+func (r *SeedRestoreReconciler) jobForSeedRestore(restore *bitcoinv1alpha1.SeedRestore, seedName string, generation string) *batchv1.Job {
+	script := fmt.Sprintf(`size=$(wc -c < "$BACKUP_FILE") || exit 1; `+
+		`if [ "$size" -gt %d ]; then echo "$BACKUP_FILE holds $size bytes, more than the %d bytes a restore Job can report" > /dev/termination-log; exit 1; fi; `+
+		`cat "$BACKUP_FILE" > /dev/termination-log`, maxTerminationMessageBytes, maxTerminationMessageBytes)
+	job := backupJob(restore.Name+"-restore", restore.Namespace, labelsForSeedRestore(restore.Name), restore.Spec.Source, backupFileName(restore.Spec.Source, seedName), true, script)
+	job.Annotations = map[string]string{restoreGenerationAnnotation: generation}
+
+	err := ctrl.SetControllerReference(restore, job, r.Scheme)
+	if err != nil {
+		return nil
+	}
+	return job
+}
+
+// seedForSeedRestore returns the Seed recreated from a backup. It is not owned by the SeedRestore, so deleting the
+// SeedRestore keeps the Seed.
+func (r *SeedRestoreReconciler) seedForSeedRestore(restore *bitcoinv1alpha1.SeedRestore, seedName string, backup *seedBackup) *bitcoinv1alpha1.Seed {
+	seed := &bitcoinv1alpha1.Seed{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      seedName,
+			Namespace: restore.Namespace,
+			Annotations: map[string]string{
+				bitcoinv1alpha1.RestoredFromAnnotation: restore.Name,
+			},
+		},
+		Spec: backup.Spec,
+	}
+
+	if restore.Spec.SecretName != "" {
+		seed.Spec.SecretName = restore.Spec.SecretName
+	}
+	return seed
+}
+
+// updateReadyCondition records the Ready condition and the observed generation in the SeedRestore status
+func (r *SeedRestoreReconciler) updateReadyCondition(ctx context.Context, restore *bitcoinv1alpha1.SeedRestore, status metav1.ConditionStatus, reason string, message string) error {
+	restore.Status.ObservedGeneration = restore.Generation
+	meta.SetStatusCondition(&restore.Status.Conditions, metav1.Condition{
+		Type:               bitcoinv1alpha1.ConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: restore.Generation,
+	})
+	return r.Status().Update(ctx, restore)
+}
+
+func labelsForSeedRestore(name string) map[string]string {
+	return map[string]string{"app": "seedrestore", "seedrestore_cr": name}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SeedRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&bitcoinv1alpha1.SeedRestore{}).
+		Owns(&batchv1.Job{}).
+		Watches(&source.Kind{Type: &bitcoinv1alpha1.Seed{}}, handler.EnqueueRequestsFromMapFunc(seedRestoreForSeed)).
+		Complete(r)
+}
+
+// seedRestoreForSeed maps a restored Seed to its SeedRestore, so the restore completes once the Seed adopted the
+// restored key material
+func seedRestoreForSeed(seed client.Object) []reconcile.Request {
+	name, ok := seed.GetAnnotations()[bitcoinv1alpha1.RestoredFromAnnotation]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: seed.GetNamespace()}}}
+}
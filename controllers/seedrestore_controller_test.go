@@ -0,0 +1,366 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"filippo.io/age"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+var _ = Describe("SeedRestore controller", func() {
+
+	const Namespace = "test-restore-namespace"
+	const SeedName = "restored"
+	const SecretName = "restored-seed"
+	const RestoreName = "test-restore"
+	const BackupConfigMapName = "seed-backups"
+	const IdentitySecretName = "backup-identity"
+	const Mnemonic = "above pioneer library glimpse exhibit analyst monitor holiday boil art ketchup mail hunt since now pattern vacant arch museum tourist brisk come pilot devote"
+	const Passphrase = "test"
+
+	ctx := context.Background()
+	seedNamespaceName := types.NamespacedName{Namespace: Namespace, Name: SeedName}
+	restoreNamespacedName := types.NamespacedName{Namespace: Namespace, Name: RestoreName}
+	var identity *age.X25519Identity
+
+	// backupOf returns the backup the SeedBackup controller writes for the sample Seed
+	backupOf := func() *seedBackup {
+		material, err := newSeedMaterial(NewSecretGenerator(), time.Now(), bitcoinv1alpha1.SeedFormatAezeed, Mnemonic, Passphrase, 0)
+		Expect(err).To(Not(HaveOccurred()))
+		hdkey, err := hdkeychain.NewMaster(material.seed, &chaincfg.SimNetParams)
+		Expect(err).To(Not(HaveOccurred()))
+		fingerprint, err := masterFingerprint(hdkey)
+		Expect(err).To(Not(HaveOccurred()))
+		birthday := metav1.NewTime(material.cipherSeed.BirthdayTime())
+
+		return &seedBackup{
+			Version: seedBackupVersion,
+			Name:    "lnd",
+			Spec: bitcoinv1alpha1.SeedSpec{
+				SecretName: "seed",
+				Format:     bitcoinv1alpha1.SeedFormatAezeed,
+				Network:    "simnet",
+				Addresses:  []bitcoinv1alpha1.AddressDerivation{{Type: "p2wkh", AccountCount: 1, Count: 1}},
+			},
+			Fingerprint: fingerprint,
+			Birthday:    &birthday,
+			Data: map[string]string{
+				"mnemonic":   Mnemonic,
+				"passphrase": Passphrase,
+				"rootkey":    hdkey.String(),
+			},
+		}
+	}
+
+	writeBackup := func(backup *seedBackup) {
+		encrypted, err := encryptSeedBackup(backup, identity.Recipient())
+		Expect(err).To(Not(HaveOccurred()))
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BackupConfigMapName,
+				Namespace: Namespace,
+			},
+			Data: map[string]string{SeedName + ".age": encrypted},
+		}
+		Expect(k8sClient.Create(ctx, configMap)).To(Succeed())
+	}
+
+	createRestore := func() {
+		restore := &bitcoinv1alpha1.SeedRestore{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      RestoreName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedRestoreSpec{
+				Source: bitcoinv1alpha1.BackupLocation{
+					ConfigMap: &bitcoinv1alpha1.ConfigMapBackupLocation{Name: BackupConfigMapName},
+				},
+				Decryption: bitcoinv1alpha1.BackupDecryption{
+					IdentitySecretRef: bitcoinv1alpha1.IdentitySecretRef{SecretName: IdentitySecretName},
+				},
+				SeedName:   SeedName,
+				SecretName: SecretName,
+			},
+		}
+		Expect(k8sClient.Create(ctx, restore)).To(Succeed())
+	}
+
+	reconcileRestore := func() {
+		restoreReconciler := SeedRestoreReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err := restoreReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: restoreNamespacedName,
+		})
+		Expect(err).To(Not(HaveOccurred()))
+	}
+
+	BeforeEach(func() {
+		By("creating namespace to perform the tests")
+		_ = k8sClient.Create(ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      Namespace,
+				Namespace: Namespace,
+			},
+		})
+
+		By("creating the secret with the age identity of the backups")
+		var err error
+		identity, err = age.GenerateX25519Identity()
+		Expect(err).To(Not(HaveOccurred()))
+		identitySecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      IdentitySecretName,
+				Namespace: Namespace,
+			},
+			StringData: map[string]string{"identity": identity.String()},
+		}
+		Expect(k8sClient.Create(ctx, identitySecret)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		By("cleaning up the SeedRestore and what it restored")
+		seed := &bitcoinv1alpha1.Seed{}
+		if k8sClient.Get(ctx, seedNamespaceName, seed) == nil {
+			seed.Finalizers = nil
+			Expect(k8sClient.Update(ctx, seed)).To(Succeed())
+		}
+
+		objects := []client.Object{
+			&bitcoinv1alpha1.SeedRestore{ObjectMeta: metav1.ObjectMeta{Name: RestoreName, Namespace: Namespace}},
+			&bitcoinv1alpha1.Seed{ObjectMeta: metav1.ObjectMeta{Name: SeedName, Namespace: Namespace}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: SecretName, Namespace: Namespace}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: IdentitySecretName, Namespace: Namespace}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: BackupConfigMapName, Namespace: Namespace}},
+		}
+		for _, object := range objects {
+			_ = k8sClient.Delete(ctx, object)
+		}
+	})
+
+	It("recreating a Seed and its secret from a backup", func() {
+		backup := backupOf()
+		writeBackup(backup)
+
+		By("creating the custom resource for the kind SeedRestore")
+		createRestore()
+
+		By("reconciling the custom resource created")
+		reconcileRestore()
+
+		By("checking if the secret was recreated for the Seed to adopt")
+		secret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: Namespace, Name: SecretName}, secret)).To(Succeed())
+		Expect(string(secret.Data["mnemonic"])).To(Equal(Mnemonic))
+		Expect(string(secret.Data["rootkey"])).To(Equal(backup.Data["rootkey"]))
+		Expect(secret.Annotations[bitcoinv1alpha1.RetainedFingerprintAnnotation]).To(Equal(backup.Fingerprint))
+		Expect(secret.Annotations[bitcoinv1alpha1.RetainedSeedAnnotation]).To(Equal(SeedName))
+		Expect(secret.Data).To(HaveKey("p2wkhAddress"))
+
+		By("checking if the Seed was recreated")
+		seed := &bitcoinv1alpha1.Seed{}
+		Expect(k8sClient.Get(ctx, seedNamespaceName, seed)).To(Succeed())
+		Expect(seed.Spec.SecretName).To(Equal(SecretName))
+		Expect(seed.Spec.Mnemonic).To(BeEmpty())
+		Expect(seed.Annotations[bitcoinv1alpha1.RestoredFromAnnotation]).To(Equal(RestoreName))
+
+		foundRestore := &bitcoinv1alpha1.SeedRestore{}
+		Expect(k8sClient.Get(ctx, restoreNamespacedName, foundRestore)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(foundRestore.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeFalse())
+
+		By("reconciling the restored Seed")
+		seedReconciler := SeedReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		for i := 0; i < 3; i++ {
+			_, err := seedReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: seedNamespaceName,
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		Expect(k8sClient.Get(ctx, seedNamespaceName, seed)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(seed.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeTrue())
+		Expect(seed.Status.Fingerprint).To(Equal(backup.Fingerprint))
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: Namespace, Name: SecretName}, secret)).To(Succeed())
+		Expect(metav1.IsControlledBy(secret, seed)).To(BeTrue())
+
+		By("checking if the SeedRestore reports the restored fingerprint and birthday")
+		reconcileRestore()
+		Expect(k8sClient.Get(ctx, restoreNamespacedName, foundRestore)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(foundRestore.Status.Conditions, bitcoinv1alpha1.ConditionReady)).To(BeTrue())
+		Expect(foundRestore.Status.SeedName).To(Equal(SeedName))
+		Expect(foundRestore.Status.Fingerprint).To(Equal(backup.Fingerprint))
+		Expect(foundRestore.Status.Birthday.Unix()).To(Equal(backup.Birthday.Unix()))
+		Expect(foundRestore.Status.CompletionTime).To(Not(BeNil()))
+	})
+
+	DescribeTable("refusing a backup that fails verification",
+		func(tamper func(backup *seedBackup), expectedMessage string) {
+			backup := backupOf()
+			tamper(backup)
+			writeBackup(backup)
+			createRestore()
+
+			reconcileRestore()
+
+			foundRestore := &bitcoinv1alpha1.SeedRestore{}
+			Expect(k8sClient.Get(ctx, restoreNamespacedName, foundRestore)).To(Succeed())
+			readyCondition := meta.FindStatusCondition(foundRestore.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+			Expect(readyCondition).To(Not(BeNil()))
+			Expect(readyCondition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(readyCondition.Reason).To(Equal(bitcoinv1alpha1.ReasonFingerprintMismatch))
+			Expect(readyCondition.Message).To(ContainSubstring(expectedMessage))
+
+			By("checking if nothing was restored")
+			Expect(k8sClient.Get(ctx, seedNamespaceName, &bitcoinv1alpha1.Seed{})).To(Not(Succeed()))
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: Namespace, Name: SecretName}, &corev1.Secret{})).To(Not(Succeed()))
+		},
+		Entry(
+			"when the fingerprint does not match the rootkey",
+			func(backup *seedBackup) {
+				backup.Fingerprint = "00000000"
+			},
+			"fingerprint",
+		),
+		Entry(
+			"when the rootkey does not match the mnemonic",
+			func(backup *seedBackup) {
+				other, err := hdkeychain.NewMaster(make([]byte, 32), &chaincfg.SimNetParams)
+				Expect(err).To(Not(HaveOccurred()))
+				backup.Data["rootkey"] = other.String()
+			},
+			"rootkey",
+		),
+		Entry(
+			"when the passphrase does not decrypt the mnemonic",
+			func(backup *seedBackup) {
+				backup.Data["passphrase"] = "wrong"
+			},
+			"cannot be decoded",
+		),
+	)
+
+	It("reporting a backup that cannot be decrypted", func() {
+		writeBackup(backupOf())
+
+		By("replacing the identity with one the backup was not encrypted to")
+		otherIdentity, err := age.GenerateX25519Identity()
+		Expect(err).To(Not(HaveOccurred()))
+		identitySecret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: Namespace, Name: IdentitySecretName}, identitySecret)).To(Succeed())
+		identitySecret.Data["identity"] = []byte(otherIdentity.String())
+		Expect(k8sClient.Update(ctx, identitySecret)).To(Succeed())
+
+		createRestore()
+		reconcileRestore()
+
+		foundRestore := &bitcoinv1alpha1.SeedRestore{}
+		Expect(k8sClient.Get(ctx, restoreNamespacedName, foundRestore)).To(Succeed())
+		readyCondition := meta.FindStatusCondition(foundRestore.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(readyCondition).To(Not(BeNil()))
+		Expect(readyCondition.Reason).To(Equal(bitcoinv1alpha1.ReasonBackupUnavailable))
+		Expect(k8sClient.Get(ctx, seedNamespaceName, &bitcoinv1alpha1.Seed{})).To(Not(Succeed()))
+	})
+
+	It("reporting a backup that is too large for the restore Job", func() {
+		jobNamespacedName := types.NamespacedName{Namespace: Namespace, Name: RestoreName + "-restore"}
+		DeferCleanup(func() {
+			_ = k8sClient.Delete(ctx, &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: jobNamespacedName.Name, Namespace: Namespace}})
+			_ = k8sClient.Delete(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: jobNamespacedName.Name + "-pod", Namespace: Namespace}})
+		})
+
+		By("creating the custom resource for the kind SeedRestore")
+		restore := &bitcoinv1alpha1.SeedRestore{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      RestoreName,
+				Namespace: Namespace,
+			},
+			Spec: bitcoinv1alpha1.SeedRestoreSpec{
+				Source: bitcoinv1alpha1.BackupLocation{
+					PersistentVolumeClaim: &bitcoinv1alpha1.PersistentVolumeClaimBackupLocation{
+						ClaimName: "backups",
+						Image:     "busybox:1.36",
+					},
+				},
+				Decryption: bitcoinv1alpha1.BackupDecryption{
+					IdentitySecretRef: bitcoinv1alpha1.IdentitySecretRef{SecretName: IdentitySecretName},
+				},
+				SeedName:   SeedName,
+				SecretName: SecretName,
+			},
+		}
+		Expect(k8sClient.Create(ctx, restore)).To(Succeed())
+		reconcileRestore()
+
+		By("checking if the Job refuses to report a backup larger than a termination message")
+		job := &batchv1.Job{}
+		Expect(k8sClient.Get(ctx, jobNamespacedName, job)).To(Succeed())
+		Expect(job.Spec.Template.Spec.Containers[0].Command[2]).To(ContainSubstring(`-gt 4096`))
+
+		By("failing the Job with the size of the backup")
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+		Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      jobNamespacedName.Name + "-pod",
+				Namespace: Namespace,
+				Labels:    map[string]string{"job-name": jobNamespacedName.Name},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "backup", Image: "busybox:1.36"}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name: "backup",
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{
+					ExitCode: 1,
+					Message:  "/backup/test.age holds 5120 bytes, more than the 4096 bytes a restore Job can report\n",
+				},
+			},
+		}}
+		Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+		reconcileRestore()
+
+		foundRestore := &bitcoinv1alpha1.SeedRestore{}
+		Expect(k8sClient.Get(ctx, restoreNamespacedName, foundRestore)).To(Succeed())
+		readyCondition := meta.FindStatusCondition(foundRestore.Status.Conditions, bitcoinv1alpha1.ConditionReady)
+		Expect(readyCondition).To(Not(BeNil()))
+		Expect(readyCondition.Reason).To(Equal(bitcoinv1alpha1.ReasonJobFailed))
+		Expect(readyCondition.Message).To(HaveSuffix("holds 5120 bytes, more than the 4096 bytes a restore Job can report"))
+		Expect(k8sClient.Get(ctx, seedNamespaceName, &bitcoinv1alpha1.Seed{})).To(Not(Succeed()))
+	})
+})
@@ -0,0 +1,112 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"io"
+)
+
+// Shamir secret sharing over GF(2^8). Every byte of the secret is the constant term of its own random polynomial
+// of degree threshold-1, and share i holds the evaluation of every polynomial at x = i. Index 0 is the secret.
+
+// gfMul multiplies two elements of GF(2^8) reduced by the AES polynomial x^8 + x^4 + x^3 + x + 1
+func gfMul(a byte, b byte) byte {
+	var p byte
+	for b > 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return p
+}
+
+// gfInv returns the multiplicative inverse of a non-zero element of GF(2^8), a^254
+func gfInv(a byte) byte {
+	inv := byte(1)
+	for i := 0; i < 254; i++ {
+		inv = gfMul(inv, a)
+	}
+	return inv
+}
+
+// splitSecret splits a secret into shares indexed 1 to count, any threshold of which recover it
+func splitSecret(secret []byte, count int, threshold int, random io.Reader) (map[byte][]byte, error) {
+	if threshold < 2 || threshold > count || count > 255 {
+		return nil, errors.New("shares must satisfy 2 <= threshold <= shares <= 255")
+	}
+
+	coefficients := make([]byte, threshold-1)
+	shares := map[byte][]byte{}
+	for x := 1; x <= count; x++ {
+		shares[byte(x)] = make([]byte, len(secret))
+	}
+
+	for i, value := range secret {
+		if _, err := io.ReadFull(random, coefficients); err != nil {
+			return nil, err
+		}
+
+		for x, share := range shares {
+			// Horner's method, highest coefficient first
+			y := byte(0)
+			for c := len(coefficients) - 1; c >= 0; c-- {
+				y = gfMul(y, x) ^ coefficients[c]
+			}
+			share[i] = gfMul(y, x) ^ value
+		}
+	}
+
+	return shares, nil
+}
+
+// interpolateShares evaluates the polynomials that pass through the given shares at x, which recovers the
+// secret for x = 0 and recreates a lost share for any other index
+func interpolateShares(shares map[byte][]byte, x byte) ([]byte, error) {
+	length := -1
+	for _, share := range shares {
+		if length >= 0 && len(share) != length {
+			return nil, errors.New("shares have different lengths")
+		}
+		length = len(share)
+	}
+	if length < 0 {
+		return nil, errors.New("no shares to interpolate")
+	}
+
+	result := make([]byte, length)
+	for xj, share := range shares {
+		// Lagrange basis polynomial of xj evaluated at x, subtraction is addition in GF(2^8)
+		basis := byte(1)
+		for xm := range shares {
+			if xm != xj {
+				basis = gfMul(basis, gfMul(x^xm, gfInv(xj^xm)))
+			}
+		}
+		for i := range result {
+			result[i] ^= gfMul(share[i], basis)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// vaultOwnerMetadata is the custom metadata key that records the UID of the Seed owning a Vault secret
+const vaultOwnerMetadata = "bitcoin.kiln-fired.github.io/owner"
+
+var vaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// vaultSeedStore stores key material in a HashiCorp Vault KV version 2 secrets engine. Annotations and ownership
+// are kept in the custom metadata of the Vault secret.
+type vaultSeedStore struct {
+	address string
+	mount   string
+	path    string
+	token   string
+}
+
+func newVaultSeedStore(storage bitcoinv1alpha1.SeedStorage, token string) *vaultSeedStore {
+	mount := strings.Trim(storage.Mount, "/")
+	if mount == "" {
+		mount = "secret"
+	}
+
+	return &vaultSeedStore{
+		address: strings.TrimSuffix(storage.Address, "/"),
+		mount:   mount,
+		path:    strings.Trim(storage.Path, "/"),
+		token:   token,
+	}
+}
+
+// vaultSecret is the part of a KV version 2 read response used by the store
+type vaultSecret struct {
+	Data struct {
+		Data     map[string]string `json:"data"`
+		Metadata struct {
+			CustomMetadata map[string]string `json:"custom_metadata"`
+		} `json:"metadata"`
+	} `json:"data"`
+}
+
+// vaultMetadata is the part of a KV version 2 metadata read response used by the store
+type vaultMetadata struct {
+	Data struct {
+		CurrentVersion int               `json:"current_version"`
+		CustomMetadata map[string]string `json:"custom_metadata"`
+	} `json:"data"`
+}
+
+func (v *vaultSeedStore) Get(ctx context.Context, s *bitcoinv1alpha1.Seed) (*StoredSeed, error) {
+	secret := vaultSecret{}
+	err := v.do(ctx, http.MethodGet, "data", s, nil, &secret)
+	if err != nil {
+		return nil, err
+	}
+	if secret.Data.Data == nil {
+		// The latest version was deleted
+		return nil, ErrSeedNotStored
+	}
+
+	annotations := map[string]string{}
+	for key, value := range secret.Data.Metadata.CustomMetadata {
+		annotations[key] = value
+	}
+	owner := annotations[vaultOwnerMetadata]
+	delete(annotations, vaultOwnerMetadata)
+
+	return &StoredSeed{
+		Data:        secret.Data.Data,
+		Annotations: annotations,
+		Owned:       owner == string(s.UID),
+	}, nil
+}
+
+func (v *vaultSeedStore) Create(ctx context.Context, s *bitcoinv1alpha1.Seed, stored *StoredSeed) error {
+	// A path that has versions, even deleted ones, or that another Seed claimed is refused before anything is written,
+	// so its recorded owner is never overwritten by a write that the check-and-set below would reject
+	metadata := vaultMetadata{}
+	err := v.do(ctx, http.MethodGet, "metadata", s, nil, &metadata)
+	if err != nil && !isSeedNotStored(err) {
+		return err
+	}
+	if err == nil {
+		owner := metadata.Data.CustomMetadata[vaultOwnerMetadata]
+		if metadata.Data.CurrentVersion > 0 || (owner != "" && owner != string(s.UID)) {
+			return fmt.Errorf("%s already exists", v.Describe(s))
+		}
+	}
+
+	// The owner is recorded before the data, so a failed data write leaves a path without data that the next
+	// attempt writes again, instead of data without an owner that no Seed can adopt
+	err = v.writeMetadata(ctx, s, stored.Annotations, string(s.UID))
+	if err != nil {
+		return err
+	}
+
+	// A check-and-set version of 0 only writes the secret if it does not exist yet
+	body := map[string]interface{}{
+		"data":    stored.Data,
+		"options": map[string]interface{}{"cas": 0},
+	}
+	return v.do(ctx, http.MethodPost, "data", s, body, nil)
+}
+
+func (v *vaultSeedStore) Update(ctx context.Context, s *bitcoinv1alpha1.Seed, stored *StoredSeed) error {
+	err := v.do(ctx, http.MethodPost, "data", s, map[string]interface{}{"data": stored.Data}, nil)
+	if err != nil {
+		return err
+	}
+	return v.writeMetadata(ctx, s, stored.Annotations, string(s.UID))
+}
+
+func (v *vaultSeedStore) Release(ctx context.Context, s *bitcoinv1alpha1.Seed, stored *StoredSeed) error {
+	return v.writeMetadata(ctx, s, stored.Annotations, "")
+}
+
+func (v *vaultSeedStore) Delete(ctx context.Context, s *bitcoinv1alpha1.Seed) error {
+	// Deleting the metadata permanently removes every version of the secret
+	return v.do(ctx, http.MethodDelete, "metadata", s, nil, nil)
+}
+
+func (v *vaultSeedStore) Describe(s *bitcoinv1alpha1.Seed) string {
+	return "vault secret " + v.mount + "/" + v.secretPath(s)
+}
+
+func (v *vaultSeedStore) writeMetadata(ctx context.Context, s *bitcoinv1alpha1.Seed, annotations map[string]string, owner string) error {
+	customMetadata := map[string]string{}
+	for key, value := range annotations {
+		customMetadata[key] = value
+	}
+	if owner != "" {
+		customMetadata[vaultOwnerMetadata] = owner
+	}
+	return v.do(ctx, http.MethodPost, "metadata", s, map[string]interface{}{"custom_metadata": customMetadata}, nil)
+}
+
+// secretPath returns the path of the Seed secret within the mount, <namespace>/<secretName> unless configured
+func (v *vaultSeedStore) secretPath(s *bitcoinv1alpha1.Seed) string {
+	if v.path != "" {
+		return v.path
+	}
+	return s.Namespace + "/" + s.Spec.SecretName
+}
+
+// do sends a request to the KV version 2 API and decodes the response into out when it is not nil
+func (v *vaultSeedStore) do(ctx context.Context, method string, api string, s *bitcoinv1alpha1.Seed, in interface{}, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/%s/%s", v.address, v.mount, api, v.secretPath(s))
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := vaultHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrSeedNotStored
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("vault returned %s for %s %s: %s", resp.Status, method, url, strings.TrimSpace(string(message)))
+	}
+
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
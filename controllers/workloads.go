@@ -0,0 +1,105 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bitcoinv1alpha1 "github.com/kiln-fired/kiln-operator/api/v1alpha1"
+)
+
+// specHash returns the hash of a desired spec. The API server adds defaults to every object, so a semantic comparison
+// only detects fields that are set in the desired spec, and the hash is what reveals removed containers, variables or
+// ports.
+func specHash(spec interface{}) (string, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// annotateSpecHash records the hash of the desired spec on a new object
+func annotateSpecHash(obj metav1.Object, spec interface{}) error {
+	hash, err := specHash(spec)
+	if err != nil {
+		return err
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[bitcoinv1alpha1.AppliedSpecHashAnnotation] = hash
+	obj.SetAnnotations(annotations)
+	return nil
+}
+
+// mergeStatefulSet copies the pod template and replicas of the desired StatefulSet into the found one and reports
+// whether the found StatefulSet changed. The selector, volumeClaimTemplates, serviceName and podManagementPolicy cannot
+// be changed after creation and are kept, as are annotations recorded by the operator such as the seed fingerprint.
+func mergeStatefulSet(found *appsv1.StatefulSet, desired *appsv1.StatefulSet) (bool, error) {
+	hash, err := specHash(desired.Spec)
+	if err != nil {
+		return false, err
+	}
+
+	if found.Annotations[bitcoinv1alpha1.AppliedSpecHashAnnotation] == hash &&
+		equality.Semantic.DeepDerivative(desired.Spec.Template, found.Spec.Template) &&
+		equality.Semantic.DeepDerivative(desired.Spec.Replicas, found.Spec.Replicas) {
+		return false, nil
+	}
+
+	found.Spec.Template = desired.Spec.Template
+	found.Spec.Replicas = desired.Spec.Replicas
+	return true, annotateSpecHash(found, desired.Spec)
+}
+
+// mergeService copies the ports, selector and type of the desired Service into the found one and reports whether the
+// found Service changed. The cluster IP assigned by the API server is kept.
+func mergeService(found *corev1.Service, desired *corev1.Service) (bool, error) {
+	hash, err := specHash(desired.Spec)
+	if err != nil {
+		return false, err
+	}
+
+	if found.Annotations[bitcoinv1alpha1.AppliedSpecHashAnnotation] == hash &&
+		equality.Semantic.DeepDerivative(desired.Spec.Ports, found.Spec.Ports) &&
+		equality.Semantic.DeepDerivative(desired.Spec.Selector, found.Spec.Selector) &&
+		equality.Semantic.DeepDerivative(desired.Labels, found.Labels) {
+		return false, nil
+	}
+
+	found.Spec.Ports = desired.Spec.Ports
+	found.Spec.Selector = desired.Spec.Selector
+	found.Spec.Type = desired.Spec.Type
+	found.Spec.PublishNotReadyAddresses = desired.Spec.PublishNotReadyAddresses
+	if found.Labels == nil {
+		found.Labels = map[string]string{}
+	}
+	for key, value := range desired.Labels {
+		found.Labels[key] = value
+	}
+	return true, annotateSpecHash(found, desired.Spec)
+}